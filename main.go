@@ -2,18 +2,48 @@ package main
 
 import (
 	"api-file/main/src/cache"
+	"api-file/main/src/commands"
 	"api-file/main/src/configs"
 	"api-file/main/src/database"
+	"api-file/main/src/metrics"
 	"api-file/main/src/middleware"
 	"api-file/main/src/routes"
+	"api-file/main/src/services"
+	"api-file/main/src/tracing"
+	"context"
 	"fmt"
 	routeutil "github.com/ArnoldPMolenaar/api-utils/routes"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
 	"github.com/gofiber/fiber/v2"
+	"log"
 	"os"
+	"sync"
+	"time"
+)
+
+// Waits between the attempts to connect to the database and the cache.
+const (
+	connectRetryWait    = time.Second
+	connectMaxRetryWait = 30 * time.Second
 )
 
 func main() {
+	// Run an administrative command instead of the server.
+	if len(os.Args) > 1 {
+		if err := commands.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Set up tracing, which exports nothing unless an exporter is configured.
+	shutdownTracing, err := tracing.OpenTracerProvider(context.Background())
+	if err != nil {
+		panic(fmt.Sprintf("Could not set up tracing: %v", err))
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	// Define Fiber config.
 	config := configs.FiberConfig()
 
@@ -23,16 +53,26 @@ func main() {
 	// Register Fiber's middleware for app.
 	middleware.FiberMiddleware(app)
 
-	// Open database connection.
-	if err := database.OpenDBConnection(); err != nil {
-		panic(fmt.Sprintf("Could not connect to the database: %v", err))
-	}
+	// Open the database and Valkey connections in the background and retry them until they succeed,
+	// so the service starts during an outage and reports that it is not ready.
+	var connections sync.WaitGroup
+	connections.Add(2)
+	go connect(&connections, "database", database.OpenDBConnection)
+	go connect(&connections, "cache", cache.OpenValkeyConnection)
+	defer func() {
+		if cache.IsConnected() {
+			cache.Valkey.Close()
+		}
+	}()
 
-	// Open Valkey connection.
-	if err := cache.OpenValkeyConnection(); err != nil {
-		panic(fmt.Sprintf("Could not connect to the cache: %v", err))
-	}
-	defer cache.Valkey.Close()
+	// Report the storage usage of the storage paths as metrics.
+	metrics.RegisterStorageUsage(services.GetStorageUsages)
+
+	// Rescan the stored documents for viruses in the background, once they can be read.
+	go func() {
+		connections.Wait()
+		services.StartVirusRescans()
+	}()
 
 	// Register a private routes_util for app.
 	routes.PrivateRoutes(app)
@@ -40,6 +80,12 @@ func main() {
 	routes.WebSocketRoutes(app)
 	// Register a public routes_util for app.
 	routes.PublicRoutes(app)
+	// Register the metrics route for app.
+	routes.MetricsRoutes(app)
+	// Register the health routes for app.
+	routes.HealthRoutes(app)
+	// Register the routes of the OpenAPI document for app.
+	routes.DocsRoutes(app)
 	// Register route for 404 Error.
 	routeutil.NotFoundRoute(app)
 
@@ -50,3 +96,20 @@ func main() {
 		utils.StartServerWithGracefulShutdown(app)
 	}
 }
+
+// connect opens a connection and retries it with a growing wait until it succeeds.
+func connect(connections *sync.WaitGroup, name string, open func() error) {
+	defer connections.Done()
+
+	wait := connectRetryWait
+	for {
+		err := open()
+		if err == nil {
+			return
+		}
+
+		log.Printf("Could not connect to the %s, retrying in %s: %v", name, wait, err)
+		time.Sleep(wait)
+		wait = min(wait*2, connectMaxRetryWait)
+	}
+}
@@ -1,12 +1,18 @@
 package cache
 
 import (
+	"api-file/main/src/tracing"
+	"sync/atomic"
+
 	"github.com/ArnoldPMolenaar/api-utils/cache"
 	"github.com/valkey-io/valkey-go"
 )
 
 var Valkey valkey.Client
 
+// connected is set when Valkey can be used.
+var connected atomic.Bool
+
 // OpenValkeyConnection Start a new valkey connection.
 func OpenValkeyConnection() error {
 	// Open connection to valkey.
@@ -15,8 +21,14 @@ func OpenValkeyConnection() error {
 		return err
 	}
 
-	// Set the global Valkey variable.
-	Valkey = client
+	// Set the global Valkey variable, which traces the commands.
+	Valkey = tracing.WrapValkey(client)
+	connected.Store(true)
 
 	return nil
 }
+
+// IsConnected checks if the Valkey connection is opened.
+func IsConnected() bool {
+	return connected.Load()
+}
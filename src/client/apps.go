@@ -0,0 +1,44 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateApp method to create a new app.
+func (c *Client) CreateApp(ctx context.Context, request requests.CreateApp) (*responses.App, error) {
+	response := &responses.App{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/apps", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetRateLimits method to get the rate limits of an app.
+func (c *Client) GetRateLimits(ctx context.Context, app string) ([]responses.RateLimit, error) {
+	response := make([]responses.RateLimit, 0)
+	if err := c.do(ctx, http.MethodGet, rateLimitsPath(app), nil, nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateRateLimits method to replace the rate limits of an app.
+func (c *Client) UpdateRateLimits(ctx context.Context, app string, request requests.UpdateRateLimits) ([]responses.RateLimit, error) {
+	response := make([]responses.RateLimit, 0)
+	if err := c.do(ctx, http.MethodPut, rateLimitsPath(app), nil, request, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// rateLimitsPath gets the path of the rate limits of an app.
+func rateLimitsPath(app string) string {
+	return basePath + "/apps/" + url.PathEscape(app) + "/rate-limits"
+}
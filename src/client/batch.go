@@ -0,0 +1,19 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+)
+
+// RunBatch method to delete, restore, move or update several images, documents and folders at once.
+// The result of each operation is in the response, also when it failed.
+func (c *Client) RunBatch(ctx context.Context, request requests.Batch) (*responses.Batch, error) {
+	response := &responses.Batch{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/batch", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	apierrors "api-file/main/src/errors"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/google/uuid"
+)
+
+// basePath is the path of the version of the API that the client calls.
+const basePath = "/v1"
+
+// Headers that the client sends.
+const (
+	headerMachineKey     = "x-machine-key"
+	headerIdempotencyKey = "Idempotency-Key"
+	headerSharePassword  = "X-Share-Password"
+)
+
+// Defaults of the retries.
+const (
+	defaultRetries   = 3
+	defaultRetryWait = 500 * time.Millisecond
+	maxRetryWait     = 30 * time.Second
+)
+
+// errBodyConsumed is returned when a body can not be sent again for a retry.
+var errBodyConsumed = errors.New("the body can not be read again")
+
+// Client calls the routes of API-File. The private routes need the machine key.
+type Client struct {
+	baseURL    string
+	machineKey string
+	httpClient *http.Client
+	retries    int
+	retryWait  time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// Error is an error response of API-File.
+type Error struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+// Error method to describe the error response.
+func (e *Error) Error() string {
+	return fmt.Sprintf("api-file: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// request is a call to a route. The body is created again for each attempt.
+// The statuses are error statuses that are returned as a response, like the 503 of an unready service.
+type request struct {
+	method   string
+	path     string
+	query    url.Values
+	header   http.Header
+	body     func() (io.Reader, error)
+	statuses []int
+}
+
+// New func to create a client of the service at the base URL, like https://files.example.com.
+func New(baseURL, machineKey string, options ...Option) *Client {
+	client := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		machineKey: machineKey,
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		retryWait:  defaultRetryWait,
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// WithHTTPClient func to send the requests with the HTTP client, for its timeouts and transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries func to retry a failed request, the wait doubles after each attempt.
+// Retries of POST and PUT requests are replayed by the service with their idempotency key.
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+		c.retryWait = wait
+	}
+}
+
+// do sends a request with a JSON body and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	r := request{method: method, path: path, query: query}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		r.body = func() (io.Reader, error) { return bytes.NewReader(body), nil }
+	}
+
+	response, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return decode(response, out)
+}
+
+// send sends a request and retries it on network errors, rate limits and unavailable responses.
+// The body of the successful response is left open for the caller.
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	idempotencyKey := ""
+	if r.method == http.MethodPost || r.method == http.MethodPut {
+		idempotencyKey = uuid.NewString()
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if r.body != nil {
+			var err error
+			if body, err = r.body(); err != nil {
+				if lastErr != nil {
+					return nil, lastErr
+				}
+				return nil, err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, r.method, c.url(r.path, r.query), body)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range r.header {
+			req.Header[key] = values
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.machineKey != "" {
+			req.Header.Set(headerMachineKey, c.machineKey)
+		}
+		if idempotencyKey != "" {
+			req.Header.Set(headerIdempotencyKey, idempotencyKey)
+		}
+
+		wait := c.retryWait << min(attempt, 10)
+		response, err := c.httpClient.Do(req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+		case response.StatusCode < http.StatusBadRequest || slices.Contains(r.statuses, response.StatusCode):
+			return response, nil
+		default:
+			apiErr := decodeError(response)
+			if !retryable(apiErr) {
+				return nil, apiErr
+			}
+			lastErr = apiErr
+			wait = max(wait, apiErr.RetryAfter)
+		}
+
+		if attempt >= c.retries {
+			return nil, lastErr
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(wait, maxRetryWait)):
+		}
+	}
+}
+
+// url creates the URL of the path with the query.
+func (c *Client) url(path string, query url.Values) string {
+	if len(query) == 0 {
+		return c.baseURL + path
+	}
+
+	return c.baseURL + path + "?" + query.Encode()
+}
+
+// retryable checks if the request can succeed when it is sent again.
+func retryable(err *Error) bool {
+	switch err.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return err.Code == apierrors.IdempotencyPending
+	default:
+		return false
+	}
+}
+
+// decode decodes the JSON body of the response into out and closes it.
+func decode(response *http.Response, out interface{}) error {
+	defer response.Body.Close()
+
+	if out == nil || response.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// decodeError decodes the error response and closes its body.
+// The message of a validator error is a list of fields, which is kept as JSON.
+func decodeError(response *http.Response) *Error {
+	defer response.Body.Close()
+
+	apiErr := &Error{Status: response.StatusCode, Code: errorutil.InternalServerError, Message: http.StatusText(response.StatusCode)}
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var body struct {
+		Code    string          `json:"code"`
+		Message json.RawMessage `json:"message"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil || body.Code == "" {
+		return apiErr
+	}
+
+	apiErr.Code = body.Code
+	if err := json.Unmarshal(body.Message, &apiErr.Message); err != nil {
+		apiErr.Message = string(body.Message)
+	}
+
+	return apiErr
+}
+
+// idPath creates the path of an item by its ID, with the segments after it.
+func idPath(group string, id uint, segments ...string) string {
+	path := basePath + "/" + group + "/" + strconv.FormatUint(uint64(id), 10)
+	for _, segment := range segments {
+		path += "/" + segment
+	}
+
+	return path
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+)
+
+// CreateDocument method to create a new document. The data of the upload is streamed instead of the data of the request.
+func (c *Client) CreateDocument(ctx context.Context, request requests.CreateDocument, upload *Upload) (*responses.Document, error) {
+	if upload != nil {
+		request.Data = ""
+	}
+
+	response := &responses.Document{}
+	if err := c.doUpload(ctx, http.MethodPost, basePath+"/documents", request, upload, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// MoveDocuments method to move several documents into a folder at once.
+func (c *Client) MoveDocuments(ctx context.Context, request requests.MoveDocuments) ([]responses.Document, error) {
+	response := make([]responses.Document, 0)
+	if err := c.do(ctx, http.MethodPut, basePath+"/documents/move", nil, request, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetDocument method to get a specific document.
+func (c *Client) GetDocument(ctx context.Context, id uint) (*responses.Document, error) {
+	response := &responses.Document{}
+	if err := c.do(ctx, http.MethodGet, idPath("documents", id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateDocument method to update a specific document. The data of the upload is streamed instead of the data of the request.
+func (c *Client) UpdateDocument(ctx context.Context, id uint, request requests.UpdateDocument, upload *Upload) (*responses.Document, error) {
+	if upload != nil {
+		request.Data = ""
+	}
+
+	response := &responses.Document{}
+	if err := c.doUpload(ctx, http.MethodPut, idPath("documents", id), request, upload, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteDocument method to delete a specific document, it can be restored.
+func (c *Client) DeleteDocument(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("documents", id), nil, nil, nil)
+}
+
+// DeleteDocumentHard method to delete a specific document with its files for ever.
+func (c *Client) DeleteDocumentHard(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("documents", id, "hard"), nil, nil, nil)
+}
+
+// RestoreDocument method to restore a deleted document.
+func (c *Client) RestoreDocument(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodPut, idPath("documents", id, "restore"), nil, nil, nil)
+}
+
+// CopyDocument method to copy a document with its previews into a folder.
+func (c *Client) CopyDocument(ctx context.Context, id uint, request requests.CopyDocument) (*responses.Document, error) {
+	response := &responses.Document{}
+	if err := c.do(ctx, http.MethodPost, idPath("documents", id, "copy"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// MoveDocument method to move a document with its previews into a folder.
+func (c *Client) MoveDocument(ctx context.Context, id uint, request requests.MoveDocument) (*responses.Document, error) {
+	response := &responses.Document{}
+	if err := c.do(ctx, http.MethodPut, idPath("documents", id, "move"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// File is a downloaded file, its body is streamed and must be closed.
+type File struct {
+	io.ReadCloser
+	ContentType   string
+	ContentLength int64
+}
+
+// Shared is what a share link shares: a folder listing, or the file of an image or document.
+type Shared struct {
+	Folder *responses.SharedFolder
+	File   *File
+}
+
+// GetImageFile method to get the public file of an image.
+func (c *Client) GetImageFile(ctx context.Context, id uint) (*File, error) {
+	return c.download(ctx, idPath("image", id), nil, nil)
+}
+
+// GetImageFilePoster method to get the poster of an animated image.
+func (c *Client) GetImageFilePoster(ctx context.Context, id uint) (*File, error) {
+	return c.download(ctx, idPath("image", id, "poster"), nil, nil)
+}
+
+// GetImageFileSize method to get a web size of an image.
+func (c *Client) GetImageFileSize(ctx context.Context, id uint, size enums.Size) (*File, error) {
+	return c.download(ctx, idPath("image", id, size.String()), nil, nil)
+}
+
+// GetImageFileCrop method to get a crop of an image.
+func (c *Client) GetImageFileCrop(ctx context.Context, id uint, crop enums.Crop) (*File, error) {
+	return c.download(ctx, idPath("image", id, "crop", crop.String()), nil, nil)
+}
+
+// GetDocumentFile method to get the file of a document.
+func (c *Client) GetDocumentFile(ctx context.Context, id uint) (*File, error) {
+	return c.download(ctx, idPath("document", id), nil, nil)
+}
+
+// GetDocumentThumbnail method to get the thumbnail of the first page of a document.
+func (c *Client) GetDocumentThumbnail(ctx context.Context, id uint) (*File, error) {
+	return c.download(ctx, idPath("document", id, "thumbnail"), nil, nil)
+}
+
+// GetDocumentPreview method to get the preview of a page of a document, the first page is 1.
+func (c *Client) GetDocumentPreview(ctx context.Context, id uint, page int) (*File, error) {
+	return c.download(ctx, idPath("document", id, "preview", strconv.Itoa(page)), nil, nil)
+}
+
+// GetShared method to get what a share link shares, the password is empty for a link without password.
+// A JSON response is the listing of a shared folder, unless it is a shared JSON document.
+func (c *Client) GetShared(ctx context.Context, token, password string) (*Shared, error) {
+	file, err := c.download(ctx, sharePath(token), nil, shareHeader(password))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(file.ContentType, "application/json") {
+		return &Shared{File: file}, nil
+	}
+
+	defer file.Close()
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &responses.SharedFolder{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(folder); err != nil {
+		file.ReadCloser = io.NopCloser(bytes.NewReader(body))
+		return &Shared{File: file}, nil
+	}
+
+	return &Shared{Folder: folder}, nil
+}
+
+// GetSharedFolderZip method to download a shared folder as a ZIP archive.
+func (c *Client) GetSharedFolderZip(ctx context.Context, token, password string) (*File, error) {
+	return c.download(ctx, sharePath(token, "zip"), nil, shareHeader(password))
+}
+
+// GetSharedFolderImage method to download an image inside a shared folder.
+func (c *Client) GetSharedFolderImage(ctx context.Context, token string, id uint, password string) (*File, error) {
+	return c.download(ctx, sharePath(token, "image", strconv.FormatUint(uint64(id), 10)), nil, shareHeader(password))
+}
+
+// GetSharedFolderDocument method to download a document inside a shared folder.
+func (c *Client) GetSharedFolderDocument(ctx context.Context, token string, id uint, password string) (*File, error) {
+	return c.download(ctx, sharePath(token, "document", strconv.FormatUint(uint64(id), 10)), nil, shareHeader(password))
+}
+
+// download gets a file, its body is left open for the caller.
+func (c *Client) download(ctx context.Context, path string, query url.Values, header http.Header) (*File, error) {
+	response, err := c.send(ctx, request{method: http.MethodGet, path: path, query: query, header: header})
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		ReadCloser:    response.Body,
+		ContentType:   response.Header.Get("Content-Type"),
+		ContentLength: response.ContentLength,
+	}, nil
+}
+
+// sharePath gets the path of a share link, with the segments after it.
+func sharePath(token string, segments ...string) string {
+	path := basePath + "/share/" + url.PathEscape(token)
+	for _, segment := range segments {
+		path += "/" + segment
+	}
+
+	return path
+}
+
+// shareHeader gets the header with the password of a share link.
+func shareHeader(password string) http.Header {
+	if password == "" {
+		return nil
+	}
+
+	return http.Header{headerSharePassword: {password}}
+}
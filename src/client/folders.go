@@ -0,0 +1,59 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+)
+
+// CreateFolder method to create a new folder.
+func (c *Client) CreateFolder(ctx context.Context, request requests.CreateFolder) (*responses.Folder, error) {
+	response := &responses.Folder{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/folders", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetFolder method to get a specific folder with its folders, images and documents.
+func (c *Client) GetFolder(ctx context.Context, id uint) (*responses.FolderPreload, error) {
+	response := &responses.FolderPreload{}
+	if err := c.do(ctx, http.MethodGet, idPath("folders", id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateFolder method to update a specific folder.
+func (c *Client) UpdateFolder(ctx context.Context, id uint, request requests.UpdateFolder) (*responses.Folder, error) {
+	response := &responses.Folder{}
+	if err := c.do(ctx, http.MethodPut, idPath("folders", id), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteFolder method to delete a specific folder.
+func (c *Client) DeleteFolder(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("folders", id), nil, nil, nil)
+}
+
+// RestoreFolder method to restore a deleted folder.
+func (c *Client) RestoreFolder(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodPut, idPath("folders", id, "restore"), nil, nil, nil)
+}
+
+// CopyFolder method to copy a folder with its content into a folder.
+// The files are copied in a background job, which is followed with GetJob or SubscribeProgress.
+func (c *Client) CopyFolder(ctx context.Context, id uint, request requests.CopyFolder) (*responses.FolderCopy, error) {
+	response := &responses.FolderCopy{}
+	if err := c.do(ctx, http.MethodPost, idPath("folders", id, "copy"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
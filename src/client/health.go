@@ -0,0 +1,52 @@
+package client
+
+import (
+	"api-file/main/src/dto/responses"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// GetLiveness method to check if the service runs.
+func (c *Client) GetLiveness(ctx context.Context) (*responses.Health, error) {
+	response := &responses.Health{}
+	if err := c.do(ctx, http.MethodGet, "/health/live", nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetReadiness method to check if the service can handle requests, with the result of each check.
+// An unready service is not retried, its checks are returned with an error.
+func (c *Client) GetReadiness(ctx context.Context) (*responses.Health, error) {
+	response, err := c.send(ctx, request{method: http.MethodGet, path: "/health/ready", statuses: []int{http.StatusServiceUnavailable}})
+	if err != nil {
+		return nil, err
+	}
+
+	health := &responses.Health{}
+	if err := decode(response, health); err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusServiceUnavailable {
+		return health, &Error{Status: response.StatusCode, Message: "The service is not ready."}
+	}
+
+	return health, nil
+}
+
+// GetMetrics method to get the Prometheus metrics in their text format.
+func (c *Client) GetMetrics(ctx context.Context) (*File, error) {
+	return c.download(ctx, "/metrics", nil, nil)
+}
+
+// GetOpenAPI method to get the OpenAPI document of the routes.
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	response := json.RawMessage{}
+	if err := c.do(ctx, http.MethodGet, basePath+"/openapi.json", nil, nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateImage method to create a new image. The data of the upload is streamed instead of the data of the request.
+func (c *Client) CreateImage(ctx context.Context, request requests.CreateImage, upload *Upload) (*responses.Image, error) {
+	if upload != nil {
+		request.Data = ""
+	}
+
+	response := &responses.Image{}
+	if err := c.doUpload(ctx, http.MethodPost, basePath+"/images", request, upload, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RegenerateImages method to regenerate the web sizes of images in a background job.
+func (c *Client) RegenerateImages(ctx context.Context, request requests.RegenerateImages) (*responses.Job, error) {
+	response := &responses.Job{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/images/regenerate", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GenerateImagePlaceholders method to generate the placeholders of images in a background job.
+func (c *Client) GenerateImagePlaceholders(ctx context.Context, request requests.GenerateImagePlaceholders) (*responses.Job, error) {
+	response := &responses.Job{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/images/placeholders", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// MoveImages method to move several images into a folder at once.
+func (c *Client) MoveImages(ctx context.Context, request requests.MoveImages) ([]responses.Image, error) {
+	response := make([]responses.Image, 0)
+	if err := c.do(ctx, http.MethodPut, basePath+"/images/move", nil, request, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetImage method to get a specific image.
+func (c *Client) GetImage(ctx context.Context, id uint) (*responses.Image, error) {
+	response := &responses.Image{}
+	if err := c.do(ctx, http.MethodGet, idPath("images", id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateImage method to update a specific image. The data of the upload is streamed instead of the data of the request.
+func (c *Client) UpdateImage(ctx context.Context, id uint, request requests.UpdateImage, upload *Upload) (*responses.Image, error) {
+	if upload != nil {
+		data := ""
+		request.Data = &data
+	}
+
+	response := &responses.Image{}
+	if err := c.doUpload(ctx, http.MethodPut, idPath("images", id), request, upload, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteImage method to delete a specific image, it can be restored.
+func (c *Client) DeleteImage(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("images", id), nil, nil, nil)
+}
+
+// DeleteImageHard method to delete a specific image with its files for ever.
+func (c *Client) DeleteImageHard(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("images", id, "hard"), nil, nil, nil)
+}
+
+// RestoreImage method to restore a deleted image.
+func (c *Client) RestoreImage(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodPut, idPath("images", id, "restore"), nil, nil, nil)
+}
+
+// GetImageFileOriginal method to get the original file of an image, without its edit or watermark.
+// The file must be closed.
+func (c *Client) GetImageFileOriginal(ctx context.Context, id uint) (*File, error) {
+	return c.download(ctx, idPath("images", id, "original"), nil, nil)
+}
+
+// EditImage method to edit an image without touching its original.
+func (c *Client) EditImage(ctx context.Context, id uint, request requests.EditImage) (*responses.Image, error) {
+	response := &responses.Image{}
+	if err := c.do(ctx, http.MethodPut, idPath("images", id, "edit"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RevertImageEdit method to revert the edit of an image, the web sizes are rendered again with the quality.
+// A quality of 0 uses the default quality.
+func (c *Client) RevertImageEdit(ctx context.Context, id uint, quality int) (*responses.Image, error) {
+	var query url.Values
+	if quality > 0 {
+		query = url.Values{"quality": {strconv.Itoa(quality)}}
+	}
+
+	response := &responses.Image{}
+	if err := c.do(ctx, http.MethodDelete, idPath("images", id, "edit"), query, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CopyImage method to copy an image with its files into a folder.
+func (c *Client) CopyImage(ctx context.Context, id uint, request requests.CopyImage) (*responses.Image, error) {
+	response := &responses.Image{}
+	if err := c.do(ctx, http.MethodPost, idPath("images", id, "copy"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// MoveImage method to move an image with its files into a folder.
+func (c *Client) MoveImage(ctx context.Context, id uint, request requests.MoveImage) (*responses.Image, error) {
+	response := &responses.Image{}
+	if err := c.do(ctx, http.MethodPut, idPath("images", id, "move"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// GetJob method to get the state of a background job.
+func (c *Client) GetJob(ctx context.Context, id string) (*responses.Job, error) {
+	response := &responses.Job{}
+	if err := c.do(ctx, http.MethodGet, basePath+"/jobs/"+url.PathEscape(id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
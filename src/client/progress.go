@@ -0,0 +1,144 @@
+package client
+
+import (
+	"api-file/main/src/dto/responses"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fasthttp/websocket"
+)
+
+// progressBuffer is the number of progress events that are kept when they are not received yet.
+const progressBuffer = 64
+
+// Progress is a subscription on the progress of the uploads and jobs, which the service sends to every subscriber.
+// The events are closed when the subscription ends, Err tells why.
+type Progress struct {
+	conn   *websocket.Conn
+	events chan responses.FileProgress
+	done   chan struct{}
+	once   sync.Once
+	err    error
+	stop   func() bool
+}
+
+// progressMessage is a message of the WebSocket, which is a progress event or the error of a failed handshake.
+type progressMessage struct {
+	responses.FileProgress
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// Handshake method to get a code to connect to the progress WebSocket with the storage path of the app.
+func (c *Client) Handshake(ctx context.Context, app string, storagePathID uint) (*responses.Handshake, error) {
+	query := url.Values{"app": {app}, "id": {strconv.FormatUint(uint64(storagePathID), 10)}}
+
+	response := &responses.Handshake{}
+	if err := c.do(ctx, http.MethodGet, basePath+"/handshake", query, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// SubscribeProgress method to receive the progress of the uploads and jobs after a handshake.
+// The subscription ends when the context is done, when it is closed or when the connection is lost.
+func (c *Client) SubscribeProgress(ctx context.Context, app string, storagePathID uint) (*Progress, error) {
+	handshake, err := c.Handshake(ctx, app, storagePathID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"app":  {app},
+		"id":   {strconv.FormatUint(uint64(storagePathID), 10)},
+		"code": {handshake.Code},
+	}
+	address := c.url(basePath+"/ws/progress", query)
+	if strings.HasPrefix(address, "http") {
+		address = "ws" + strings.TrimPrefix(address, "http")
+	}
+
+	conn, response, err := websocket.DefaultDialer.DialContext(ctx, address, nil)
+	if err != nil {
+		if response != nil && response.StatusCode >= http.StatusBadRequest {
+			return nil, decodeError(response)
+		}
+		return nil, err
+	}
+
+	progress := &Progress{
+		conn:   conn,
+		events: make(chan responses.FileProgress, progressBuffer),
+		done:   make(chan struct{}),
+	}
+	progress.stop = context.AfterFunc(ctx, func() {
+		progress.close(ctx.Err())
+	})
+	go progress.read()
+
+	return progress, nil
+}
+
+// Events method to receive the progress events, the channel is closed when the subscription ends.
+func (p *Progress) Events() <-chan responses.FileProgress {
+	return p.events
+}
+
+// Err method to get why the subscription ended, it is nil when it is closed.
+// It is only set after the events are closed.
+func (p *Progress) Err() error {
+	return p.err
+}
+
+// Close method to end the subscription.
+func (p *Progress) Close() error {
+	p.close(nil)
+
+	return nil
+}
+
+// close ends the subscription with the error, only the first error is kept.
+func (p *Progress) close(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+		_ = p.conn.Close()
+	})
+}
+
+// read receives the messages until the subscription ends.
+func (p *Progress) read() {
+	defer close(p.events)
+	defer p.stop()
+
+	for {
+		_, data, err := p.conn.ReadMessage()
+		if err != nil {
+			p.close(err)
+			return
+		}
+
+		message := progressMessage{}
+		if err := json.Unmarshal(data, &message); err != nil {
+			p.close(err)
+			return
+		}
+		if message.Error != "" {
+			p.close(&Error{Status: http.StatusSwitchingProtocols, Code: message.Code, Message: message.Message})
+			return
+		}
+
+		select {
+		case p.events <- message.FileProgress:
+		case <-p.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"net/http"
+)
+
+// CreateShareLink method to share an image, document or folder with a link.
+func (c *Client) CreateShareLink(ctx context.Context, request requests.CreateShareLink) (*responses.ShareLink, error) {
+	response := &responses.ShareLink{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/share-links", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetShareLink method to get a specific share link.
+func (c *Client) GetShareLink(ctx context.Context, id uint) (*responses.ShareLink, error) {
+	response := &responses.ShareLink{}
+	if err := c.do(ctx, http.MethodGet, idPath("share-links", id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateShareLink method to replace the password, expiry date and download limit of a share link.
+// A rule that is left out of the request is removed.
+func (c *Client) UpdateShareLink(ctx context.Context, id uint, request requests.UpdateShareLink) (*responses.ShareLink, error) {
+	response := &responses.ShareLink{}
+	if err := c.do(ctx, http.MethodPut, idPath("share-links", id), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RevokeShareLink method to revoke a share link.
+func (c *Client) RevokeShareLink(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("share-links", id), nil, nil, nil)
+}
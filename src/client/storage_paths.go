@@ -0,0 +1,151 @@
+package client
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Page is a page of the pagination of api-utils.
+type Page[T any] struct {
+	Limit     int `json:"limit"`
+	Page      int `json:"page"`
+	PageCount int `json:"pageCount"`
+	Total     int `json:"total"`
+	Result    []T `json:"result"`
+}
+
+// StoragePathQuery filters and sorts the storage paths with the params of the pagination of api-utils,
+// like SearchEq "app:my-app" or SortBy "id:desc". The columns are id, app, path and limit.
+type StoragePathQuery struct {
+	Limit         int
+	SearchLike    string
+	SearchEq      string
+	SearchLikeOr  string
+	SearchEqOr    string
+	SearchIn      string
+	SearchBetween string
+	SortBy        string
+}
+
+// values gets the query params of the page.
+func (q StoragePathQuery) values(page int) url.Values {
+	values := url.Values{}
+	values.Set("page", strconv.Itoa(page))
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	for key, value := range map[string]string{
+		"searchLike":    q.SearchLike,
+		"searchEq":      q.SearchEq,
+		"searchLikeOr":  q.SearchLikeOr,
+		"searchEqOr":    q.SearchEqOr,
+		"searchIn":      q.SearchIn,
+		"searchBetween": q.SearchBetween,
+		"sortBy":        q.SortBy,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	return values
+}
+
+// GetStoragePaths method to get a page of the storage paths, the first page is 1.
+func (c *Client) GetStoragePaths(ctx context.Context, page int, query StoragePathQuery) (*Page[responses.AppStoragePathPaginate], error) {
+	response := &Page[responses.AppStoragePathPaginate]{}
+	if err := c.do(ctx, http.MethodGet, basePath+"/storage-paths", query.values(page), nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// StoragePaths method to iterate over the storage paths of every page.
+// The iteration stops after the first error.
+func (c *Client) StoragePaths(ctx context.Context, query StoragePathQuery) iter.Seq2[responses.AppStoragePathPaginate, error] {
+	return func(yield func(responses.AppStoragePathPaginate, error) bool) {
+		for page := 1; ; page++ {
+			response, err := c.GetStoragePaths(ctx, page, query)
+			if err != nil {
+				yield(responses.AppStoragePathPaginate{}, err)
+				return
+			}
+
+			for _, storagePath := range response.Result {
+				if !yield(storagePath, nil) {
+					return
+				}
+			}
+			if page >= response.PageCount || len(response.Result) == 0 {
+				return
+			}
+		}
+	}
+}
+
+// CreateStoragePath method to create a new storage path.
+func (c *Client) CreateStoragePath(ctx context.Context, request requests.CreateAppStoragePath) (*responses.AppStoragePath, error) {
+	response := &responses.AppStoragePath{}
+	if err := c.do(ctx, http.MethodPost, basePath+"/storage-paths", nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetStoragePathIDByApp method to get the ID of the storage path of an app.
+func (c *Client) GetStoragePathIDByApp(ctx context.Context, app string) (*responses.AppStoragePathID, error) {
+	response := &responses.AppStoragePathID{}
+	if err := c.do(ctx, http.MethodGet, basePath+"/storage-paths/id", url.Values{"app": {app}}, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetStoragePath method to get a specific storage path.
+func (c *Client) GetStoragePath(ctx context.Context, id uint) (*responses.AppStoragePath, error) {
+	response := &responses.AppStoragePath{}
+	if err := c.do(ctx, http.MethodGet, idPath("storage-paths", id), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// UpdateStoragePath method to update a specific storage path.
+func (c *Client) UpdateStoragePath(ctx context.Context, id uint, request requests.UpdateAppStoragePath) (*responses.AppStoragePath, error) {
+	response := &responses.AppStoragePath{}
+	if err := c.do(ctx, http.MethodPut, idPath("storage-paths", id), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CheckStoragePath method to report the inconsistencies between the files and the database of a storage path.
+func (c *Client) CheckStoragePath(ctx context.Context, id uint) (*responses.Fsck, error) {
+	response := &responses.Fsck{}
+	if err := c.do(ctx, http.MethodGet, idPath("storage-paths", id, "fsck"), nil, nil, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RepairStoragePath method to repair the inconsistencies between the files and the database of a storage path.
+func (c *Client) RepairStoragePath(ctx context.Context, id uint, request requests.RepairAppStoragePath) (*responses.Fsck, error) {
+	response := &responses.Fsck{}
+	if err := c.do(ctx, http.MethodPost, idPath("storage-paths", id, "fsck"), nil, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
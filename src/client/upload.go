@@ -0,0 +1,124 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// mimeHeaderSize is the number of bytes that are read to detect the MIME type of an upload.
+const mimeHeaderSize = 3072
+
+// dataField is the data of a request that is marshalled without data, it is replaced by the stream.
+var dataField = []byte(`"data":""`)
+
+// Upload is the data of a file that is streamed to the service, without holding its base64 in memory.
+// An upload is only retried when its reader is an io.Seeker.
+type Upload struct {
+	Reader io.Reader
+	// MimeType of the data, it is detected from the data when it is empty.
+	MimeType string
+}
+
+// uploadBody creates the body of a request with the data of the upload as base64 data URI.
+// The request is marshalled with empty data.
+func uploadBody(in interface{}, upload *Upload) (func() (io.Reader, error), error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	index := bytes.Index(body, dataField)
+	if index == -1 {
+		return nil, fmt.Errorf("the request has no data field")
+	}
+	prefix := body[:index+len(dataField)-1]
+	suffix := body[index+len(dataField)-1:]
+
+	start := int64(0)
+	seeker, ok := upload.Reader.(io.Seeker)
+	if ok {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	sent := false
+	return func() (io.Reader, error) {
+		if sent {
+			if seeker == nil {
+				return nil, errBodyConsumed
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		sent = true
+
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(writeUpload(writer, prefix, suffix, upload))
+		}()
+
+		return reader, nil
+	}, nil
+}
+
+// writeUpload writes the request with the data of the upload encoded as base64 data URI.
+func writeUpload(w io.Writer, prefix, suffix []byte, upload *Upload) error {
+	data := bufio.NewReaderSize(upload.Reader, mimeHeaderSize)
+
+	mimeType := upload.MimeType
+	if mimeType == "" {
+		header, err := data.Peek(mimeHeaderSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+		mimeType, _, _ = strings.Cut(mimetype.Detect(header).String(), ";")
+	}
+
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "data:"+mimeType+";base64,"); err != nil {
+		return err
+	}
+
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, data); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(suffix)
+	return err
+}
+
+// doUpload sends a request with the data of the upload and decodes the JSON response into out.
+// Without an upload the data of the request is sent.
+func (c *Client) doUpload(ctx context.Context, method, path string, in interface{}, upload *Upload, out interface{}) error {
+	if upload == nil {
+		return c.do(ctx, method, path, nil, in, out)
+	}
+
+	body, err := uploadBody(in, upload)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.send(ctx, request{method: method, path: path, body: body})
+	if err != nil {
+		return err
+	}
+
+	return decode(response, out)
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is an administrative subcommand of the binary.
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+// commands maps the subcommand names to their implementation.
+var commands = map[string]command{
+	"migrate":               {"Migrate the database schema.", Migrate},
+	"fsck":                  {"Check and repair the files of the storage paths.", Fsck},
+	"regenerate-sizes":      {"Create the web sizes and crops of the images again.", RegenerateSizes},
+	"generate-placeholders": {"Create the BlurHash, LQIP and dominant color of the images.", GeneratePlaceholders},
+	"generate-previews":     {"Create the thumbnails and page counts of the documents.", GeneratePreviews},
+	"rotate-keys":           {"Wrap the data keys of the storage paths with the current master key.", RotateKeys},
+	"rescan":                {"Scan the stored documents for viruses again.", Rescan},
+	"import-dir":            {"Import the files of a local directory into a folder.", ImportDir},
+	"purge-trash":           {"Delete the trashed images, documents and folders for ever.", PurgeTrash},
+	"usage":                 {"Show the used space of the storage paths.", Usage},
+}
+
+// Run executes the subcommand with its arguments.
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "help" {
+		printHelp()
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printHelp()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	if err := cmd.run(args[1:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+
+	return nil
+}
+
+// printHelp prints the available subcommands.
+func printHelp() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Usage: api-file [command] [flags]")
+	fmt.Println()
+	fmt.Println("Without a command the server is started. Commands:")
+	for _, name := range names {
+		fmt.Printf("  %-18s %s\n", name, commands[name].description)
+	}
+	fmt.Println()
+	fmt.Println("Use \"api-file [command] -h\" for the flags of a command.")
+}
+
+// newFlagSet creates the flag set of a subcommand.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("api-file "+name, flag.ContinueOnError)
+}
+
+// openConnections opens the database and cache connections used by the services.
+func openConnections() error {
+	if err := database.OpenDBConnection(); err != nil {
+		return fmt.Errorf("could not connect to the database: %w", err)
+	}
+
+	if err := cache.OpenValkeyConnection(); err != nil {
+		return fmt.Errorf("could not connect to the cache: %w", err)
+	}
+
+	return nil
+}
+
+// closeConnections closes the cache connection.
+func closeConnections() {
+	if cache.Valkey != nil {
+		cache.Valkey.Close()
+	}
+}
+
+// getStoragePaths returns the storage path with the ID or all storage paths when the ID is zero.
+func getStoragePaths(id uint) ([]models.AppStoragePath, error) {
+	if id == 0 {
+		return services.GetStoragePaths()
+	}
+
+	storagePath, err := services.GetStoragePath(id)
+	if err != nil {
+		return nil, err
+	} else if storagePath.ID == 0 {
+		return nil, fmt.Errorf("storage path %d does not exist", id)
+	}
+
+	return []models.AppStoragePath{*storagePath}, nil
+}
+
+// printDryRun prints the notice that nothing is changed.
+func printDryRun(dryRun bool) {
+	if dryRun {
+		fmt.Fprintln(os.Stdout, "Dry run: nothing is changed.")
+	}
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Fsck checks the files of the storage paths against the database and repairs them.
+func Fsck(args []string) error {
+	flags := newFlagSet("fsck")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path, all storage paths when omitted")
+	deleteOrphans := flags.Bool("delete-orphans", false, "delete files without a database record")
+	markBroken := flags.Bool("mark-broken", false, "mark records with a missing or truncated file as broken")
+	regenerateSizes := flags.Bool("regenerate-sizes", false, "create missing web sizes from the original")
+	dryRun := flags.Bool("dry-run", false, "only report what would be repaired")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	storagePaths, err := getStoragePaths(*storagePathID)
+	if err != nil {
+		return err
+	}
+
+	options := services.FsckOptions{}
+	if !*dryRun {
+		options.DeleteOrphans = *deleteOrphans
+		options.MarkBroken = *markBroken
+		options.RegenerateSizes = *regenerateSizes
+	}
+	printDryRun(*dryRun)
+
+	for i := range storagePaths {
+		issues, err := services.CheckStoragePath(&storagePaths[i], options)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Storage path %d (%s%s): %d issue(s)\n", storagePaths[i].ID, storagePaths[i].AppName, storagePaths[i].Path, len(issues))
+		for _, issue := range issues {
+			fmt.Printf("  %s\n", formatIssue(&issue))
+		}
+	}
+
+	return nil
+}
+
+// formatIssue formats an issue as a single line.
+func formatIssue(issue *models.FsckIssue) string {
+	var line strings.Builder
+
+	line.WriteString(issue.Type.String())
+	if issue.FileType != "" {
+		fmt.Fprintf(&line, " %s %d", issue.FileType, issue.ID)
+	}
+	if issue.Size != "" {
+		fmt.Fprintf(&line, " size %s", issue.Size)
+	}
+	fmt.Fprintf(&line, " %s", strings.TrimPrefix(issue.Path, os.Getenv("PATH_FILES")))
+	if issue.Expected != issue.Actual {
+		fmt.Fprintf(&line, " (expected %d bytes, actual %d bytes)", issue.Expected, issue.Actual)
+	}
+	if issue.Repaired {
+		line.WriteString(" [repaired]")
+	}
+
+	return line.String()
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"api-file/main/src/services"
+	"errors"
+	"fmt"
+)
+
+// GeneratePlaceholders creates the placeholders of the images of a storage path.
+func GeneratePlaceholders(args []string) error {
+	flags := newFlagSet("generate-placeholders")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path (required)")
+	imageID := flags.Uint("image", 0, "ID of a single image, all images of the storage path when omitted")
+	overwrite := flags.Bool("overwrite", false, "also replace the placeholders that already exist")
+	dryRun := flags.Bool("dry-run", false, "only list the images that would get a placeholder")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *storagePathID == 0 {
+		return errors.New("the -storage-path flag is required")
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	images, err := services.GetImagesByStoragePath(*storagePathID)
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	var generated, failed int
+	for i := range images {
+		image := &images[i]
+		if *imageID != 0 && image.ID != *imageID {
+			continue
+		}
+		if !*overwrite && !image.Placeholder.IsEmpty() {
+			continue
+		}
+		if image.Broken {
+			fmt.Printf("Skipped image %d %s.%s: the original is broken.\n", image.ID, image.Name, image.Extension)
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Would generate the placeholder of image %d %s.%s.\n", image.ID, image.Name, image.Extension)
+			continue
+		}
+
+		if err := services.RegenerateImagePlaceholder(image); err != nil {
+			fmt.Printf("Failed image %d %s.%s: %v\n", image.ID, image.Name, image.Extension, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Generated the placeholder of image %d %s.%s.\n", image.ID, image.Name, image.Extension)
+		generated++
+	}
+
+	if !*dryRun {
+		fmt.Printf("Generated %d placeholder(s), %d failed.\n", generated, failed)
+	}
+
+	return nil
+}
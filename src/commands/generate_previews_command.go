@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"api-file/main/src/services"
+	"errors"
+	"fmt"
+)
+
+// GeneratePreviews creates the thumbnails and page counts of the documents of a storage path.
+func GeneratePreviews(args []string) error {
+	flags := newFlagSet("generate-previews")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path (required)")
+	documentID := flags.Uint("document", 0, "ID of a single document, all documents of the storage path when omitted")
+	overwrite := flags.Bool("overwrite", false, "also replace the previews that already exist")
+	dryRun := flags.Bool("dry-run", false, "only list the documents that would get a preview")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *storagePathID == 0 {
+		return errors.New("the -storage-path flag is required")
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	documents, err := services.GetDocumentsByStoragePath(*storagePathID)
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	var generated, failed int
+	for i := range documents {
+		document := &documents[i]
+		if *documentID != 0 && document.ID != *documentID {
+			continue
+		}
+		if !*overwrite && document.HasPreview {
+			continue
+		}
+		if !services.IsPdfDocument(document.MimeType) && !services.IsOfficeDocument(document.MimeType) {
+			continue
+		}
+		if document.Broken {
+			fmt.Printf("Skipped document %d %s.%s: the original is broken.\n", document.ID, document.Name, document.Extension)
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Would generate the preview of document %d %s.%s.\n", document.ID, document.Name, document.Extension)
+			continue
+		}
+
+		if err := services.GenerateDocumentPreview(&document.Folder.AppStoragePath, document); err != nil {
+			fmt.Printf("Failed document %d %s.%s: %v\n", document.ID, document.Name, document.Extension, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Generated the preview of document %d %s.%s.\n", document.ID, document.Name, document.Extension)
+		generated++
+	}
+
+	if !*dryRun {
+		fmt.Printf("Generated %d preview(s), %d failed.\n", generated, failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+	upload "api-file/main/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// importOptions are the flags of the import-dir command.
+type importOptions struct {
+	quality        int
+	isNotResizable bool
+	dryRun         bool
+}
+
+// ImportDir imports the files and sub directories of a local directory into a folder.
+func ImportDir(args []string) error {
+	flags := newFlagSet("import-dir")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path (required)")
+	folderID := flags.Uint("folder", 0, "ID of the folder to import into (required)")
+	dir := flags.String("dir", "", "local directory to import (required)")
+	quality := flags.Int("quality", 0, "quality of the web sizes, the default quality when omitted")
+	isNotResizable := flags.Bool("not-resizable", false, "do not create web sizes of the images")
+	dryRun := flags.Bool("dry-run", false, "only list what would be imported")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *storagePathID == 0 || *folderID == 0 || *dir == "" {
+		return errors.New("the -storage-path, -folder and -dir flags are required")
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	storagePath, err := services.GetStoragePath(*storagePathID)
+	if err != nil {
+		return err
+	} else if storagePath.ID == 0 {
+		return fmt.Errorf("storage path %d does not exist", *storagePathID)
+	}
+
+	folder, _, err := services.GetFolder(*folderID)
+	if err != nil {
+		return err
+	} else if folder.ID == 0 || folder.AppStoragePathID != storagePath.ID {
+		return fmt.Errorf("folder %d does not exist within storage path %d", *folderID, *storagePathID)
+	}
+
+	printDryRun(*dryRun)
+
+	return importDir(storagePath, *dir, folder.ID, &importOptions{
+		quality:        *quality,
+		isNotResizable: *isNotResizable,
+		dryRun:         *dryRun,
+	})
+}
+
+// importDir imports a directory into the folder and recurses into sub directories.
+// In a dry run a folder that does not exist yet has ID zero.
+func importDir(storagePath *models.AppStoragePath, dir string, folderID uint, options *importOptions) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+
+		if entry.IsDir() {
+			childFolderID, err := importFolder(storagePath, entry.Name(), folderID, options)
+			if err != nil {
+				return err
+			}
+			if err := importDir(storagePath, entryPath, childFolderID, options); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		if err := importFile(storagePath, entryPath, folderID, options); err != nil {
+			fmt.Printf("Failed %s: %v\n", entryPath, err)
+		}
+	}
+
+	return nil
+}
+
+// importFolder returns the ID of the child folder with the name, creating it when it does not exist.
+func importFolder(storagePath *models.AppStoragePath, name string, parentFolderID uint, options *importOptions) (uint, error) {
+	if parentFolderID != 0 {
+		folder, err := services.GetChildFolder(storagePath.ID, name, parentFolderID)
+		if err != nil {
+			return 0, err
+		} else if folder.ID != 0 {
+			return folder.ID, nil
+		}
+	}
+
+	if options.dryRun {
+		fmt.Printf("Would create folder %s.\n", name)
+		return 0, nil
+	}
+
+	folder, err := services.CreateFolder(storagePath.ID, name, "", false, parentFolderID)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Printf("Created folder %d %s.\n", folder.ID, name)
+
+	return folder.ID, nil
+}
+
+// importFile imports a single file as an image or a document.
+func importFile(storagePath *models.AppStoragePath, filePath string, folderID uint, options *importOptions) error {
+	name := filepath.Base(filePath)
+	filename, extension, err := upload.GetExtensionFromFilename(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	mimeType, _, err := mime.ParseMediaType(mimetype.Detect(data).String())
+	if err != nil {
+		return err
+	}
+
+	if available, err := services.IsStorageSpaceAvailable(storagePath.ID); err != nil {
+		return err
+	} else if !available {
+		return errors.New("storage path is full")
+	}
+
+	switch {
+	case upload.IsValidImage(mimeType):
+		if folderID != 0 {
+			if available, err := services.IsImageAvailable(folderID, filename, extension); err != nil {
+				return err
+			} else if available {
+				fmt.Printf("Skipped %s: image already exists.\n", filePath)
+				return nil
+			}
+		}
+		if options.dryRun {
+			fmt.Printf("Would import image %s.\n", filePath)
+			return nil
+		}
+
+		image, err := services.ImportImage(context.Background(), storagePath, folderID, filename, extension, mimeType, data, services.ImportImageOptions{
+			Quality:        options.quality,
+			IsNotResizable: options.isNotResizable,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported image %d %s.\n", image.ID, filePath)
+	case upload.IsValidDocument(mimeType):
+		if folderID != 0 {
+			if available, err := services.IsDocumentAvailable(folderID, filename, extension); err != nil {
+				return err
+			} else if available {
+				fmt.Printf("Skipped %s: document already exists.\n", filePath)
+				return nil
+			}
+		}
+		if options.dryRun {
+			fmt.Printf("Would import document %s.\n", filePath)
+			return nil
+		}
+
+		scan, err := services.ScanData(data)
+		if err != nil {
+			return err
+		} else if scan.Status == enums.Infected && !services.IsQuarantineEnabled() {
+			fmt.Printf("Skipped %s: document is infected with %s.\n", filePath, scan.Signature)
+			return nil
+		}
+
+		if err := services.UploadDocumentFile(context.Background(), storagePath, folderID, name, data, nil); err != nil {
+			return err
+		}
+
+		document, err := services.CreateDocument(folderID, filename, extension, mimeType, len(data), scan)
+		if err != nil {
+			return err
+		}
+		if err := services.GenerateDocumentPreview(storagePath, &document); err != nil {
+			fmt.Printf("Imported document %d %s without a preview: %v\n", document.ID, filePath, err)
+			return nil
+		}
+		fmt.Printf("Imported document %d %s.\n", document.ID, filePath)
+	default:
+		fmt.Printf("Skipped %s: %s is not supported.\n", filePath, mimeType)
+	}
+
+	return nil
+}
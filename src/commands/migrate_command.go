@@ -0,0 +1,21 @@
+package commands
+
+import "fmt"
+
+// Migrate migrates the database schema.
+func Migrate(args []string) error {
+	flags := newFlagSet("migrate")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Opening the connection migrates the schema.
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	fmt.Println("Database schema migrated.")
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"api-file/main/src/services"
+	"fmt"
+	"time"
+)
+
+// PurgeTrash deletes the trashed images, documents and folders for ever.
+func PurgeTrash(args []string) error {
+	flags := newFlagSet("purge-trash")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path, all storage paths when omitted")
+	olderThan := flags.Duration("older-than", 30*24*time.Hour, "only purge items that are deleted longer ago than this duration")
+	dryRun := flags.Bool("dry-run", false, "only list the items that would be purged")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	storagePaths, err := getStoragePaths(*storagePathID)
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	before := time.Now().Add(-*olderThan)
+	verb := "Purged"
+	if *dryRun {
+		verb = "Would purge"
+	}
+
+	for i := range storagePaths {
+		images, err := services.GetDeletedImages(storagePaths[i].ID, before)
+		if err != nil {
+			return err
+		}
+		for j := range images {
+			if !*dryRun {
+				// The files are removed first, so a failure leaves the record to purge them again.
+				if err := services.DeleteImageFiles(&images[j]); err != nil {
+					return err
+				}
+				if err := services.DeleteImage(&images[j], true); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%s image %d %s.%s.\n", verb, images[j].ID, images[j].Name, images[j].Extension)
+		}
+
+		documents, err := services.GetDeletedDocuments(storagePaths[i].ID, before)
+		if err != nil {
+			return err
+		}
+		for j := range documents {
+			if !*dryRun {
+				if err := services.DeleteDocumentFile(&documents[j]); err != nil {
+					return err
+				}
+				if err := services.DeleteDocument(&documents[j], true); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%s document %d %s.%s.\n", verb, documents[j].ID, documents[j].Name, documents[j].Extension)
+		}
+
+		folders, err := services.GetDeletedFolders(storagePaths[i].ID, before)
+		if err != nil {
+			return err
+		}
+		for j := range folders {
+			if !*dryRun {
+				if err := services.DeleteFolderHard(&folders[j]); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%s folder %d %s with its contents.\n", verb, folders[j].ID, folders[j].Name)
+		}
+	}
+
+	return nil
+}
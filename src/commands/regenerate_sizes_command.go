@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/services"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RegenerateSizes creates the web sizes of the images of a storage path again.
+func RegenerateSizes(args []string) error {
+	flags := newFlagSet("regenerate-sizes")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path (required)")
+	imageID := flags.Uint("image", 0, "ID of a single image, all images of the storage path when omitted")
+	quality := flags.Int("quality", 0, "quality of the web sizes, the default quality when omitted")
+	sizeList := flags.String("sizes", "", "comma separated web sizes to regenerate, all sizes when omitted")
+	dryRun := flags.Bool("dry-run", false, "only list the images that would be regenerated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *storagePathID == 0 {
+		return errors.New("the -storage-path flag is required")
+	}
+
+	var sizes []enums.Size
+	if *sizeList != "" {
+		for _, value := range strings.Split(*sizeList, ",") {
+			size := enums.Size(strings.TrimSpace(value))
+			if !size.IsValid() {
+				return fmt.Errorf("unknown size %q", value)
+			}
+			sizes = append(sizes, size)
+		}
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	images, err := services.GetImagesByStoragePath(*storagePathID)
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	var regenerated, failed int
+	for i := range images {
+		image := &images[i]
+		if *imageID != 0 && image.ID != *imageID {
+			continue
+		}
+		if image.Broken {
+			fmt.Printf("Skipped image %d %s.%s: the original is broken.\n", image.ID, image.Name, image.Extension)
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Would regenerate image %d %s.%s (%d size(s)).\n", image.ID, image.Name, image.Extension, len(image.ImageSizes))
+			continue
+		}
+
+		imageSizes, err := services.RegenerateImageSizes(image, *quality, sizes...)
+		if err == nil && len(sizes) == 0 {
+			_, err = services.RegenerateImageCrops(image, *quality)
+		}
+		if err != nil {
+			fmt.Printf("Failed image %d %s.%s: %v\n", image.ID, image.Name, image.Extension, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Regenerated image %d %s.%s (%d size(s)).\n", image.ID, image.Name, image.Extension, len(imageSizes))
+		regenerated++
+	}
+
+	if !*dryRun {
+		fmt.Printf("Regenerated %d image(s), %d failed.\n", regenerated, failed)
+	}
+
+	return nil
+}
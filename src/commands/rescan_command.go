@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/services"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rescan scans the stored documents for viruses again.
+func Rescan(args []string) error {
+	flags := newFlagSet("rescan")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path, all storage paths when omitted")
+	olderThan := flags.Duration("older-than", 0, "only rescan documents that are scanned longer ago than this duration")
+	dryRun := flags.Bool("dry-run", false, "only list the documents that would be scanned")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !services.IsVirusScanEnabled() {
+		return errors.New("no virus scanner is configured, set CLAMD_ADDRESS")
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	documents, err := services.GetDocumentsToRescan(*storagePathID, time.Now().Add(-*olderThan))
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	var clean, infected, failed int
+	for i := range documents {
+		document := &documents[i]
+		if document.Broken {
+			fmt.Printf("Skipped document %d %s.%s: the original is broken.\n", document.ID, document.Name, document.Extension)
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Would scan document %d %s.%s.\n", document.ID, document.Name, document.Extension)
+			continue
+		}
+
+		result, err := services.ScanDocument(document)
+		if err != nil {
+			fmt.Printf("Failed document %d %s.%s: %v\n", document.ID, document.Name, document.Extension, err)
+			failed++
+			continue
+		}
+
+		if result.Status == enums.Infected {
+			fmt.Printf("Quarantined document %d %s.%s: infected with %s.\n", document.ID, document.Name, document.Extension, result.Signature)
+			infected++
+		} else {
+			clean++
+		}
+	}
+
+	if !*dryRun {
+		fmt.Printf("Scanned %d document(s): %d clean, %d infected, %d failed.\n", clean+infected, clean, infected, failed)
+	}
+
+	return nil
+}
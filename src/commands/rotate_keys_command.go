@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"api-file/main/src/services"
+	"fmt"
+)
+
+// RotateKeys wraps the data keys of the encrypted storage paths with the current master key.
+func RotateKeys(args []string) error {
+	flags := newFlagSet("rotate-keys")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path, all storage paths when omitted")
+	dryRun := flags.Bool("dry-run", false, "only list the storage paths whose data key would be re-wrapped")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !services.IsEncryptionConfigured() {
+		return services.ErrMasterKeyMissing
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	storagePaths, err := getStoragePaths(*storagePathID)
+	if err != nil {
+		return err
+	}
+	printDryRun(*dryRun)
+
+	var rotated int
+	for i := range storagePaths {
+		storagePath := &storagePaths[i]
+		if services.IsDataKeyRotated(storagePath) {
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("Would re-wrap the data key of storage path %d %s/%s from master key %q.\n", storagePath.ID, storagePath.AppName, storagePath.Path, storagePath.MasterKeyID.String)
+			continue
+		}
+
+		if _, err := services.RotateDataKey(storagePath); err != nil {
+			return fmt.Errorf("storage path %d: %w", storagePath.ID, err)
+		}
+		fmt.Printf("Re-wrapped the data key of storage path %d %s/%s.\n", storagePath.ID, storagePath.AppName, storagePath.Path)
+		rotated++
+	}
+
+	if !*dryRun {
+		fmt.Printf("Re-wrapped %d data key(s).\n", rotated)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"api-file/main/src/services"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// Usage shows the used space and the limit of the storage paths.
+func Usage(args []string) error {
+	flags := newFlagSet("usage")
+	storagePathID := flags.Uint("storage-path", 0, "ID of the storage path, all storage paths when omitted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := openConnections(); err != nil {
+		return err
+	}
+	defer closeConnections()
+
+	storagePaths, err := getStoragePaths(*storagePathID)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tAPP\tPATH\tUSED\tLIMIT\tPERCENTAGE")
+	for i := range storagePaths {
+		used, err := services.GetUsedSpace(storagePaths[i].ID)
+		if err != nil {
+			return err
+		}
+
+		limit, percentage := "-", "-"
+		if storagePaths[i].Limit.Valid {
+			limit = fmt.Sprintf("%d", storagePaths[i].Limit.Int64)
+			if storagePaths[i].Limit.Int64 > 0 {
+				percentage = fmt.Sprintf("%.1f%%", float64(used)*100/float64(storagePaths[i].Limit.Int64))
+			}
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\n", storagePaths[i].ID, storagePaths[i].AppName, storagePaths[i].Path, used, limit, percentage)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
+	"api-file/main/src/errors"
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RunBatch func to delete, restore, move or update several images, documents and folders at once.
+// The operations run in one transaction and each gets its own result. When atomic, a failing operation
+// rolls back every operation, otherwise only itself.
+func RunBatch(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.Batch{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate batch fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Run the operations.
+	items := make([]models.BatchItem, len(request.Operations))
+	for i, operation := range request.Operations {
+		items[i] = models.BatchItem{
+			Operation:   enums.BatchOperation(operation.Operation),
+			Type:        enums.FileType(operation.Type),
+			ID:          operation.ID,
+			FolderID:    operation.FolderID,
+			Description: operation.Description,
+			Color:       operation.Color,
+		}
+	}
+	results, err := services.RunBatch(items, request.Atomic)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the results.
+	response := responses.Batch{}
+	response.SetBatch(request.Atomic, len(items))
+	for i := range items {
+		status, code, message := batchResult(&items[i], results[i])
+		response.AddResult(&items[i], status, code, message)
+	}
+
+	return c.JSON(response)
+}
+
+// batchResult gets the status, error code and message an operation would get as a single request.
+func batchResult(item *models.BatchItem, err error) (int, string, string) {
+	if err == nil {
+		if item.Operation == enums.BatchDelete || item.Operation == enums.BatchRestore {
+			return fiber.StatusNoContent, "", ""
+		}
+		return fiber.StatusOK, "", ""
+	}
+
+	switch err {
+	case services.ErrBatchItemMissing:
+		return fiber.StatusNotFound, batchExistsCode(item.Type), err.Error()
+	case services.ErrBatchFolderMissing:
+		return fiber.StatusNotFound, errors.FolderExists, err.Error()
+	case services.ErrBatchConflict:
+		return fiber.StatusConflict, batchExistsCode(item.Type), err.Error()
+	case services.ErrBatchImmutable:
+		return fiber.StatusBadRequest, errors.FolderImmutable, err.Error()
+	case services.ErrBatchStorageFull:
+		return fiber.StatusBadRequest, errors.StoragePathFull, err.Error()
+	case services.ErrBatchUnsupported:
+		return fiber.StatusBadRequest, errors.BatchOperation, err.Error()
+	case services.ErrBatchRolledBack:
+		return fiber.StatusFailedDependency, errors.BatchRolledBack, err.Error()
+	default:
+		return fiber.StatusInternalServerError, errorutil.QueryError, err.Error()
+	}
+}
+
+// batchExistsCode gets the error code of an item that does or does not exist.
+func batchExistsCode(fileType enums.FileType) string {
+	switch fileType {
+	case enums.Image:
+		return errors.ImageExists
+	case enums.Document:
+		return errors.DocumentExist
+	default:
+		return errors.FolderExists
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"api-file/main/src/docs"
+	"encoding/json"
+	"sync"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// openAPI keeps the OpenAPI document, the routes do not change once the server runs.
+var openAPI struct {
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// docsPage is the page that renders the OpenAPI document with Redoc.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>API-File</title>
+</head>
+<body>
+	<redoc spec-url="/` + docs.Version + `/openapi.json"></redoc>
+	<script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// GetOpenAPI func to get the OpenAPI document of the routes of the app.
+func GetOpenAPI(c *fiber.Ctx) error {
+	openAPI.once.Do(func() {
+		openAPI.document, openAPI.err = json.Marshal(docs.Generate(c.App().GetRoutes(true)))
+	})
+	if openAPI.err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, openAPI.err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	return c.Send(openAPI.document)
+}
+
+// GetDocs func to get the page that renders the OpenAPI document.
+func GetDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+	return c.SendString(docsPage)
+}
@@ -8,8 +8,9 @@ import (
 	"api-file/main/src/models"
 	"api-file/main/src/services"
 	upload "api-file/main/src/utils"
+	"context"
 	"fmt"
-	"os"
+	"log"
 
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
@@ -53,6 +54,8 @@ func GetDocumentFile(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	} else if document.ID == 0 {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+	} else if document.ScanStatus == enums.Infected {
+		return errorutil.Response(c, fiber.StatusForbidden, errors.DocumentInfected, "Document is quarantined.")
 	}
 
 	// Construct the file path.
@@ -60,10 +63,81 @@ func GetDocumentFile(c *fiber.Ctx) error {
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	}
-	filePath := fmt.Sprintf("%s%s.%s", path, document.Name, document.Extension)
+	filePath := services.DocumentFilePath(path, &document)
 
 	// Send the file as a response.
-	return c.SendFile(filePath)
+	return sendStorageFile(c, filePath)
+}
+
+// GetDocumentThumbnail method to get the thumbnail of the first page of a document by ID.
+func GetDocumentThumbnail(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Try to get the thumbnail from cache.
+	filePath, err := services.GetDocumentFromCache(id, services.DocumentThumbnailCacheSuffix)
+	if filePath == "" || err != nil {
+		// Get the document.
+		document, err := services.GetDocumentById(id)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if document.ID == 0 || !document.HasPreview {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+		}
+
+		// Construct the file path.
+		path, err := services.GetPath(&document.Folder.AppStoragePath, document.FolderID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
+
+		filePath = services.DocumentThumbnailFilePath(path, &document)
+		_ = services.SaveDocumentToCache(document.ID, filePath, services.DocumentThumbnailCacheSuffix)
+	}
+
+	// Send the file as a response.
+	return sendStorageFile(c, filePath)
+}
+
+// GetDocumentPreview method to get a page of a document as image by ID.
+// The page is rendered on the first request.
+func GetDocumentPreview(c *fiber.Ctx) error {
+	// Get the ID and the page from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+	page, err := c.ParamsInt("page")
+	if err != nil || page < 1 {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid page.")
+	}
+
+	// Try to get the page from cache.
+	filePath, err := services.GetDocumentFromCache(id, services.DocumentPreviewCacheSuffix(page))
+	if filePath == "" || err != nil {
+		// Get the document.
+		document, err := services.GetDocumentById(id)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if document.ID == 0 || !document.HasPreview {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+		} else if page > document.PageCount {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentPageExists, "Page does not exist.")
+		}
+
+		// Render the page when it does not exist yet.
+		filePath, err = services.GetDocumentPreviewFile(&document, page)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.PreviewDocument, err.Error())
+		}
+		_ = services.SaveDocumentToCache(document.ID, filePath, services.DocumentPreviewCacheSuffix(page))
+	}
+
+	// Send the file as a response.
+	return sendStorageFile(c, filePath)
 }
 
 // CreateDocument method to create an document.
@@ -115,26 +189,39 @@ func CreateDocument(c *fiber.Ctx) error {
 	} else if isValid := upload.IsValidDocument(mimeType); !isValid {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.DocumentTypeInvalid, fmt.Sprintf("Invalid document for %s.", mimeType))
 	}
-	data, err := upload.Base64ToBytes(base64Data)
+	data, err := upload.Base64ToBytesContext(c.UserContext(), base64Data)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseBase64, fmt.Sprintf("Error while decoding bytes. Amount of correct parsed bytes: %d", err))
 	}
 
+	// Scan the document for viruses before it is stored.
+	scan, err := services.ScanData(data)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusServiceUnavailable, errors.ScanDocument, err.Error())
+	} else if scan.Status == enums.Infected && !services.IsQuarantineEnabled() {
+		return errorutil.Response(c, fiber.StatusUnprocessableEntity, errors.DocumentInfected, fmt.Sprintf("Document is infected with %s.", scan.Signature))
+	}
+
 	// Upload the document.
 	fileProgress := responses.FileProgress{}
 	fileProgress.SetFileProgress(storagePath.AppName, enums.Document, request.Name, 0.0)
 
-	err = uploadDocument(storagePath, request.FolderID, request.Name, data, &fileProgress)
+	err = uploadDocument(c.UserContext(), storagePath, request.FolderID, request.Name, data, &fileProgress)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errors.UploadDocument, err)
 	}
 
 	// Create the document.
-	document, err := services.CreateDocument(request.FolderID, filename, extension, mimeType, len(data))
+	document, err := services.CreateDocument(request.FolderID, filename, extension, mimeType, len(data), scan)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	}
 
+	// Render the preview, the document is kept when this fails.
+	if err := services.GenerateDocumentPreview(storagePath, &document); err != nil {
+		log.Printf("Error generating the preview of document %d: %v", document.ID, err)
+	}
+
 	// Return the document.
 	response := responses.Document{}
 	response.SetDocument(&document, &storagePath.ID)
@@ -181,6 +268,11 @@ func UpdateDocument(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseFilename, err)
 	}
 
+	// Rename the document without uploading it again.
+	if request.Data == "" {
+		return renameDocument(c, &document, filename, extension)
+	}
+
 	// Convert data to bytes.
 	mimeType, base64Data, err := upload.GetMimeTypeAndBase64(request.Data)
 	if err != nil {
@@ -188,11 +280,19 @@ func UpdateDocument(c *fiber.Ctx) error {
 	} else if isValid := upload.IsValidDocument(mimeType); !isValid {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.DocumentTypeInvalid, fmt.Sprintf("Invalid document for %s.", mimeType))
 	}
-	data, err := upload.Base64ToBytes(base64Data)
+	data, err := upload.Base64ToBytesContext(c.UserContext(), base64Data)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseBase64, fmt.Sprintf("Error while decoding bytes. Amount of correct parsed bytes: %d", err))
 	}
 
+	// Scan the document for viruses before it is stored.
+	scan, err := services.ScanData(data)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusServiceUnavailable, errors.ScanDocument, err.Error())
+	} else if scan.Status == enums.Infected && !services.IsQuarantineEnabled() {
+		return errorutil.Response(c, fiber.StatusUnprocessableEntity, errors.DocumentInfected, fmt.Sprintf("Document is infected with %s.", scan.Signature))
+	}
+
 	// Delete existing document.
 	if err := deleteDocument(&document); err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DeleteDocument, err)
@@ -202,17 +302,22 @@ func UpdateDocument(c *fiber.Ctx) error {
 	fileProgress := responses.FileProgress{}
 	fileProgress.SetFileProgress(document.Folder.AppStoragePath.AppName, enums.Document, request.Name, 0.0)
 
-	err = uploadDocument(&document.Folder.AppStoragePath, document.FolderID, request.Name, data, &fileProgress)
+	err = uploadDocument(c.UserContext(), &document.Folder.AppStoragePath, document.FolderID, request.Name, data, &fileProgress)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errors.UploadDocument, err)
 	}
 
 	// Update the document.
-	document, err = services.UpdateDocument(&document, filename, extension, mimeType, len(data))
+	document, err = services.UpdateDocument(&document, filename, extension, mimeType, len(data), scan)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	}
 
+	// Render the preview, the document is kept when this fails.
+	if err := services.GenerateDocumentPreview(&document.Folder.AppStoragePath, &document); err != nil {
+		log.Printf("Error generating the preview of document %d: %v", document.ID, err)
+	}
+
 	// Return the document.
 	response := responses.Document{}
 	response.SetDocument(&document, nil)
@@ -296,52 +401,245 @@ func RestoreDocument(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// Upload the document to the storage path.
-func uploadDocument(appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, fileProgress *responses.FileProgress) error {
-	path, err := services.GetPath(appStoragePath, folderID)
+// CopyDocument func to copy a document with its previews into a folder.
+func CopyDocument(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
 	if err != nil {
-		return err
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
 
-	err = os.MkdirAll(path, os.ModePerm)
+	// Parse the request.
+	request := requests.CopyDocument{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate document fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Find the document.
+	document, err := services.GetDocumentById(id)
 	if err != nil {
-		return err
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if document.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+	}
+
+	// Find the target folder.
+	folder, err := services.GetFolderWithStoragePath(request.FolderID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+	}
+
+	// Check if the storage path has space for the copy.
+	if available, err := services.IsStorageSpaceAvailableFor(folder.AppStoragePathID, int64(document.Size)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if !available {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathFull, "Storage path is full.")
+	}
+
+	// Check if the document is available in the target folder.
+	name := document.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	conflict := conflictStrategy(request.Conflict)
+	if conflict == enums.ConflictFail {
+		if exists, err := services.IsDocumentAvailable(folder.ID, name, document.Extension); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if exists {
+			return errorutil.Response(c, fiber.StatusConflict, errors.DocumentExist, "Document already exists.")
+		}
+	}
+
+	// Copy the document.
+	documentCopy, _, err := services.CopyDocument(&document, folder, name, conflict)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.CopyFile, err.Error())
 	}
 
-	chunks := upload.ChunkBytes(data)
-	file, err := os.OpenFile(path+filename, os.O_WRONLY|os.O_CREATE, os.ModePerm)
+	// Return the document.
+	response := responses.Document{}
+	response.SetDocument(&documentCopy, &folder.AppStoragePathID)
+
+	return c.JSON(response)
+}
+
+// MoveDocument func to move a document with its previews into a folder.
+func MoveDocument(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
 	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Parse the request.
+	request := requests.MoveDocument{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate document fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Move the document.
+	storagePathID, documents, err := moveDocuments(c, []uint{id}, request.FolderID)
+	if documents == nil {
 		return err
 	}
-	defer file.Close()
 
-	var seeker int64
-	for i, chunk := range chunks {
-		_, err := file.WriteAt(chunk, seeker)
-		if err != nil {
-			return err
+	// Return the document.
+	response := responses.Document{}
+	response.SetDocument(&documents[0], &storagePathID)
+
+	return c.JSON(response)
+}
+
+// MoveDocuments func to move several documents into a folder at once.
+func MoveDocuments(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.MoveDocuments{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate document fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Move the documents.
+	storagePathID, documents, err := moveDocuments(c, request.DocumentIDs, request.FolderID)
+	if documents == nil {
+		return err
+	}
+
+	// Return the documents.
+	response := make([]responses.Document, len(documents))
+	for i := range documents {
+		response[i].SetDocument(&documents[i], &storagePathID)
+	}
+
+	return c.JSON(response)
+}
+
+// renameDocument renames the document with its previews and sends the renamed document.
+func renameDocument(c *fiber.Ctx, document *models.Document, filename, extension string) error {
+	if extension != document.Extension {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseFilename, "The extension of a document can only change with new data.")
+	}
+
+	if filename != document.Name {
+		if exists, err := services.IsDocumentAvailable(document.FolderID, filename, document.Extension); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if exists {
+			return errorutil.Response(c, fiber.StatusConflict, errors.DocumentExist, "Document already exists.")
+		}
+
+		if err := services.RenameDocument(document, filename); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.RenameFile, err)
 		}
-		seeker += int64(len(chunk))
-		fileProgress.Progress = float64(i) * 100.0 / float64(len(chunks))
-		BroadcastProgress(fileProgress)
 	}
 
-	fileProgress.Progress = 100.0
-	BroadcastProgress(fileProgress)
+	// Return the document.
+	response := responses.Document{}
+	response.SetDocument(document, nil)
+
+	return c.JSON(response)
+}
 
-	return err
+// Upload the document to the storage path.
+func uploadDocument(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, fileProgress *responses.FileProgress) error {
+	return services.UploadDocumentFile(ctx, appStoragePath, folderID, filename, data, func(percentage float64) {
+		fileProgress.Progress = percentage
+		BroadcastProgress(fileProgress)
+	})
 }
 
 // Delete the document from the storage path.
 func deleteDocument(document *models.Document) error {
-	path, err := services.GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	return services.DeleteDocumentFile(document)
+}
+
+// moveDocuments moves the documents into the folder after checking the conflicts and the space of the storage path.
+// Documents that are already in the folder stay as they are. The documents are nil when an error response is sent.
+func moveDocuments(c *fiber.Ctx, documentIDs []uint, folderID uint) (uint, []models.Document, error) {
+	// Find the target folder.
+	folder, err := services.GetFolderWithStoragePath(folderID)
 	if err != nil {
-		return err
+		return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return 0, nil, errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
 	}
 
-	if err := os.Remove(fmt.Sprintf("%s%s.%s", path, document.Name, document.Extension)); err != nil {
-		return err
+	// Find the documents.
+	documents, err := services.GetDocumentsByIDs(documentIDs)
+	if err != nil {
+		return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	found := make(map[uint]bool, len(documents))
+	for i := range documents {
+		found[documents[i].ID] = true
+	}
+	for _, id := range documentIDs {
+		if !found[id] {
+			return 0, nil, errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, fmt.Sprintf("Document %d does not exist.", id))
+		}
+	}
+
+	// Check if the documents are available in the target folder.
+	moving := make([]models.Document, 0, len(documents))
+	movingIndexes := make([]int, 0, len(documents))
+	names := make(map[string]bool, len(documents))
+	var size int64
+	for i := range documents {
+		if documents[i].FolderID == folder.ID {
+			continue
+		}
+
+		filename := fmt.Sprintf("%s.%s", documents[i].Name, documents[i].Extension)
+		if exists, err := services.IsDocumentAvailable(folder.ID, documents[i].Name, documents[i].Extension); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if exists || names[filename] {
+			return 0, nil, errorutil.Response(c, fiber.StatusConflict, errors.DocumentExist, fmt.Sprintf("Document %s already exists.", filename))
+		}
+		names[filename] = true
+
+		if documents[i].Folder.AppStoragePathID != folder.AppStoragePathID {
+			size += int64(documents[i].Size)
+		}
+		moving = append(moving, documents[i])
+		movingIndexes = append(movingIndexes, i)
+	}
+
+	// Check if the storage path has space for the documents of other storage paths.
+	if size > 0 {
+		if available, err := services.IsStorageSpaceAvailableFor(folder.AppStoragePathID, size); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if !available {
+			return 0, nil, errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathFull, "Storage path is full.")
+		}
+	}
+
+	// Move the documents.
+	if len(moving) > 0 {
+		if err := services.MoveDocuments(moving, folder); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errors.MoveFile, err.Error())
+		}
+		for i, index := range movingIndexes {
+			documents[index] = moving[i]
+		}
 	}
 
-	return nil
+	return folder.AppStoragePathID, documents, nil
 }
@@ -3,6 +3,7 @@ package controllers
 import (
 	"api-file/main/src/dto/requests"
 	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
 	"api-file/main/src/errors"
 	"api-file/main/src/models"
 	"api-file/main/src/services"
@@ -234,3 +235,135 @@ func RestoreFolder(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// CopyFolder func to copy a folder with its sub folders, images and documents into a folder.
+// The folder is created right away, its files are copied in a background job.
+func CopyFolder(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Parse the request.
+	request := requests.CopyFolder{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate folder fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Find the folder and the target folder.
+	folder, err := services.GetFolderWithStoragePath(id)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+	}
+	target, err := services.GetFolderWithStoragePath(request.FolderID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if target.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+	}
+
+	// A folder can not be copied into itself.
+	if inside, err := services.IsFolderInside(target, folder); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if inside {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.FolderInside, "Folder can not be copied into itself.")
+	}
+
+	// Check if the storage path has space for the copy.
+	count, size, err := services.GetFolderFilesSize(folder.AppStoragePathID, folder.ID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if available, err := services.IsStorageSpaceAvailableFor(target.AppStoragePathID, size); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if !available {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathFull, "Storage path is full.")
+	}
+
+	// Resolve a folder with the same name in the target folder.
+	name := folder.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	conflict := conflictStrategy(request.Conflict)
+	exists, err := services.IsFolderAvailable(target.AppStoragePathID, name, "", target.ID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	var folderCopy *models.Folder
+	if exists {
+		switch conflict {
+		case enums.ConflictRename:
+			if name, err = services.CopyName(name, func(name string) (bool, error) {
+				return services.IsFolderAvailable(target.AppStoragePathID, name, "", target.ID)
+			}); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+			}
+		case enums.ConflictSkip, enums.ConflictOverwrite:
+			if folderCopy, err = services.GetChildFolder(target.AppStoragePathID, name, target.ID); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+			} else if folderCopy.ID == 0 {
+				return errorutil.Response(c, fiber.StatusConflict, errors.FolderExists, "Folder already exists in the trash.")
+			}
+		default:
+			return errorutil.Response(c, fiber.StatusConflict, errors.FolderExists, "Folder already exists.")
+		}
+	}
+
+	// The existing folder is returned as is when the copy is skipped.
+	response := responses.FolderCopy{}
+	if exists && conflict == enums.ConflictSkip {
+		response.SetFolderCopy(folderCopy, nil)
+		return c.JSON(response)
+	}
+
+	// Create the folder.
+	if folderCopy == nil {
+		if folderCopy, err = services.CreateFolder(target.AppStoragePathID, name, folder.Color, false, target.ID); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+	}
+	folderCopy.AppStoragePath = target.AppStoragePath
+
+	// Create the job.
+	job, err := services.CreateJob(enums.CopyFolder, target.AppStoragePathID, count)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+	response.SetFolderCopy(folderCopy, job)
+
+	// Copy the files in the background.
+	fileProgress := responses.FileProgress{}
+	fileProgress.SetFileProgress(target.AppStoragePath.AppName, enums.Image, "", 0.0)
+	fileProgress.JobID = job.ID
+
+	services.RunJob(job, func(job *models.Job) error {
+		return services.CopyFolder(job, folder, folderCopy, conflict, func(fileType enums.FileType, filename string) {
+			fileProgress.Type = fileType
+			fileProgress.Filename = filename
+			fileProgress.Progress = job.Progress()
+			BroadcastProgress(&fileProgress)
+		})
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// conflictStrategy gets the conflict strategy of a request, failing by default.
+func conflictStrategy(conflict string) enums.ConflictStrategy {
+	if conflict == "" {
+		return enums.ConflictFail
+	}
+
+	return enums.ConflictStrategy(conflict)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
+	"api-file/main/src/services"
+	"crypto/subtle"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetLiveness func to tell that the service is running. It does not check the dependencies,
+// so the service is not restarted when one of them is down.
+func GetLiveness(c *fiber.Ctx) error {
+	return c.JSON(responses.Health{Status: enums.Up.String()})
+}
+
+// GetReadiness func to tell if the service can handle requests: the database and the cache are reachable,
+// and the storage roots are mounted and writable with enough free space.
+// Machine callers get the result of each check.
+func GetReadiness(c *fiber.Ctx) error {
+	checks := services.CheckHealth(c.UserContext())
+	healthy := services.IsHealthy(checks)
+
+	response := responses.Health{}
+	response.SetHealth(healthy, checks, isMachine(c))
+
+	status := fiber.StatusOK
+	if !healthy {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(response)
+}
+
+// isMachine checks if the request has the machine key, without refusing it when it has not.
+func isMachine(c *fiber.Ctx) bool {
+	machineKey := os.Getenv("MACHINE_KEY")
+
+	return machineKey != "" && subtle.ConstantTimeCompare([]byte(c.Get("x-machine-key")), []byte(machineKey)) == 1
+}
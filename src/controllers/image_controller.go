@@ -8,13 +8,13 @@ import (
 	"api-file/main/src/models"
 	"api-file/main/src/services"
 	upload "api-file/main/src/utils"
+	"context"
+	stderrors "errors"
 	"fmt"
-	"os"
 
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
 	"github.com/gofiber/fiber/v2"
-	"github.com/h2non/bimg"
 )
 
 // GetImage method to get the image by ID.
@@ -66,12 +66,50 @@ func GetImageFile(c *fiber.Ctx) error {
 		if err != nil {
 			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 		}
-		filePath = fmt.Sprintf("%s%s.%s", path, image.Name, image.Extension)
+		filePath = services.ImagePublicFilePath(path, &image)
 		_ = services.SaveImageToCache(image.ID, filePath)
 	}
 
 	// Send the file as a response.
-	return c.SendFile(filePath)
+	c.Set(fiber.HeaderContentSecurityPolicy, services.ImageContentSecurityPolicy)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return sendStorageFile(c, filePath)
+}
+
+// GetImageFileOriginal method to get the untouched original of an image file by ID,
+// without the edit and the watermark.
+func GetImageFileOriginal(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Try to get image from cache.
+	filePath, err := services.GetImageFromCache(id, services.ImageOriginalCacheSuffix)
+	if filePath == "" || err != nil {
+		// Get the image.
+		image, err := services.GetImage(id)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if image.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+		}
+
+		// Construct the file path.
+		path, err := services.GetPath(&image.Folder.AppStoragePath, image.FolderID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
+
+		filePath = services.ImageFilePath(path, &image)
+		_ = services.SaveImageToCache(image.ID, filePath, services.ImageOriginalCacheSuffix)
+	}
+
+	// Send the file as a response.
+	c.Set(fiber.HeaderContentSecurityPolicy, services.ImageContentSecurityPolicy)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return sendStorageFile(c, filePath)
 }
 
 // GetImageFileSize method to get the image file by ID.
@@ -99,12 +137,80 @@ func GetImageFileSize(c *fiber.Ctx) error {
 			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 		}
 
-		filePath = fmt.Sprintf("%s%s-%s.webp", path, imageSize.Image.Name, size)
+		filePath = services.ImageSizeFilePath(path, imageSize.Image.Name, size)
 		_ = services.SaveImageToCache(imageSize.Image.ID, filePath, size.String())
 	}
 
 	// Send the file as a response.
-	return c.SendFile(filePath)
+	return sendStorageFile(c, filePath)
+}
+
+// GetImageFilePoster method to get the still poster frame of an animated image file by ID.
+func GetImageFilePoster(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Try to get image from cache.
+	filePath, err := services.GetImageFromCache(id, services.ImagePosterCacheSuffix)
+	if filePath == "" || err != nil {
+		// Get the image.
+		image, err := services.GetImage(id)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if image.ID == 0 || !image.Animation.HasPoster {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+		}
+
+		// Construct the file path.
+		path, err := services.GetPath(&image.Folder.AppStoragePath, image.FolderID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
+
+		filePath = services.ImagePosterFilePath(path, image.Name)
+		_ = services.SaveImageToCache(image.ID, filePath, services.ImagePosterCacheSuffix)
+	}
+
+	// Send the file as a response.
+	return sendStorageFile(c, filePath)
+}
+
+// GetImageFileCrop method to get a crop of the image file by ID.
+func GetImageFileCrop(c *fiber.Ctx) error {
+	crop := enums.Crop(c.Params("crop"))
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	} else if !crop.IsValid() {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid crop.")
+	}
+
+	// Try to get image from cache.
+	filePath, err := services.GetImageFromCache(id, services.ImageCropCacheSuffix(crop))
+	if filePath == "" || err != nil {
+		// Get the image crop.
+		imageCrop, err := services.GetImageCropById(id, crop)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if imageCrop.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+		}
+
+		// Construct the file path.
+		path, err := services.GetPath(&imageCrop.Image.Folder.AppStoragePath, imageCrop.Image.FolderID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
+
+		filePath = services.ImageCropFilePath(path, imageCrop.Image.Name, crop)
+		_ = services.SaveImageToCache(imageCrop.Image.ID, filePath, services.ImageCropCacheSuffix(crop))
+	}
+
+	// Send the file as a response.
+	return sendStorageFile(c, filePath)
 }
 
 // CreateImage method to create an image.
@@ -156,36 +262,33 @@ func CreateImage(c *fiber.Ctx) error {
 	} else if isValid := upload.IsValidImage(mimeType); !isValid {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.ImageTypeInvalid, fmt.Sprintf("Invalid image for %s.", mimeType))
 	}
-	data, err := upload.Base64ToBytes(base64Data)
+	data, err := upload.Base64ToBytesContext(c.UserContext(), base64Data)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseBase64, fmt.Sprintf("Error while decoding bytes. Amount of correct parsed bytes: %d", err))
 	}
 
-	// Upload the image.
-	progress := 100.0
-	if !request.IsNotResizable {
-		progress = 100.0 / 7
-	}
-
+	// Store the image with its web sizes, crops and renditions.
 	fileProgress := responses.FileProgress{}
 	fileProgress.SetFileProgress(storagePath.AppName, enums.Image, request.Name, 0.0)
 
-	width, height, err := uploadImage(storagePath, request.FolderID, request.Name, data, progress, &fileProgress)
-	if err != nil {
+	image, err := services.ImportImage(c.UserContext(), storagePath, request.FolderID, filename, extension, mimeType, data, services.ImportImageOptions{
+		Quality:        request.Quality,
+		IsNotResizable: request.IsNotResizable,
+		Description:    request.Description,
+		OnProgress: func(percentage float64) {
+			fileProgress.Progress = percentage
+			BroadcastProgress(&fileProgress)
+		},
+	})
+	switch {
+	case err == nil:
+	case stderrors.Is(err, services.ErrImageInvalid):
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.ImageTypeInvalid, fmt.Sprintf("Invalid SVG image: %s.", err))
+	case stderrors.Is(err, services.ErrImageUpload):
 		return errorutil.Response(c, fiber.StatusInternalServerError, errors.UploadImage, err)
-	}
-
-	// Create web size images.
-	var imageSizes []models.ImageSize
-	if !request.IsNotResizable {
-		if imageSizes, err = convertAndUploadImages(storagePath, request.FolderID, filename, data, request.Quality, progress, &fileProgress); err != nil {
-			return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
-		}
-	}
-
-	// Create the image.
-	image, err := services.CreateImage(request.FolderID, filename, extension, mimeType, len(data), width, height, request.Description, imageSizes)
-	if err != nil {
+	case stderrors.Is(err, services.ErrImageConvert):
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+	default:
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	}
 
@@ -216,6 +319,17 @@ func UpdateImage(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
 	}
 
+	// Lock the image, so a job does not change its files at the same time.
+	unlock, err := services.LockImage(c.UserContext(), id)
+	switch err {
+	case nil:
+		defer unlock()
+	case services.ErrImageLocked:
+		return errorutil.Response(c, fiber.StatusConflict, errors.ImageLocked, "Image is changed by another request or job.")
+	default:
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err)
+	}
+
 	// Check if the image exists.
 	image, err := services.GetImageById(id, true)
 	if err != nil {
@@ -235,13 +349,33 @@ func UpdateImage(c *fiber.Ctx) error {
 	var size *int
 	var width *int
 	var height *int
+	var metadata *models.ImageMetadata
+	var placeholder *models.ImagePlaceholder
+	var animation *models.ImageAnimation
 	var imageSizes *[]models.ImageSize
+	var imageCrops *[]models.ImageCrop
+	var renamedFrom *string
+
+	// Set the focal point.
+	focalPointChanged := false
+	if request.FocalPoint != nil {
+		focalPointChanged = services.SetImageFocalPoint(&image, &services.FocalPoint{X: request.FocalPoint.X, Y: request.FocalPoint.Y})
+	} else if request.RemoveFocalPoint {
+		focalPointChanged = services.SetImageFocalPoint(&image, nil)
+	}
+
+	quality := 0
+	if request.Quality != nil {
+		quality = *request.Quality
+	}
+	isResizable := request.IsNotResizable == nil || !*request.IsNotResizable
 
 	if request.Name != nil && request.Data != nil {
-		// Delete the old image.
+		// Delete the old image, a new original also drops its edit.
 		if err := deleteImage(&image); err != nil {
 			return errorutil.Response(c, fiber.StatusInternalServerError, errors.DeleteImage, err)
 		}
+		image.Edit = models.ImageEdit{}
 
 		// Extract the extension from the image.
 		parsedFilename, parsedExtension, err := upload.GetExtensionFromFilename(*request.Name)
@@ -258,23 +392,37 @@ func UpdateImage(c *fiber.Ctx) error {
 		} else if isValid := upload.IsValidImage(mimeType); !isValid {
 			return errorutil.Response(c, fiber.StatusBadRequest, errors.ImageTypeInvalid, fmt.Sprintf("Invalid image for %s.", mimeType))
 		}
-		data, err := upload.Base64ToBytes(base64Data)
+		data, err := upload.Base64ToBytesContext(c.UserContext(), base64Data)
 		if err != nil {
 			return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseBase64, fmt.Sprintf("Error while decoding bytes. Amount of correct parsed bytes: %d", err))
 		}
+
+		// Remove scripts and external references from an SVG.
+		if services.IsSvgImage(mimeType, parsedExtension, data) {
+			if data, err = services.SanitizeSvg(data); err != nil {
+				return errorutil.Response(c, fiber.StatusBadRequest, errors.ImageTypeInvalid, fmt.Sprintf("Invalid SVG image: %s.", err))
+			}
+		}
+
+		// Read the metadata and strip it from the original.
+		data, imageMetadata, err := services.ProcessImageMetadata(c.UserContext(), data, image.Folder.AppStoragePath.StripMetadata)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+		}
+		metadata = &imageMetadata
 		dataLen := len(data)
 		size = &dataLen
 
 		// Upload the image.
 		progress := 100.0
-		if request.IsNotResizable == nil || !*request.IsNotResizable {
+		if isResizable {
 			progress = 100.0 / 7
 		}
 
 		fileProgress := responses.FileProgress{}
 		fileProgress.SetFileProgress(image.Folder.AppStoragePath.AppName, enums.Image, *request.Name, 0.0)
 
-		imageWidth, imageHeight, err := uploadImage(&image.Folder.AppStoragePath, image.FolderID, *request.Name, data, progress, &fileProgress)
+		imageWidth, imageHeight, err := uploadImage(c.UserContext(), &image.Folder.AppStoragePath, image.FolderID, *request.Name, data, progress, &fileProgress)
 		if err != nil {
 			return errorutil.Response(c, fiber.StatusInternalServerError, errors.UploadImage, err)
 		}
@@ -282,20 +430,86 @@ func UpdateImage(c *fiber.Ctx) error {
 		height = &imageHeight
 
 		// Create web size images.
-		if request.IsNotResizable == nil || !*request.IsNotResizable {
-			if createdImageSizes, err := convertAndUploadImages(&image.Folder.AppStoragePath, image.FolderID, *filename, data, *request.Quality, progress, &fileProgress); err != nil {
+		if isResizable {
+			if createdImageSizes, err := convertAndUploadImages(c.UserContext(), &image.Folder.AppStoragePath, image.FolderID, *filename, data, quality, progress, &fileProgress); err != nil {
 				return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
 			} else {
 				imageSizes = &createdImageSizes
 			}
+
+			// Create crop images.
+			if createdImageCrops, err := services.CropAndUploadImageFiles(c.UserContext(), &image.Folder.AppStoragePath, image.FolderID, *filename, data, services.ImageFocalPoint(&image), quality); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+			} else {
+				imageCrops = &createdImageCrops
+			}
+		} else {
+			imageCrops = &[]models.ImageCrop{}
+		}
+
+		// Create the poster of an animated image.
+		imageAnimation := services.ReadImageAnimation(data)
+		if isResizable {
+			if err := services.CreateImagePosterFile(c.UserContext(), &image.Folder.AppStoragePath, image.FolderID, *filename, data, &imageAnimation, quality); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+			}
+		}
+		animation = &imageAnimation
+
+		// Create the watermarked rendition.
+		if err := services.CreateImageWatermarkedFile(c.UserContext(), &image.Folder.AppStoragePath, image.FolderID, *filename, data, quality); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+		}
+
+		// Create the placeholder.
+		imagePlaceholder, err := services.GenerateImagePlaceholder(c.UserContext(), data)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+		}
+		placeholder = &imagePlaceholder
+	} else if request.Name != nil {
+		// Rename the image without uploading it again.
+		parsedFilename, parsedExtension, err := upload.GetExtensionFromFilename(*request.Name)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseFilename, err)
+		} else if parsedExtension != image.Extension {
+			return errorutil.Response(c, fiber.StatusBadRequest, errors.ParseFilename, "The extension of an image can only change with new data.")
+		}
+
+		if parsedFilename != image.Name {
+			if exists, err := services.IsImageAvailable(image.FolderID, parsedFilename, image.Extension); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+			} else if exists {
+				return errorutil.Response(c, fiber.StatusConflict, errors.ImageExists, "Image already exists.")
+			}
+
+			oldName := image.Name
+			if err := services.RenameImage(&image, parsedFilename); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errors.RenameFile, err)
+			}
+			renamedFrom = &oldName
 		}
 	}
 
 	// Update the image.
-	image, err = services.UpdateImage(&image, filename, extension, mimeType, size, width, height, request.Description, imageSizes)
+	updatedImage, err := services.UpdateImage(&image, filename, extension, mimeType, size, width, height, request.Description, metadata, placeholder, animation, imageSizes, imageCrops)
 	if err != nil {
+		// Undo the rename, so the files keep matching the image in the database.
+		if renamedFrom != nil {
+			if err := services.RenameImage(&image, *renamedFrom); err != nil {
+				return errorutil.Response(c, fiber.StatusInternalServerError, errors.RenameFile, err)
+			}
+		}
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 	}
+	image = updatedImage
+
+	// Cut the crops again around the changed focal point.
+	if focalPointChanged && imageCrops == nil && isResizable && !image.Broken {
+		if _, err := services.RegenerateImageCrops(&image, quality); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.ConvertImage, err)
+		}
+	}
 
 	// Return the image.
 	response := responses.Image{}
@@ -380,149 +594,459 @@ func RestoreImage(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// Upload the image to the storage path.
-func uploadImage(appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, progress float64, fileProgress *responses.FileProgress) (width, height int, err error) {
-	path, err := services.GetPath(appStoragePath, folderID)
+// EditImage func to edit an image without touching its original.
+// The edited rendition is served by default and the derivatives are rendered from it.
+func EditImage(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
 	if err != nil {
-		return 0, 0, err
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
 
-	err = os.MkdirAll(path, os.ModePerm)
-	if err != nil {
-		return 0, 0, err
+	// Parse the request.
+	request := requests.EditImage{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
 	}
 
-	img := bimg.NewImage(data)
-	size, err := img.Size()
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Find the image.
+	image, err := services.GetImageById(id, true)
 	if err != nil {
-		return 0, 0, err
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if image.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+	} else if image.Broken {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.EditImage, "The original of the image is broken.")
+	} else if image.Animation.IsAnimated() {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.EditImage, "Animated images can not be edited.")
+	}
+
+	// Edit the image.
+	edit := models.ImageEdit{
+		Rotation:       request.Rotation,
+		FlipHorizontal: request.FlipHorizontal,
+		FlipVertical:   request.FlipVertical,
+		Brightness:     request.Brightness,
+		Contrast:       request.Contrast,
+	}
+	if request.Crop != nil {
+		edit.CropLeft = request.Crop.Left
+		edit.CropTop = request.Crop.Top
+		edit.CropWidth = request.Crop.Width
+		edit.CropHeight = request.Crop.Height
 	}
+	if err := services.EditImage(&image, edit, request.Quality); err != nil {
+		if err == services.ErrImageEditCrop {
+			return errorutil.Response(c, fiber.StatusBadRequest, errors.EditImage, "The crop box is outside the image.")
+		}
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.EditImage, err.Error())
+	}
+
+	// Return the image.
+	response := responses.Image{}
+	response.SetImage(&image, nil)
 
-	width = size.Width
-	height = size.Height
-	chunks := upload.ChunkBytes(data)
+	return c.JSON(response)
+}
 
-	file, err := os.OpenFile(path+filename, os.O_WRONLY|os.O_CREATE, os.ModePerm)
+// RevertImageEdit func to revert an edited image to its original.
+func RevertImageEdit(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
 	if err != nil {
-		return 0, 0, err
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
-	defer file.Close()
 
-	var seeker int64
-	for i, chunk := range chunks {
-		_, err := file.WriteAt(chunk, seeker)
-		if err != nil {
-			return 0, 0, err
-		}
-		seeker += int64(len(chunk))
-		percentage := float64(i) * 100.0 / float64(len(chunks))
-		if progress == 100.0 {
-			fileProgress.Progress = percentage
-			BroadcastProgress(fileProgress)
-		} else {
-			fileProgress.Progress = progress * percentage / 100.0
-			BroadcastProgress(fileProgress)
-		}
+	// Find the image.
+	image, err := services.GetImageById(id, true)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if image.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
 	}
 
-	fileProgress.Progress = progress
-	BroadcastProgress(fileProgress)
+	// Revert the image.
+	if err := services.RevertImageEdit(&image, c.QueryInt("quality", 0)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.EditImage, err.Error())
+	}
+
+	// Return the image.
+	response := responses.Image{}
+	response.SetImage(&image, nil)
 
-	return width, height, err
+	return c.JSON(response)
 }
 
-// Convert and upload the images to the storage path.
-func convertAndUploadImages(appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, quality int, progress float64, fileProgress *responses.FileProgress) ([]models.ImageSize, error) {
-	var imageSizes []models.ImageSize
-	sizes := map[enums.Size]int{
-		enums.XS:  600,
-		enums.SM:  960,
-		enums.MD:  1280,
-		enums.LG:  1920,
-		enums.XL:  2560,
-		enums.XXL: 3840,
+// CopyImage func to copy an image with its web sizes, crops and poster into a folder.
+func CopyImage(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
-	path, err := services.GetPath(appStoragePath, folderID)
+
+	// Parse the request.
+	request := requests.CopyImage{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Find the image.
+	image, err := services.GetImageById(id, true)
 	if err != nil {
-		return imageSizes, err
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if image.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
 	}
-	originalSize, err := bimg.NewImage(data).Size()
+
+	// Find the target folder.
+	folder, err := services.GetFolderWithStoragePath(request.FolderID)
 	if err != nil {
-		return imageSizes, err
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
 	}
 
-	var amountOfImagesToCreate int8
-	for _, width := range sizes {
-		if originalSize.Width > width {
-			amountOfImagesToCreate++
+	// Check if the storage path has space for the copy.
+	if available, err := services.IsStorageSpaceAvailableFor(folder.AppStoragePathID, int64(image.Size)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if !available {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathFull, "Storage path is full.")
+	}
+
+	// Check if the image is available in the target folder.
+	name := image.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	conflict := conflictStrategy(request.Conflict)
+	if conflict == enums.ConflictFail {
+		if exists, err := services.IsImageAvailable(folder.ID, name, image.Extension); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if exists {
+			return errorutil.Response(c, fiber.StatusConflict, errors.ImageExists, "Image already exists.")
 		}
 	}
 
-	calculatedProgress := (100.0 - progress) / float64(amountOfImagesToCreate)
-	var currentImage int8
-	for size, width := range sizes {
-		currentImage++
-		filenameSize := fmt.Sprintf("%s-%s.webp", filename, size)
+	// Copy the image.
+	imageCopy, _, err := services.CopyImage(&image, folder, name, conflict)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.CopyFile, err.Error())
+	}
 
-		if originalSize.Width <= width {
-			continue
-		}
+	// Return the image.
+	response := responses.Image{}
+	response.SetImage(&imageCopy, &folder.AppStoragePathID)
 
-		newHeight := originalSize.Height * width / originalSize.Width
-		resized, err := bimg.NewImage(data).Resize(width, newHeight)
-		if err != nil {
-			return imageSizes, err
-		}
+	return c.JSON(response)
+}
 
-		s, err := bimg.NewImage(resized).Size()
-		if err != nil {
-			return imageSizes, err
-		}
+// MoveImage func to move an image with its web sizes, crops and poster into a folder.
+func MoveImage(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Parse the request.
+	request := requests.MoveImage{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Move the image.
+	storagePathID, images, err := moveImages(c, []uint{id}, request.FolderID)
+	if images == nil {
+		return err
+	}
+
+	// Return the image.
+	response := responses.Image{}
+	response.SetImage(&images[0], &storagePathID)
+
+	return c.JSON(response)
+}
+
+// MoveImages func to move several images into a folder at once.
+func MoveImages(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.MoveImages{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Move the images.
+	storagePathID, images, err := moveImages(c, request.ImageIDs, request.FolderID)
+	if images == nil {
+		return err
+	}
+
+	// Return the images.
+	response := make([]responses.Image, len(images))
+	for i := range images {
+		response[i].SetImage(&images[i], &storagePathID)
+	}
+
+	return c.JSON(response)
+}
+
+// RegenerateImages func to regenerate the web sizes of images in a background job.
+func RegenerateImages(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.RegenerateImages{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Get the images to regenerate.
+	storagePath, images, err := getScopedImages(c, request.AppStoragePathID, request.FolderID, request.ImageID)
+	if storagePath == nil {
+		return err
+	}
+
+	sizes := make([]enums.Size, len(request.Sizes))
+	for i := range request.Sizes {
+		sizes[i] = enums.Size(request.Sizes[i])
+	}
+
+	// Create the job.
+	job, err := services.CreateJob(enums.RegenerateSizes, storagePath.ID, len(images))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+
+	response := responses.Job{}
+	response.SetJob(job)
+
+	// Regenerate the images in the background.
+	fileProgress := responses.FileProgress{}
+	fileProgress.SetFileProgress(storagePath.AppName, enums.Image, "", 0.0)
+	fileProgress.JobID = job.ID
+
+	services.RunJob(job, func(job *models.Job) error {
+		return services.RegenerateImages(job, images, request.Quality, sizes, func(image *models.Image) {
+			fileProgress.Filename = fmt.Sprintf("%s.%s", image.Name, image.Extension)
+			fileProgress.Progress = job.Progress()
+			BroadcastProgress(&fileProgress)
+		})
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
 
-		converted, err := bimg.NewImage(resized).Convert(bimg.WEBP)
+// GenerateImagePlaceholders func to generate the placeholders of images in a background job.
+func GenerateImagePlaceholders(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.GenerateImagePlaceholders{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate image fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Get the images to generate the placeholders for.
+	storagePath, images, err := getScopedImages(c, request.AppStoragePathID, request.FolderID, request.ImageID)
+	if storagePath == nil {
+		return err
+	}
+
+	// Create the job.
+	job, err := services.CreateJob(enums.GeneratePlaceholders, storagePath.ID, len(images))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+
+	response := responses.Job{}
+	response.SetJob(job)
+
+	// Generate the placeholders in the background.
+	fileProgress := responses.FileProgress{}
+	fileProgress.SetFileProgress(storagePath.AppName, enums.Image, "", 0.0)
+	fileProgress.JobID = job.ID
+
+	services.RunJob(job, func(job *models.Job) error {
+		return services.GenerateImagePlaceholders(job, images, request.Overwrite, func(image *models.Image) {
+			fileProgress.Filename = fmt.Sprintf("%s.%s", image.Name, image.Extension)
+			fileProgress.Progress = job.Progress()
+			BroadcastProgress(&fileProgress)
+		})
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// Upload the image to the storage path.
+func uploadImage(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, progress float64, fileProgress *responses.FileProgress) (width, height int, err error) {
+	return services.UploadImageFile(ctx, appStoragePath, folderID, filename, data, func(percentage float64) {
+		fileProgress.Progress = progress * percentage / 100.0
+		BroadcastProgress(fileProgress)
+	})
+}
+
+// Convert and upload the images to the storage path.
+func convertAndUploadImages(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, quality int, progress float64, fileProgress *responses.FileProgress) ([]models.ImageSize, error) {
+	return services.ConvertAndUploadImageFiles(ctx, appStoragePath, folderID, filename, data, quality, func(done, total int) {
+		fileProgress.Progress = progress + (100.0-progress)*float64(done)/float64(total)
+		BroadcastProgress(fileProgress)
+	})
+}
+
+// Delete the image from the storage path.
+func deleteImage(image *models.Image) error {
+	return services.DeleteImageFiles(image)
+}
+
+// getScopedImages gets the images of a storage path, limited to the folder with its sub folders
+// or to a single image when given. The storage path is nil when an error response is sent.
+func getScopedImages(c *fiber.Ctx, appStoragePathID uint, folderID, imageID *uint) (*models.AppStoragePath, []models.Image, error) {
+	// Check if the storage path exists.
+	storagePath, err := services.GetStoragePath(appStoragePathID)
+	if err != nil {
+		return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+	} else if storagePath.ID == 0 {
+		return nil, nil, errorutil.Response(c, fiber.StatusNotFound, errors.StoragePathExists, "Storage path does not exist.")
+	}
+
+	// Get the images of the scope.
+	var images []models.Image
+	switch {
+	case imageID != nil:
+		image, err := services.GetImageById(*imageID, true)
 		if err != nil {
-			return imageSizes, err
+			return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if image.ID == 0 || image.Folder.AppStoragePathID != storagePath.ID {
+			return nil, nil, errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
 		}
-
-		processed, err := bimg.NewImage(converted).Process(bimg.Options{Quality: quality})
+		images = []models.Image{image}
+	case folderID != nil:
+		folder, _, err := services.GetFolder(*folderID)
 		if err != nil {
-			return imageSizes, err
+			return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		} else if folder.ID == 0 || folder.AppStoragePathID != storagePath.ID {
+			return nil, nil, errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
 		}
 
-		err = bimg.Write(path+filenameSize, processed)
+		folderIDs, err := services.GetFolderDescendantIDs(storagePath.ID, folder.ID)
 		if err != nil {
-			return imageSizes, err
+			return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
 		}
 
-		imageSizes = append(imageSizes, models.ImageSize{
-			Size:   size,
-			Width:  s.Width,
-			Height: s.Height,
-		})
-
-		fileProgress.Progress = progress + calculatedProgress*float64(currentImage)
-		BroadcastProgress(fileProgress)
+		if images, err = services.GetImagesByFolderIDs(append(folderIDs, folder.ID)); err != nil {
+			return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
+	default:
+		if images, err = services.GetImagesByStoragePath(storagePath.ID); err != nil {
+			return nil, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err)
+		}
 	}
 
-	return imageSizes, nil
+	return storagePath, images, nil
 }
 
-// Delete the image from the storage path.
-func deleteImage(image *models.Image) error {
-	path, err := services.GetPath(&image.Folder.AppStoragePath, image.FolderID)
+// moveImages moves the images into the folder after checking the conflicts and the space of the storage path.
+// Images that are already in the folder stay as they are. The images are nil when an error response is sent.
+func moveImages(c *fiber.Ctx, imageIDs []uint, folderID uint) (uint, []models.Image, error) {
+	// Find the target folder.
+	folder, err := services.GetFolderWithStoragePath(folderID)
 	if err != nil {
-		return err
+		return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return 0, nil, errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
 	}
 
-	if err := os.Remove(fmt.Sprintf("%s%s.%s", path, image.Name, image.Extension)); err != nil {
-		return err
+	// Find the images.
+	images, err := services.GetImagesByIDs(imageIDs)
+	if err != nil {
+		return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	found := make(map[uint]bool, len(images))
+	for i := range images {
+		found[images[i].ID] = true
+	}
+	for _, id := range imageIDs {
+		if !found[id] {
+			return 0, nil, errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, fmt.Sprintf("Image %d does not exist.", id))
+		}
+	}
+
+	// Check if the images are available in the target folder.
+	moving := make([]models.Image, 0, len(images))
+	movingIndexes := make([]int, 0, len(images))
+	names := make(map[string]bool, len(images))
+	var size int64
+	for i := range images {
+		if images[i].FolderID == folder.ID {
+			continue
+		}
+
+		filename := fmt.Sprintf("%s.%s", images[i].Name, images[i].Extension)
+		if exists, err := services.IsImageAvailable(folder.ID, images[i].Name, images[i].Extension); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if exists || names[filename] {
+			return 0, nil, errorutil.Response(c, fiber.StatusConflict, errors.ImageExists, fmt.Sprintf("Image %s already exists.", filename))
+		}
+		names[filename] = true
+
+		if images[i].Folder.AppStoragePathID != folder.AppStoragePathID {
+			size += int64(images[i].Size)
+		}
+		moving = append(moving, images[i])
+		movingIndexes = append(movingIndexes, i)
 	}
 
-	for i := range image.ImageSizes {
-		if err := os.Remove(fmt.Sprintf("%s%s-%s.webp", path, image.Name, image.ImageSizes[i].Size.String())); err != nil {
-			return err
+	// Check if the storage path has space for the images of other storage paths.
+	if size > 0 {
+		if available, err := services.IsStorageSpaceAvailableFor(folder.AppStoragePathID, size); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if !available {
+			return 0, nil, errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathFull, "Storage path is full.")
+		}
+	}
+
+	// Move the images.
+	if len(moving) > 0 {
+		if err := services.MoveImages(moving, folder); err != nil {
+			return 0, nil, errorutil.Response(c, fiber.StatusInternalServerError, errors.MoveFile, err.Error())
+		}
+		for i, index := range movingIndexes {
+			images[index] = moving[i]
 		}
 	}
 
-	return nil
+	return folder.AppStoragePathID, images, nil
 }
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/errors"
+	"api-file/main/src/services"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJob func to get the state of a background job.
+func GetJob(c *fiber.Ctx) error {
+	// Get the job.
+	job, err := services.GetJob(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	} else if job == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.JobExists, "Job does not exist.")
+	}
+
+	// Return the job.
+	response := responses.Job{}
+	response.SetJob(job)
+
+	return c.JSON(response)
+}
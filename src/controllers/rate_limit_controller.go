@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/errors"
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+	"fmt"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRateLimits func to get the rate limits of an app.
+func GetRateLimits(c *fiber.Ctx) error {
+	// Check if the app exists.
+	app := c.Params("name")
+	if available, err := services.IsAppAvailable(app); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if !available {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Get the rate limits.
+	rateLimits, err := services.GetRateLimits(app)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.JSON(rateLimitResponses(rateLimits))
+}
+
+// UpdateRateLimits func to replace the rate limits of an app.
+func UpdateRateLimits(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.UpdateRateLimits{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate rate limit fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Check if the app exists.
+	app := c.Params("name")
+	if available, err := services.IsAppAvailable(app); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if !available {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// A group can only have one rate limit.
+	rateLimits := make([]models.RateLimit, len(request.RateLimits))
+	groups := make(map[string]bool, len(request.RateLimits))
+	for i, rateLimit := range request.RateLimits {
+		if groups[rateLimit.Group] {
+			return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, fmt.Sprintf("Group %s has more than one rate limit.", rateLimit.Group))
+		}
+		groups[rateLimit.Group] = true
+
+		rateLimits[i] = models.RateLimit{
+			RouteGroup:    rateLimit.Group,
+			Requests:      rateLimit.Requests,
+			UploadBytes:   rateLimit.UploadBytes,
+			DownloadBytes: rateLimit.DownloadBytes,
+			Window:        rateLimit.Window,
+		}
+	}
+
+	// Replace the rate limits.
+	rateLimits, err := services.ReplaceRateLimits(app, rateLimits)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.JSON(rateLimitResponses(rateLimits))
+}
+
+// rateLimitResponses maps the rate limits to their responses.
+func rateLimitResponses(rateLimits []models.RateLimit) []responses.RateLimit {
+	response := make([]responses.RateLimit, len(rateLimits))
+	for i := range rateLimits {
+		response[i].SetRateLimit(&rateLimits[i])
+	}
+
+	return response
+}
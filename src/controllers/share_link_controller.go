@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
+	"api-file/main/src/errors"
+	"api-file/main/src/models"
+	"api-file/main/src/services"
+	"bufio"
+	"fmt"
+	"time"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// shareLinkPasswordHeader is the header with the password of a share link.
+const shareLinkPasswordHeader = "X-Share-Password"
+
+// CreateShareLink func to share an image, document or folder with a link.
+func CreateShareLink(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.CreateShareLink{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate share link fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.ShareLinkExpired, "The expiry date has to be in the future.")
+	}
+
+	// Check if the shared item exists.
+	switch {
+	case request.ImageID != nil:
+		if image, err := services.GetImageById(*request.ImageID, false); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if image.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+		}
+	case request.DocumentID != nil:
+		if document, err := services.GetDocumentById(*request.DocumentID); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if document.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+		}
+	default:
+		if folder, err := services.GetFolderWithStoragePath(*request.FolderID); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if folder.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+		}
+	}
+
+	// Create the share link.
+	shareLink := models.ShareLink{ImageID: request.ImageID, DocumentID: request.DocumentID, FolderID: request.FolderID}
+	if err := services.CreateShareLink(&shareLink, request.Password, request.ExpiresAt, request.MaxDownloads); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the share link.
+	response := responses.ShareLink{}
+	response.SetShareLink(&shareLink)
+
+	return c.JSON(response)
+}
+
+// GetShareLink func to get a share link by ID.
+func GetShareLink(c *fiber.Ctx) error {
+	// Find the share link.
+	shareLink, err := findShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	// Return the share link.
+	response := responses.ShareLink{}
+	response.SetShareLink(shareLink)
+
+	return c.JSON(response)
+}
+
+// UpdateShareLink func to update the password, expiry date and download limit of a share link.
+func UpdateShareLink(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.UpdateShareLink{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate share link fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.ShareLinkExpired, "The expiry date has to be in the future.")
+	}
+
+	// Find the share link.
+	shareLink, err := findShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	// Update the share link.
+	if err := services.UpdateShareLink(shareLink, request.Password, request.ExpiresAt, request.MaxDownloads); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the share link.
+	response := responses.ShareLink{}
+	response.SetShareLink(shareLink)
+
+	return c.JSON(response)
+}
+
+// RevokeShareLink func to revoke a share link, so it can not be used anymore.
+func RevokeShareLink(c *fiber.Ctx) error {
+	// Find the share link.
+	shareLink, err := findShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	// Revoke the share link.
+	if err := services.RevokeShareLink(shareLink); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetSharedFile func to download a shared image or document, or to list a shared folder.
+// Downloads are counted, listing a folder is not.
+func GetSharedFile(c *fiber.Ctx) error {
+	// Find the share link.
+	shareLink, err := usableShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	switch shareLink.FileType() {
+	case enums.Image:
+		image, err := services.GetImage(*shareLink.ImageID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if image.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+		}
+
+		return sendSharedImage(c, shareLink, &image)
+	case enums.Document:
+		document, err := services.GetDocumentById(*shareLink.DocumentID)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if document.ID == 0 {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+		}
+
+		return sendSharedDocument(c, shareLink, &document)
+	default:
+		tree, err := sharedFolderTree(c, shareLink)
+		if tree == nil {
+			return err
+		}
+
+		response := responses.SharedFolder{}
+		response.SetSharedFolder(tree, shareLink.Token)
+
+		return c.JSON(response)
+	}
+}
+
+// GetSharedFolderZip func to download a shared folder as a ZIP archive, which counts as one download.
+func GetSharedFolderZip(c *fiber.Ctx) error {
+	// Find the share link.
+	shareLink, err := usableShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	tree, err := sharedFolderTree(c, shareLink)
+	if tree == nil {
+		return err
+	}
+
+	// Check the files, because the status is sent once the archive is streamed.
+	if err := services.CheckFolderZip(tree); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, err.Error())
+	}
+
+	if counted, err := countShareLinkDownload(c, shareLink); !counted {
+		return err
+	}
+
+	// Stream the archive as a response, an error while it is written leaves the archive incomplete.
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", tree.Folder.Name+".zip"))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		_ = services.WriteFolderZip(w, tree)
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// GetSharedFolderImage func to download an image inside a shared folder.
+func GetSharedFolderImage(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Find the share link.
+	shareLink, err := usableShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	tree, err := sharedFolderTree(c, shareLink)
+	if tree == nil {
+		return err
+	}
+
+	image := tree.FindImage(id)
+	if image == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.ImageExists, "Image does not exist.")
+	}
+
+	return sendSharedImage(c, shareLink, image)
+}
+
+// GetSharedFolderDocument func to download a document inside a shared folder.
+func GetSharedFolderDocument(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Find the share link.
+	shareLink, err := usableShareLink(c)
+	if shareLink == nil {
+		return err
+	}
+
+	tree, err := sharedFolderTree(c, shareLink)
+	if tree == nil {
+		return err
+	}
+
+	document := tree.FindDocument(id)
+	if document == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DocumentExist, "Document does not exist.")
+	}
+
+	return sendSharedDocument(c, shareLink, document)
+}
+
+// findShareLink finds the share link of the ID in the URL.
+// The share link is nil when the error response is sent.
+func findShareLink(c *fiber.Ctx) (*models.ShareLink, error) {
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	shareLink, err := services.GetShareLink(id)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if shareLink.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.ShareLinkExists, "Share link does not exist.")
+	}
+
+	return &shareLink, nil
+}
+
+// usableShareLink finds the share link of the token in the URL and checks if it is not revoked
+// or expired and if the password in the header is right.
+// The share link is nil when the error response is sent.
+func usableShareLink(c *fiber.Ctx) (*models.ShareLink, error) {
+	shareLink, err := services.GetShareLinkByToken(c.Params("token"))
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if shareLink.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.ShareLinkExists, "Share link does not exist.")
+	}
+
+	switch err := services.CheckShareLink(&shareLink, c.Get(shareLinkPasswordHeader)); err {
+	case nil:
+		return &shareLink, nil
+	case services.ErrShareLinkRevoked, services.ErrShareLinkExpired:
+		return nil, errorutil.Response(c, fiber.StatusGone, errors.ShareLinkExpired, "Share link is expired.")
+	case services.ErrShareLinkPassword:
+		return nil, errorutil.Response(c, fiber.StatusUnauthorized, errors.ShareLinkPassword, "Share link password is wrong.")
+	default:
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+}
+
+// sharedFolderTree gets the tree of the folder of the share link.
+// The tree is nil when the error response is sent.
+func sharedFolderTree(c *fiber.Ctx, shareLink *models.ShareLink) (*models.FolderTree, error) {
+	if shareLink.FolderID == nil {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+	}
+
+	folder, err := services.GetFolderWithStoragePath(*shareLink.FolderID)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if folder.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.FolderExists, "Folder does not exist.")
+	}
+
+	tree, err := services.GetFolderTree(folder)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return tree, nil
+}
+
+// countShareLinkDownload counts a download of the share link. It reports false
+// when the error response is sent, for example when no downloads are left.
+func countShareLinkDownload(c *fiber.Ctx, shareLink *models.ShareLink) (bool, error) {
+	if err := services.CountShareLinkDownload(shareLink); err == services.ErrShareLinkDownloads {
+		return false, errorutil.Response(c, fiber.StatusGone, errors.ShareLinkDownloads, "Share link has no downloads left.")
+	} else if err != nil {
+		return false, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return true, nil
+}
+
+// sendSharedImage counts the download and sends the image as it is served publicly.
+func sendSharedImage(c *fiber.Ctx, shareLink *models.ShareLink, image *models.Image) error {
+	path, err := services.GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	if counted, err := countShareLinkDownload(c, shareLink); !counted {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentSecurityPolicy, services.ImageContentSecurityPolicy)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return sendStorageFile(c, services.ImagePublicFilePath(path, image))
+}
+
+// sendSharedDocument counts the download and sends the document, unless it is quarantined.
+func sendSharedDocument(c *fiber.Ctx, shareLink *models.ShareLink, document *models.Document) error {
+	if document.ScanStatus == enums.Infected {
+		return errorutil.Response(c, fiber.StatusForbidden, errors.DocumentInfected, "Document is quarantined.")
+	}
+
+	path, err := services.GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	if counted, err := countShareLinkDownload(c, shareLink); !counted {
+		return err
+	}
+
+	return sendStorageFile(c, services.DocumentFilePath(path, document))
+}
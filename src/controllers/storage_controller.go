@@ -4,9 +4,15 @@ import (
 	"api-file/main/src/database"
 	"api-file/main/src/dto/requests"
 	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
 	"api-file/main/src/errors"
+	"api-file/main/src/metrics"
 	"api-file/main/src/models"
 	"api-file/main/src/services"
+	upload "api-file/main/src/utils"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/ArnoldPMolenaar/api-utils/pagination"
@@ -141,8 +147,19 @@ func CreateStoragePath(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.StoragePathAvailable, "Storage path already available.")
 	}
 
+	// Check if the files can be encrypted.
+	if request.Encrypted && !services.IsEncryptionConfigured() {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.EncryptStorage, "Encryption master key is not configured.")
+	}
+
+	// Read the watermark.
+	storageWatermark, err := watermark(request.Watermark)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Watermark, err.Error())
+	}
+
 	// Create the storage path.
-	storagePath, err := services.CreateStoragePath(request.App, request.Path, request.Limit)
+	storagePath, err := services.CreateStoragePath(request.App, request.Path, request.Limit, stripMetadata(request.StripMetadata), animationMode(request.AnimationMode), request.AnimationPoster, request.Encrypted, storageWatermark)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
@@ -198,12 +215,32 @@ func UpdateStoragePath(c *fiber.Ctx) error {
 		}
 	}
 
+	// Check if the files can be encrypted.
+	if request.Encrypted && !services.IsEncryptionConfigured() {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.EncryptStorage, "Encryption master key is not configured.")
+	}
+
+	// Read the watermark.
+	storageWatermark, err := watermark(request.Watermark)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Watermark, err.Error())
+	}
+	watermarkChanged := !storagePath.Watermark.Equal(&storageWatermark)
+
 	// Update the storage path.
-	storagePath, err = services.UpdateStoragePath(storagePath, request.App, request.Path, request.Limit)
+	storagePath, err = services.UpdateStoragePath(storagePath, request.App, request.Path, request.Limit, stripMetadata(request.StripMetadata), animationMode(request.AnimationMode), request.AnimationPoster, request.Encrypted, storageWatermark)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
+	// Render the public renditions of the images again with the new watermark.
+	var job *models.Job
+	if watermarkChanged {
+		if job, err = rewatermarkImages(storagePath); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+	}
+
 	// Get the used space.
 	usedSpace, err := services.GetUsedSpace(storagePath.ID)
 	if err != nil {
@@ -213,6 +250,78 @@ func UpdateStoragePath(c *fiber.Ctx) error {
 	// Return the storage path.
 	response := responses.AppStoragePath{}
 	response.SetAppStoragePath(storagePath, usedSpace)
+	if job != nil {
+		response.Job = &responses.Job{}
+		response.Job.SetJob(job)
+	}
+
+	return c.JSON(response)
+}
+
+// CheckStoragePath func to report the inconsistencies between the files and the database of a storage path.
+func CheckStoragePath(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Find the storage path.
+	storagePath, err := services.GetStoragePath(id)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if storagePath == nil || storagePath.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.StoragePathExists, "Storage path does not exist.")
+	}
+
+	// Check the storage path.
+	issues, err := services.CheckStoragePath(storagePath, services.FsckOptions{})
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.CheckStorage, err.Error())
+	}
+
+	// Return the issues.
+	response := responses.Fsck{}
+	response.SetFsck(storagePath.ID, issues)
+
+	return c.JSON(response)
+}
+
+// RepairStoragePath func to repair the inconsistencies between the files and the database of a storage path.
+func RepairStoragePath(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	id, err := utils.StringToUint(c.Params("id"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Parse the request.
+	request := requests.RepairAppStoragePath{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Find the storage path.
+	storagePath, err := services.GetStoragePath(id)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if storagePath == nil || storagePath.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.StoragePathExists, "Storage path does not exist.")
+	}
+
+	// Check and repair the storage path.
+	issues, err := services.CheckStoragePath(storagePath, services.FsckOptions{
+		DeleteOrphans:   request.DeleteOrphans,
+		MarkBroken:      request.MarkBroken,
+		RegenerateSizes: request.RegenerateSizes,
+	})
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.CheckStorage, err.Error())
+	}
+
+	// Return the issues.
+	response := responses.Fsck{}
+	response.SetFsck(storagePath.ID, issues)
 
 	return c.JSON(response)
 }
@@ -229,3 +338,123 @@ func toStoragePathPagination(storagePaths []models.AppStoragePath) []responses.A
 
 	return result
 }
+
+// stripMetadata converts the requested strip option and defaults to keeping the metadata.
+func stripMetadata(strip string) enums.StripMetadata {
+	if strip == "" {
+		return enums.StripNone
+	}
+
+	return enums.StripMetadata(strip)
+}
+
+// animationMode converts the requested animation mode and defaults to animated web sizes.
+func animationMode(mode string) enums.AnimationMode {
+	if mode == "" {
+		return enums.AnimationAnimate
+	}
+
+	return enums.AnimationMode(mode)
+}
+
+// watermark converts the requested watermark, which is empty when none is requested.
+// The position, opacity and scale default to the bottom right, half transparent and a quarter of the width.
+func watermark(request *requests.Watermark) (models.Watermark, error) {
+	if request == nil {
+		return models.Watermark{}, nil
+	}
+
+	storageWatermark := models.Watermark{
+		Text:     request.Text,
+		Position: enums.WatermarkBottomRight,
+		Opacity:  0.5,
+		Scale:    0.25,
+		Sizes:    strings.Join(request.Sizes, ","),
+	}
+	if request.Position != "" {
+		storageWatermark.Position = enums.WatermarkPosition(request.Position)
+	}
+	if request.Opacity > 0 {
+		storageWatermark.Opacity = request.Opacity
+	}
+	if request.Scale > 0 {
+		storageWatermark.Scale = request.Scale
+	}
+
+	if request.Image != "" {
+		_, base64Data, err := upload.GetMimeTypeAndBase64(request.Image)
+		if err != nil {
+			return models.Watermark{}, err
+		}
+		data, err := upload.Base64ToBytes(base64Data)
+		if err != nil {
+			return models.Watermark{}, err
+		}
+		if err := services.ValidateWatermarkImage(data); err != nil {
+			return models.Watermark{}, err
+		}
+		storageWatermark.Image = data
+	}
+
+	return storageWatermark, nil
+}
+
+// rewatermarkImages starts a job that renders the public renditions of the images
+// of the storage path again, after its watermark is changed.
+func rewatermarkImages(storagePath *models.AppStoragePath) (*models.Job, error) {
+	images, err := services.GetImagesByStoragePath(storagePath.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := services.CreateJob(enums.Rewatermark, storagePath.ID, len(images))
+	if err != nil {
+		return nil, err
+	}
+
+	fileProgress := responses.FileProgress{}
+	fileProgress.SetFileProgress(storagePath.AppName, enums.Image, "", 0.0)
+	fileProgress.JobID = job.ID
+
+	services.RunJob(job, func(job *models.Job) error {
+		return services.RewatermarkImages(job, images, func(image *models.Image) {
+			fileProgress.Filename = fmt.Sprintf("%s.%s", image.Name, image.Extension)
+			fileProgress.Progress = job.Progress()
+			BroadcastProgress(&fileProgress)
+		})
+	})
+
+	return job, nil
+}
+
+// sendStorageFile sends a file of a storage path. Encrypted files are decrypted in memory,
+// other files are sent from disk.
+func sendStorageFile(c *fiber.Ctx, filePath string) error {
+	if encrypted, err := services.IsEncryptedFile(filePath); err != nil || !encrypted {
+		if err := c.SendFile(filePath); err != nil {
+			return err
+		}
+		countServedBytes(c, filePath, c.Response().Header.ContentLength())
+		return nil
+	}
+
+	data, err := services.ReadStorageFile(filePath)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DecryptFile, err.Error())
+	}
+
+	c.Type(strings.TrimPrefix(filepath.Ext(filePath), "."))
+	countServedBytes(c, filePath, len(data))
+	return c.Send(data)
+}
+
+// countServedBytes counts the bytes of a file that is sent for the app of its storage path.
+// Responses without a body, like a not modified response, are not counted.
+func countServedBytes(c *fiber.Ctx, filePath string, size int) {
+	status := c.Response().StatusCode()
+	if size <= 0 || c.Method() == fiber.MethodHead || (status != fiber.StatusOK && status != fiber.StatusPartialContent) {
+		return
+	}
+
+	metrics.BytesServed.WithLabelValues(services.GetStoragePathAppName(filePath)).Add(float64(size))
+}
@@ -3,10 +3,12 @@ package controllers
 import (
 	"api-file/main/src/dto/responses"
 	"api-file/main/src/errors"
+	"api-file/main/src/metrics"
 	"api-file/main/src/services"
 	"encoding/json"
 	"log"
 	"strconv"
+	"sync"
 
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/gofiber/contrib/websocket"
@@ -16,6 +18,9 @@ import (
 // ProgressConnections is a map of WebSocket connections from clients.
 var ProgressConnections = make(map[*websocket.Conn]bool)
 
+// progressMutex guards the connections, because progress is also sent by background jobs.
+var progressMutex sync.Mutex
+
 // WebSocketProgress is a WebSocket handler that sends progress updates to the client.
 func WebSocketProgress(c *websocket.Conn) {
 	// Access query parameters.
@@ -34,10 +39,16 @@ func WebSocketProgress(c *websocket.Conn) {
 		return
 	}
 
+	progressMutex.Lock()
 	ProgressConnections[c] = true
+	progressMutex.Unlock()
+	metrics.WebSocketConnections.Inc()
 
 	defer func() {
+		progressMutex.Lock()
 		delete(ProgressConnections, c)
+		progressMutex.Unlock()
+		metrics.WebSocketConnections.Dec()
 		c.Close()
 	}()
 
@@ -59,6 +70,9 @@ func BroadcastProgress(data *responses.FileProgress) {
 		return
 	}
 
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
 	for c := range ProgressConnections {
 		_ = c.WriteMessage(websocket.TextMessage, message)
 	}
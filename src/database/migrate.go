@@ -18,6 +18,16 @@ func Migrate(db *gorm.DB) error {
 		return tx.Error
 	}
 
+	// Adds the crop enum type to the database.
+	if tx := db.Exec(`DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'crop') THEN 
+			CREATE TYPE crop AS ENUM ('avatar', 'card', 'banner', 'wide'); 
+		END IF; 
+	END $$;`); tx.Error != nil {
+		return tx.Error
+	}
+
 	err := db.AutoMigrate(
 		&models.App{},
 		&models.AppStoragePath{},
@@ -25,7 +35,10 @@ func Migrate(db *gorm.DB) error {
 		&models.FolderFolder{},
 		&models.Document{},
 		&models.Image{},
-		&models.ImageSize{})
+		&models.ImageSize{},
+		&models.ImageCrop{},
+		&models.ShareLink{},
+		&models.RateLimit{})
 	if err != nil {
 		return err
 	}
@@ -1,12 +1,18 @@
 package database
 
 import (
+	"api-file/main/src/tracing"
+	"sync/atomic"
+
 	"github.com/ArnoldPMolenaar/api-utils/database"
 	"gorm.io/gorm"
 )
 
 var Pg *gorm.DB
 
+// connected is set when Pg can be used.
+var connected atomic.Bool
+
 // OpenDBConnection Start a new database connection.
 // Also tries to migrate the database schema.
 func OpenDBConnection() error {
@@ -16,6 +22,11 @@ func OpenDBConnection() error {
 		return err
 	}
 
+	// Trace the queries.
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return err
+	}
+
 	// Migrate the database schema.
 	err = Migrate(db)
 	if err != nil {
@@ -24,6 +35,12 @@ func OpenDBConnection() error {
 
 	// Set the global DB variable.
 	Pg = db
+	connected.Store(true)
 
 	return nil
 }
+
+// IsConnected checks if the database connection is opened.
+func IsConnected() bool {
+	return connected.Load()
+}
@@ -0,0 +1,295 @@
+package docs
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Version is the version of the API that is documented.
+const Version = "v1"
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups the operations.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by their lowercase method.
+type PathItem map[string]*OperationObject
+
+// OperationObject describes an operation of a path.
+type OperationObject struct {
+	Tags        []string                   `json:"tags,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []ParameterObject          `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// ParameterObject describes a path, query or header parameter.
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// ResponseObject describes a response of an operation.
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a header of a response.
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas that are referenced.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how a client authenticates.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// machineKeyScheme is the name of the security scheme of the machine key.
+const machineKeyScheme = "machineKey"
+
+// Generate func to generate the OpenAPI document of the registered routes with their operations.
+// The HEAD routes that Fiber adds for GET routes are left out, as are routes without an operation.
+func Generate(routes []fiber.Route) *Document {
+	g := newGenerator()
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "API-File",
+			Description: "Storage of images and documents for apps, with web sizes, crops, previews and share links.",
+			Version:     Version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				machineKeyScheme: {Type: "apiKey", In: "header", Name: "x-machine-key", Description: "The MACHINE_KEY of the service."},
+			},
+		},
+	}
+
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		operation, ok := Operations[RouteKey(route.Method, route.Path)]
+		if !ok {
+			continue
+		}
+
+		path := openAPIPath(route.Path)
+		item, ok := document.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			document.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = g.operation(route, &operation)
+		tags[operation.Tag] = true
+	}
+
+	for tag := range tags {
+		document.Tags = append(document.Tags, Tag{Name: tag})
+	}
+	sort.Slice(document.Tags, func(i, j int) bool { return document.Tags[i].Name < document.Tags[j].Name })
+
+	return document
+}
+
+// RouteKey func to get the key of the operation of a route, like "GET /v1/images/:id".
+// The trailing slash of the root of a group is left out.
+func RouteKey(method, path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return method + " " + path
+}
+
+// IsDocumented func to check if a registered route needs no operation or has one.
+func IsDocumented(route fiber.Route) bool {
+	if route.Method == fiber.MethodHead {
+		return true
+	}
+
+	_, ok := Operations[RouteKey(route.Method, route.Path)]
+	return ok
+}
+
+// operation creates the operation of the route.
+func (g *generator) operation(route fiber.Route, operation *Operation) *OperationObject {
+	object := &OperationObject{
+		Tags:        []string{operation.Tag},
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		OperationID: operation.ID,
+		Responses:   make(map[string]*ResponseObject),
+	}
+	if operation.Machine {
+		object.Security = []map[string][]string{{machineKeyScheme: {}}}
+	}
+
+	// Parameters.
+	for _, param := range route.Params {
+		schema, ok := operation.PathParams[param]
+		if !ok {
+			schema = pathParamSchema(param)
+		}
+		object.Parameters = append(object.Parameters, ParameterObject{Name: param, In: "path", Required: true, Schema: schema})
+	}
+	for _, param := range operation.Query {
+		object.Parameters = append(object.Parameters, ParameterObject{Name: param.Name, In: "query", Description: param.Description, Required: param.Required, Schema: param.Schema})
+	}
+	for _, param := range operation.Headers {
+		object.Parameters = append(object.Parameters, ParameterObject{Name: param.Name, In: "header", Description: param.Description, Required: param.Required, Schema: param.Schema})
+	}
+	idempotent := operation.Machine && (route.Method == fiber.MethodPost || route.Method == fiber.MethodPut)
+	if idempotent {
+		object.Parameters = append(object.Parameters, ParameterObject{
+			Name:        "Idempotency-Key",
+			In:          "header",
+			Description: "Replays the response of the first request when the request is retried with the same key.",
+			Schema:      &Schema{Type: "string", MaxLength: intPointer(255)},
+		})
+	}
+
+	// Request body.
+	if operation.Request != nil {
+		object.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: g.schema(reflect.TypeOf(operation.Request))}},
+		}
+	}
+
+	// Successful response.
+	status := operation.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := &ResponseObject{Description: http.StatusText(status)}
+	if operation.Response != nil || operation.File != "" {
+		success.Content = make(map[string]MediaType)
+	}
+	if operation.File != "" {
+		success.Content[operation.File] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	if operation.Response != nil {
+		success.Content[fiber.MIMEApplicationJSON] = MediaType{Schema: g.schema(reflect.TypeOf(operation.Response))}
+	}
+	object.Responses[statusKey(status)] = success
+
+	// Error responses.
+	errorStatuses := append([]int{fiber.StatusInternalServerError}, operation.Errors...)
+	if operation.Request != nil || len(route.Params) > 0 {
+		errorStatuses = append(errorStatuses, fiber.StatusBadRequest)
+	}
+	if len(route.Params) > 0 {
+		errorStatuses = append(errorStatuses, fiber.StatusNotFound)
+	}
+	if operation.Machine {
+		errorStatuses = append(errorStatuses, fiber.StatusUnauthorized)
+	}
+	if idempotent {
+		errorStatuses = append(errorStatuses, fiber.StatusConflict, fiber.StatusUnprocessableEntity)
+	}
+	if operation.RateLimited {
+		errorStatuses = append(errorStatuses, fiber.StatusTooManyRequests)
+	}
+	for _, errorStatus := range errorStatuses {
+		if _, ok := object.Responses[statusKey(errorStatus)]; ok {
+			continue
+		}
+
+		response := &ResponseObject{
+			Description: http.StatusText(errorStatus),
+			Content:     map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: &Schema{Ref: "#/components/schemas/" + errorSchema}}},
+		}
+		if errorStatus == fiber.StatusTooManyRequests {
+			response.Headers = map[string]Header{
+				fiber.HeaderRetryAfter: {Description: "Seconds until the limit resets.", Schema: &Schema{Type: "integer"}},
+			}
+		}
+		object.Responses[statusKey(errorStatus)] = response
+	}
+
+	return object
+}
+
+// openAPIPath converts the params of a Fiber path, like /v1/images/:id, to /v1/images/{id}.
+func openAPIPath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimPrefix(segment, ":") + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// pathParamSchema gets the schema of a path param by its name.
+func pathParamSchema(param string) *Schema {
+	switch param {
+	case "id", "page":
+		return &Schema{Type: "integer", Minimum: floatPointer(1)}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// statusKey gets the key of a status in the responses.
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,87 @@
+package docs_test
+
+import (
+	"api-file/main/src/docs"
+	"api-file/main/src/routes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newApp registers the routes like main does.
+func newApp() *fiber.App {
+	app := fiber.New()
+	routes.PrivateRoutes(app)
+	routes.WebSocketRoutes(app)
+	routes.PublicRoutes(app)
+	routes.MetricsRoutes(app)
+	routes.HealthRoutes(app)
+	routes.DocsRoutes(app)
+
+	return app
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	for _, route := range newApp().GetRoutes(true) {
+		if !docs.IsDocumented(route) {
+			t.Errorf("route %s has no operation in docs.Operations", docs.RouteKey(route.Method, route.Path))
+		}
+	}
+}
+
+func TestEveryOperationIsRegistered(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, route := range newApp().GetRoutes(true) {
+		registered[docs.RouteKey(route.Method, route.Path)] = true
+	}
+
+	for key := range docs.Operations {
+		if !registered[key] {
+			t.Errorf("operation %s has no registered route", key)
+		}
+	}
+}
+
+func TestEveryErrorCodeIsDocumented(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../errors/errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		literal, ok := node.(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			return true
+		}
+
+		code, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(docs.ErrorCodes, code) {
+			t.Errorf("error code %s is not in docs.ErrorCodes", code)
+		}
+
+		return true
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	document := docs.Generate(newApp().GetRoutes(true))
+
+	if _, err := json.Marshal(document); err != nil {
+		t.Fatal(err)
+	}
+	if item, ok := document.Paths["/v1/images/{id}"]; !ok || (*item)["get"] == nil {
+		t.Error("GET /v1/images/{id} is not in the document")
+	}
+	if _, ok := document.Components.Schemas["Image"]; !ok {
+		t.Error("the Image schema is not in the components")
+	}
+}
@@ -0,0 +1,287 @@
+package docs
+
+import (
+	"api-file/main/src/dto/requests"
+	"api-file/main/src/dto/responses"
+	"api-file/main/src/enums"
+	"api-file/main/src/errors"
+	"fmt"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operation documents a route. The path params are taken from the route, the error responses
+// follow from the request body, the path params, the machine key, the idempotency key and the rate limits.
+type Operation struct {
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+	Machine     bool
+	RateLimited bool
+	Request     interface{}
+	Response    interface{}
+	Status      int
+	File        string
+	PathParams  map[string]*Schema
+	Query       []Parameter
+	Headers     []Parameter
+	Errors      []int
+}
+
+// Parameter documents a query or header parameter.
+type Parameter struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// storagePathPage is the page of storage paths that is returned by the pagination of api-utils.
+type storagePathPage struct {
+	Limit     int                                `json:"limit"`
+	Page      int                                `json:"page"`
+	PageCount int                                `json:"pageCount"`
+	Total     int                                `json:"total"`
+	Result    []responses.AppStoragePathPaginate `json:"result"`
+}
+
+// ErrorCodes lists the codes of the error responses, of api-utils and of the service.
+var ErrorCodes = []string{
+	errorutil.NotFound,
+	errorutil.Unauthorized,
+	errorutil.InternalServerError,
+	errorutil.BodyParse,
+	errorutil.Validator,
+	errorutil.QueryError,
+	errorutil.CacheError,
+	errorutil.Forbidden,
+	errorutil.MissingRequiredParam,
+	errorutil.InvalidParam,
+	errorutil.OutOfSync,
+	errors.AppExists,
+	errors.StoragePathExists,
+	errors.StoragePathAvailable,
+	errors.StoragePathFull,
+	errors.FolderExists,
+	errors.FolderImmutable,
+	errors.FolderInside,
+	errors.ImageExists,
+	errors.ImageTypeInvalid,
+	errors.ImageLocked,
+	errors.ParseBase64,
+	errors.ParseFilename,
+	errors.DeleteImage,
+	errors.UploadImage,
+	errors.ConvertImage,
+	errors.EditImage,
+	errors.CodeInvalid,
+	errors.CodeExists,
+	errors.DocumentExist,
+	errors.DocumentTypeInvalid,
+	errors.UploadDocument,
+	errors.DeleteDocument,
+	errors.DocumentPageExists,
+	errors.PreviewDocument,
+	errors.ScanDocument,
+	errors.DocumentInfected,
+	errors.EncryptStorage,
+	errors.Watermark,
+	errors.DecryptFile,
+	errors.CheckStorage,
+	errors.CopyFile,
+	errors.MoveFile,
+	errors.RenameFile,
+	errors.JobExists,
+	errors.ShareLinkExists,
+	errors.ShareLinkExpired,
+	errors.ShareLinkPassword,
+	errors.ShareLinkDownloads,
+	errors.RateLimited,
+	errors.IdempotencyKey,
+	errors.IdempotencyReused,
+	errors.IdempotencyPending,
+	errors.BatchOperation,
+	errors.BatchRolledBack,
+	errors.NotReady,
+}
+
+// Schemas of the params that are shared by several operations.
+var (
+	sizeSchema  = &Schema{Type: "string", Enum: enumStrings(enums.Sizes)}
+	cropSchema  = &Schema{Type: "string", Enum: enumStrings(enums.Crops)}
+	appParam    = Parameter{Name: "app", Description: "Name of the app.", Required: true, Schema: &Schema{Type: "string"}}
+	storagePath = Parameter{Name: "id", Description: "ID of the storage path of the app.", Required: true, Schema: &Schema{Type: "integer"}}
+	shareHeader = []Parameter{{Name: "X-Share-Password", Description: "Password of the share link.", Schema: &Schema{Type: "string"}}}
+)
+
+// Operations documents each route by its method and path, like "GET /v1/images/:id".
+var Operations = map[string]Operation{
+	// Apps.
+	"POST /v1/apps": private(Operation{ID: "createApp", Tag: "Apps", Summary: "Create a new app", Request: requests.CreateApp{}, Response: responses.App{}}),
+	"GET /v1/apps/:name/rate-limits": private(Operation{ID: "getRateLimits", Tag: "Apps", Summary: "Get the rate limits of an app",
+		Response: []responses.RateLimit{}}),
+	"PUT /v1/apps/:name/rate-limits": private(Operation{ID: "updateRateLimits", Tag: "Apps", Summary: "Replace the rate limits of an app",
+		Request: requests.UpdateRateLimits{}, Response: []responses.RateLimit{}}),
+
+	// Storage paths.
+	"GET /v1/storage-paths": private(Operation{ID: "getStoragePaths", Tag: "Storage Paths", Summary: "Get all storage paths", Response: storagePathPage{},
+		Query: []Parameter{
+			{Name: "page", Description: "Page, 1 by default.", Schema: &Schema{Type: "integer", Minimum: floatPointer(1)}},
+			{Name: "limit", Description: "Storage paths per page, 10 by default.", Schema: &Schema{Type: "integer", Minimum: floatPointer(1)}},
+			{Name: "searchLike", Description: "Filter of api-utils on id, app, path or limit.", Schema: &Schema{Type: "string"}},
+			{Name: "searchEq", Description: "Filter of api-utils on id, app, path or limit.", Schema: &Schema{Type: "string"}},
+			{Name: "sortBy", Description: "Sort of api-utils on id, app, path or limit.", Schema: &Schema{Type: "string"}},
+		}}),
+	"POST /v1/storage-paths": private(Operation{ID: "createStoragePath", Tag: "Storage Paths", Summary: "Create a new storage path",
+		Request: requests.CreateAppStoragePath{}, Response: responses.AppStoragePath{}}),
+	"GET /v1/storage-paths/id": private(Operation{ID: "getStoragePathIdByApp", Tag: "Storage Paths", Summary: "Get the ID of the storage path of an app",
+		Query: []Parameter{appParam}, Response: responses.AppStoragePathID{}}),
+	"GET /v1/storage-paths/:id": private(Operation{ID: "getStoragePath", Tag: "Storage Paths", Summary: "Get a specific storage path", Response: responses.AppStoragePath{}}),
+	"PUT /v1/storage-paths/:id": private(Operation{ID: "updateStoragePath", Tag: "Storage Paths", Summary: "Update a specific storage path",
+		Description: "A changed watermark renders the public renditions again in a background job.",
+		Request:     requests.UpdateAppStoragePath{}, Response: responses.AppStoragePath{}}),
+	"GET /v1/storage-paths/:id/fsck": private(Operation{ID: "checkStoragePath", Tag: "Storage Paths", Summary: "Report the inconsistencies between the files and the database",
+		Response: responses.Fsck{}}),
+	"POST /v1/storage-paths/:id/fsck": private(Operation{ID: "repairStoragePath", Tag: "Storage Paths", Summary: "Repair the inconsistencies between the files and the database",
+		Request: requests.RepairAppStoragePath{}, Response: responses.Fsck{}}),
+
+	// Folders.
+	"POST /v1/folders": private(Operation{ID: "createFolder", Tag: "Folders", Summary: "Create a new folder", Request: requests.CreateFolder{}, Response: responses.Folder{}}),
+	"GET /v1/folders/:id": private(Operation{ID: "getFolder", Tag: "Folders", Summary: "Get a specific folder with its folders, images and documents",
+		Response: responses.FolderPreload{}}),
+	"PUT /v1/folders/:id":         private(Operation{ID: "updateFolder", Tag: "Folders", Summary: "Update a specific folder", Request: requests.UpdateFolder{}, Response: responses.Folder{}}),
+	"DELETE /v1/folders/:id":      private(Operation{ID: "deleteFolder", Tag: "Folders", Summary: "Delete a specific folder", Status: fiber.StatusNoContent}),
+	"PUT /v1/folders/:id/restore": private(Operation{ID: "restoreFolder", Tag: "Folders", Summary: "Restore a deleted folder", Status: fiber.StatusNoContent}),
+	"POST /v1/folders/:id/copy": private(Operation{ID: "copyFolder", Tag: "Folders", Summary: "Copy a folder with its content into a folder",
+		Description: "The folder is created right away, its files are copied in a background job.",
+		Request:     requests.CopyFolder{}, Response: responses.FolderCopy{}, Status: fiber.StatusAccepted, Errors: []int{fiber.StatusConflict}}),
+
+	// Images.
+	"POST /v1/images": private(Operation{ID: "createImage", Tag: "Images", Summary: "Create a new image", Request: requests.CreateImage{}, Response: responses.Image{},
+		Errors: []int{fiber.StatusConflict, fiber.StatusRequestEntityTooLarge}}),
+	"POST /v1/images/regenerate": private(Operation{ID: "regenerateImages", Tag: "Images", Summary: "Regenerate the web sizes of images in a background job",
+		Request: requests.RegenerateImages{}, Response: responses.Job{}, Status: fiber.StatusAccepted}),
+	"POST /v1/images/placeholders": private(Operation{ID: "generateImagePlaceholders", Tag: "Images", Summary: "Generate the placeholders of images in a background job",
+		Request: requests.GenerateImagePlaceholders{}, Response: responses.Job{}, Status: fiber.StatusAccepted}),
+	"PUT /v1/images/move": private(Operation{ID: "moveImages", Tag: "Images", Summary: "Move several images into a folder at once",
+		Request: requests.MoveImages{}, Response: []responses.Image{}, Errors: []int{fiber.StatusNotFound, fiber.StatusConflict}}),
+	"GET /v1/images/:id": private(Operation{ID: "getImage", Tag: "Images", Summary: "Get a specific image", Response: responses.Image{}}),
+	"PUT /v1/images/:id": private(Operation{ID: "updateImage", Tag: "Images", Summary: "Update a specific image",
+		Request: requests.UpdateImage{}, Response: responses.Image{}, Errors: []int{fiber.StatusConflict}}),
+	"DELETE /v1/images/:id":       private(Operation{ID: "deleteImage", Tag: "Images", Summary: "Delete a specific image", Status: fiber.StatusNoContent}),
+	"DELETE /v1/images/:id/hard":  private(Operation{ID: "deleteImageHard", Tag: "Images", Summary: "Delete a specific image with its files for ever", Status: fiber.StatusNoContent}),
+	"PUT /v1/images/:id/restore":  private(Operation{ID: "restoreImage", Tag: "Images", Summary: "Restore a deleted image", Status: fiber.StatusNoContent}),
+	"GET /v1/images/:id/original": private(Operation{ID: "getImageOriginal", Tag: "Images", Summary: "Get the original file of an image", File: "image/*"}),
+	"PUT /v1/images/:id/edit": private(Operation{ID: "editImage", Tag: "Images", Summary: "Edit an image without touching its original",
+		Request: requests.EditImage{}, Response: responses.Image{}}),
+	"DELETE /v1/images/:id/edit": private(Operation{ID: "revertImageEdit", Tag: "Images", Summary: "Revert the edit of an image", Response: responses.Image{},
+		Query: []Parameter{{Name: "quality", Description: "Quality of the web sizes that are rendered again.", Schema: &Schema{Type: "integer"}}}}),
+	"POST /v1/images/:id/copy": private(Operation{ID: "copyImage", Tag: "Images", Summary: "Copy an image with its files into a folder",
+		Request: requests.CopyImage{}, Response: responses.Image{}, Errors: []int{fiber.StatusConflict}}),
+	"PUT /v1/images/:id/move": private(Operation{ID: "moveImage", Tag: "Images", Summary: "Move an image with its files into a folder",
+		Request: requests.MoveImage{}, Response: responses.Image{}, Errors: []int{fiber.StatusConflict}}),
+
+	// Documents.
+	"POST /v1/documents": private(Operation{ID: "createDocument", Tag: "Documents", Summary: "Create a new document", Request: requests.CreateDocument{}, Response: responses.Document{},
+		Errors: []int{fiber.StatusConflict, fiber.StatusRequestEntityTooLarge}}),
+	"PUT /v1/documents/move": private(Operation{ID: "moveDocuments", Tag: "Documents", Summary: "Move several documents into a folder at once",
+		Request: requests.MoveDocuments{}, Response: []responses.Document{}, Errors: []int{fiber.StatusNotFound, fiber.StatusConflict}}),
+	"GET /v1/documents/:id": private(Operation{ID: "getDocument", Tag: "Documents", Summary: "Get a specific document", Response: responses.Document{}}),
+	"PUT /v1/documents/:id": private(Operation{ID: "updateDocument", Tag: "Documents", Summary: "Update a specific document",
+		Request: requests.UpdateDocument{}, Response: responses.Document{}}),
+	"DELETE /v1/documents/:id":      private(Operation{ID: "deleteDocument", Tag: "Documents", Summary: "Delete a specific document", Status: fiber.StatusNoContent}),
+	"DELETE /v1/documents/:id/hard": private(Operation{ID: "deleteDocumentHard", Tag: "Documents", Summary: "Delete a specific document with its files for ever", Status: fiber.StatusNoContent}),
+	"PUT /v1/documents/:id/restore": private(Operation{ID: "restoreDocument", Tag: "Documents", Summary: "Restore a deleted document", Status: fiber.StatusNoContent}),
+	"POST /v1/documents/:id/copy": private(Operation{ID: "copyDocument", Tag: "Documents", Summary: "Copy a document with its previews into a folder",
+		Request: requests.CopyDocument{}, Response: responses.Document{}, Errors: []int{fiber.StatusConflict}}),
+	"PUT /v1/documents/:id/move": private(Operation{ID: "moveDocument", Tag: "Documents", Summary: "Move a document with its previews into a folder",
+		Request: requests.MoveDocument{}, Response: responses.Document{}, Errors: []int{fiber.StatusConflict}}),
+
+	// Share links.
+	"POST /v1/share-links": private(Operation{ID: "createShareLink", Tag: "Share Links", Summary: "Share an image, document or folder with a link",
+		Request: requests.CreateShareLink{}, Response: responses.ShareLink{}}),
+	"GET /v1/share-links/:id": private(Operation{ID: "getShareLink", Tag: "Share Links", Summary: "Get a specific share link", Response: responses.ShareLink{}}),
+	"PUT /v1/share-links/:id": private(Operation{ID: "updateShareLink", Tag: "Share Links", Summary: "Update the password, expiry date and download limit of a share link",
+		Request: requests.UpdateShareLink{}, Response: responses.ShareLink{}}),
+	"DELETE /v1/share-links/:id": private(Operation{ID: "revokeShareLink", Tag: "Share Links", Summary: "Revoke a share link", Status: fiber.StatusNoContent}),
+
+	// Batch.
+	"POST /v1/batch": private(Operation{ID: "runBatch", Tag: "Batch", Summary: "Delete, restore, move or update several images, documents and folders at once",
+		Description: "Each operation gets the status, error code and message it would get as a single request.",
+		Request:     requests.Batch{}, Response: responses.Batch{}}),
+
+	// Jobs.
+	"GET /v1/jobs/:id": private(Operation{ID: "getJob", Tag: "Jobs", Summary: "Get the state of a background job", Response: responses.Job{},
+		PathParams: map[string]*Schema{"id": {Type: "string", Format: "uuid"}}}),
+
+	// WebSocket.
+	"GET /v1/handshake": private(Operation{ID: "handshake", Tag: "WebSocket", Summary: "Get a code to connect to the progress WebSocket",
+		Query: []Parameter{appParam, storagePath}, Response: responses.Handshake{}}),
+	"GET /v1/ws/progress": {ID: "progress", Tag: "WebSocket", Summary: "Receive the progress of uploads and jobs",
+		Description: "Upgrades to a WebSocket that sends a FileProgress message for each step of an upload or job of the app.",
+		Query:       []Parameter{appParam, storagePath, {Name: "code", Description: "Code of the handshake.", Required: true, Schema: &Schema{Type: "string"}}},
+		Status:      fiber.StatusSwitchingProtocols, Errors: []int{fiber.StatusUpgradeRequired}},
+
+	// Public images.
+	"GET /v1/image/:id":        public(Operation{ID: "getImageFile", Tag: "Public", Summary: "Get the public file of an image", File: "image/*"}),
+	"GET /v1/image/:id/poster": public(Operation{ID: "getImageFilePoster", Tag: "Public", Summary: "Get the poster of an animated image", File: "image/*"}),
+	"GET /v1/image/:id/:size": public(Operation{ID: "getImageFileSize", Tag: "Public", Summary: "Get a web size of an image", File: "image/*",
+		PathParams: map[string]*Schema{"size": sizeSchema}}),
+	"GET /v1/image/:id/crop/:crop": public(Operation{ID: "getImageFileCrop", Tag: "Public", Summary: "Get a crop of an image", File: "image/*",
+		PathParams: map[string]*Schema{"crop": cropSchema}}),
+
+	// Public documents.
+	"GET /v1/document/:id": public(Operation{ID: "getDocumentFile", Tag: "Public", Summary: "Get the file of a document", File: "application/octet-stream",
+		Errors: []int{fiber.StatusForbidden}}),
+	"GET /v1/document/:id/thumbnail":     public(Operation{ID: "getDocumentThumbnail", Tag: "Public", Summary: "Get the thumbnail of a document", File: "image/*"}),
+	"GET /v1/document/:id/preview/:page": public(Operation{ID: "getDocumentPreview", Tag: "Public", Summary: "Get the preview of a page of a document", File: "image/*"}),
+
+	// Share.
+	"GET /v1/share/:token": public(Operation{ID: "getSharedFile", Tag: "Public", Summary: "Download a shared image or document, or list a shared folder",
+		Headers: shareHeader, Response: responses.SharedFolder{}, File: "application/octet-stream",
+		Errors: []int{fiber.StatusUnauthorized, fiber.StatusGone}}),
+	"GET /v1/share/:token/zip": public(Operation{ID: "getSharedFolderZip", Tag: "Public", Summary: "Download a shared folder as a ZIP archive",
+		Headers: shareHeader, File: "application/zip", Errors: []int{fiber.StatusUnauthorized, fiber.StatusGone}}),
+	"GET /v1/share/:token/image/:id": public(Operation{ID: "getSharedFolderImage", Tag: "Public", Summary: "Download an image inside a shared folder",
+		Headers: shareHeader, File: "image/*", Errors: []int{fiber.StatusUnauthorized, fiber.StatusGone}}),
+	"GET /v1/share/:token/document/:id": public(Operation{ID: "getSharedFolderDocument", Tag: "Public", Summary: "Download a document inside a shared folder",
+		Headers: shareHeader, File: "application/octet-stream", Errors: []int{fiber.StatusUnauthorized, fiber.StatusGone}}),
+
+	// Documentation.
+	"GET /v1/openapi.json": {ID: "getOpenAPI", Tag: "Documentation", Summary: "Get this OpenAPI document", File: fiber.MIMEApplicationJSON},
+	"GET /v1/docs":         {ID: "getDocs", Tag: "Documentation", Summary: "Read this OpenAPI document", File: fiber.MIMETextHTMLCharsetUTF8},
+
+	// Operations.
+	"GET /metrics":     {ID: "getMetrics", Tag: "Operations", Summary: "Get the Prometheus metrics", Machine: true, File: "text/plain"},
+	"GET /health/live": {ID: "getLiveness", Tag: "Operations", Summary: "Check if the service runs", Response: responses.Health{}},
+	"GET /health/ready": {ID: "getReadiness", Tag: "Operations", Summary: "Check if the service can handle requests",
+		Description: "Callers with the machine key get the result of each check.",
+		Response:    responses.Health{}, Errors: []int{fiber.StatusServiceUnavailable}},
+}
+
+// private documents a route that needs the machine key and is rate limited.
+func private(operation Operation) Operation {
+	operation.Machine = true
+	operation.RateLimited = true
+
+	return operation
+}
+
+// public documents a route that is rate limited.
+func public(operation Operation) Operation {
+	operation.RateLimited = true
+
+	return operation
+}
+
+// enumStrings gets the values of an enum as strings.
+func enumStrings[T fmt.Stringer](values []T) []string {
+	strs := make([]string, len(values))
+	for i := range values {
+		strs[i] = values[i].String()
+	}
+
+	return strs
+}
@@ -0,0 +1,253 @@
+package docs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of a value in an OpenAPI document.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// errorSchema is the name of the schema of an error response.
+const errorSchema = "Error"
+
+// timeType is the type of dates, which are strings in JSON.
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator creates the schemas of the DTO structs. Named structs become components that are referenced.
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// newGenerator creates a generator with the schema of an error response.
+func newGenerator() *generator {
+	return &generator{
+		schemas: map[string]*Schema{
+			errorSchema: {
+				Type:     "object",
+				Required: []string{"code", "message"},
+				Properties: map[string]*Schema{
+					"code":    {Type: "string", Enum: ErrorCodes},
+					"message": {Description: "A text, or the fields that are not valid for the validator code."},
+				},
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// schema creates the schema of a type.
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch {
+	case t.Kind() == reflect.Pointer:
+		schema := g.schema(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structRef adds the schema of a struct to the components and references it.
+func (g *generator) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+
+	name, ok := g.names[t]
+	if !ok {
+		name = g.componentName(t)
+		g.names[t] = name
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName names the schema of a struct. Requests get a suffix, because responses often have the same name.
+func (g *generator) componentName(t reflect.Type) string {
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+
+	name := t.Name()
+	if pkg == "requests" {
+		name += "Request"
+	}
+	if _, taken := g.schemas[name]; taken {
+		name = pkg + "." + t.Name()
+	}
+
+	return name
+}
+
+// structSchema creates the schema of the fields of a struct, embedded structs are flattened.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+
+	return schema
+}
+
+// addFields adds the exported fields with their validation rules to the schema.
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, tagged := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			g.addFields(schema, field.Type)
+			continue
+		}
+
+		property := g.schema(field.Type)
+		if applyRules(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyRules adds the validation rules of a field to its schema, the rules after dive apply to the items.
+// It returns if the field is required.
+func applyRules(schema *Schema, validate string) bool {
+	if validate == "" {
+		return false
+	}
+
+	required := false
+	target := schema
+	for _, rule := range strings.Split(validate, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		if key == "required" && target == schema {
+			required = true
+		}
+		if target.Ref != "" {
+			continue
+		}
+
+		switch key {
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "oneof":
+			target.Enum = strings.Fields(value)
+		case "min", "max", "gte", "lte":
+			limit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			setLimit(target, key, limit)
+		}
+	}
+
+	return required
+}
+
+// setLimit sets a min or max rule as the limit of a number, or of the length of a string or array.
+func setLimit(schema *Schema, key string, limit float64) {
+	lower := key == "min" || key == "gte"
+	length := int(limit)
+
+	switch schema.Type {
+	case "integer", "number":
+		if lower {
+			schema.Minimum = floatPointer(limit)
+		} else {
+			schema.Maximum = floatPointer(limit)
+		}
+	case "string":
+		if lower {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &length
+		} else {
+			schema.MaxItems = &length
+		}
+	}
+}
+
+// jsonName gets the name of a field in JSON and if it has a json tag.
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name, false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name, false
+	}
+
+	return name, true
+}
+
+// floatPointer gets a pointer to the number.
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+// intPointer gets a pointer to the number.
+func intPointer(value int) *int {
+	return &value
+}
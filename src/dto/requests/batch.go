@@ -0,0 +1,19 @@
+package requests
+
+// Batch struct to run operations on images, documents and folders at once.
+// When atomic, either every operation succeeds or none of them.
+type Batch struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+// BatchOperation struct for an operation of a batch. Images and documents are moved into the folder,
+// images are updated with the description and folders with the color.
+type BatchOperation struct {
+	Operation   string  `json:"operation" validate:"required,oneof=delete restore move update"`
+	Type        string  `json:"type" validate:"required,oneof=image document folder"`
+	ID          uint    `json:"id" validate:"required"`
+	FolderID    uint    `json:"folderId" validate:"required_if=Operation move"`
+	Description *string `json:"description"`
+	Color       *string `json:"color"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+// CopyDocument struct to copy the document into the target folder.
+// The name, without the extension, defaults to the name of the document. On a name conflict the copy fails,
+// is renamed, is skipped or overwrites the existing one.
+type CopyDocument struct {
+	FolderID uint    `json:"folderId" validate:"required"`
+	Name     *string `json:"name"`
+	Conflict string  `json:"conflict" validate:"omitempty,oneof=fail rename skip overwrite"`
+}
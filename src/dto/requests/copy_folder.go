@@ -0,0 +1,10 @@
+package requests
+
+// CopyFolder struct to copy the folder into the target folder.
+// The name defaults to the name of the folder. On a name conflict the copy fails, is renamed,
+// is skipped or is merged into the existing folder, where it overwrites files with the same name.
+type CopyFolder struct {
+	FolderID uint    `json:"folderId" validate:"required"`
+	Name     *string `json:"name"`
+	Conflict string  `json:"conflict" validate:"omitempty,oneof=fail rename skip overwrite"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+// CopyImage struct to copy the image into the target folder.
+// The name, without the extension, defaults to the name of the image. On a name conflict the copy fails,
+// is renamed, is skipped or overwrites the existing one.
+type CopyImage struct {
+	FolderID uint    `json:"folderId" validate:"required"`
+	Name     *string `json:"name"`
+	Conflict string  `json:"conflict" validate:"omitempty,oneof=fail rename skip overwrite"`
+}
@@ -2,7 +2,12 @@ package requests
 
 // CreateAppStoragePath struct for creating a new AppStoragePath.
 type CreateAppStoragePath struct {
-	App   string `json:"app" validate:"required"`
-	Path  string `json:"path" validate:"required"`
-	Limit *int64 `json:"limit"`
+	App             string     `json:"app" validate:"required"`
+	Path            string     `json:"path" validate:"required"`
+	Limit           *int64     `json:"limit"`
+	StripMetadata   string     `json:"stripMetadata" validate:"omitempty,oneof=none location all"`
+	AnimationMode   string     `json:"animationMode" validate:"omitempty,oneof=animate still original"`
+	AnimationPoster bool       `json:"animationPoster"`
+	Encrypted       bool       `json:"encrypted"`
+	Watermark       *Watermark `json:"watermark"`
 }
@@ -0,0 +1,14 @@
+package requests
+
+import "time"
+
+// CreateShareLink struct for sharing an image, document or folder.
+// Exactly one of the image, document or folder is shared.
+type CreateShareLink struct {
+	ImageID      *uint      `json:"imageId" validate:"required_without_all=DocumentID FolderID,excluded_with=DocumentID FolderID"`
+	DocumentID   *uint      `json:"documentId" validate:"required_without_all=ImageID FolderID,excluded_with=ImageID FolderID"`
+	FolderID     *uint      `json:"folderId" validate:"required_without_all=ImageID DocumentID,excluded_with=ImageID DocumentID"`
+	Password     *string    `json:"password" validate:"omitempty,min=4,max=72"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	MaxDownloads *int64     `json:"maxDownloads" validate:"omitempty,min=1"`
+}
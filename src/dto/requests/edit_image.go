@@ -0,0 +1,21 @@
+package requests
+
+// EditImage struct for the non-destructive edit of an image.
+// The crop box is in pixels of the displayed original. Brightness and contrast are percentages.
+type EditImage struct {
+	Crop           *ImageEditCrop `json:"crop"`
+	Rotation       int            `json:"rotation" validate:"oneof=0 90 180 270"`
+	FlipHorizontal bool           `json:"flipHorizontal"`
+	FlipVertical   bool           `json:"flipVertical"`
+	Brightness     float64        `json:"brightness" validate:"min=-100,max=100"`
+	Contrast       float64        `json:"contrast" validate:"gt=-100,max=100"`
+	Quality        int            `json:"quality" validate:"min=0,max=100"`
+}
+
+// ImageEditCrop struct for the crop box of an image edit.
+type ImageEditCrop struct {
+	Left   int `json:"left" validate:"min=0"`
+	Top    int `json:"top" validate:"min=0"`
+	Width  int `json:"width" validate:"required,min=1"`
+	Height int `json:"height" validate:"required,min=1"`
+}
@@ -0,0 +1,7 @@
+package requests
+
+// FocalPoint struct for the point of interest of an image as x and y percentages.
+type FocalPoint struct {
+	X float64 `json:"x" validate:"min=0,max=100"`
+	Y float64 `json:"y" validate:"min=0,max=100"`
+}
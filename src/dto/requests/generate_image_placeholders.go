@@ -0,0 +1,12 @@
+package requests
+
+// GenerateImagePlaceholders struct to generate the placeholders of the images.
+// The images of the storage path are used, limited to the folder with its
+// sub folders or to a single image when given. Existing placeholders are
+// only replaced when Overwrite is set.
+type GenerateImagePlaceholders struct {
+	AppStoragePathID uint  `json:"appStoragePathId" validate:"required"`
+	FolderID         *uint `json:"folderId"`
+	ImageID          *uint `json:"imageId"`
+	Overwrite        bool  `json:"overwrite"`
+}
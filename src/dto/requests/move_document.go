@@ -0,0 +1,6 @@
+package requests
+
+// MoveDocument struct to move the document into the target folder.
+type MoveDocument struct {
+	FolderID uint `json:"folderId" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package requests
+
+// MoveDocuments struct to move several documents into the target folder at once.
+// Either all documents are moved or none of them.
+type MoveDocuments struct {
+	DocumentIDs []uint `json:"documentIds" validate:"required,min=1,dive,required"`
+	FolderID    uint   `json:"folderId" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package requests
+
+// MoveImage struct to move the image into the target folder.
+type MoveImage struct {
+	FolderID uint `json:"folderId" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package requests
+
+// MoveImages struct to move several images into the target folder at once.
+// Either all images are moved or none of them.
+type MoveImages struct {
+	ImageIDs []uint `json:"imageIds" validate:"required,min=1,dive,required"`
+	FolderID uint   `json:"folderId" validate:"required"`
+}
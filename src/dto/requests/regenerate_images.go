@@ -0,0 +1,12 @@
+package requests
+
+// RegenerateImages struct to regenerate the web sizes of the images.
+// The images of the storage path are regenerated, limited to the folder
+// with its sub folders or to a single image when given.
+type RegenerateImages struct {
+	AppStoragePathID uint     `json:"appStoragePathId" validate:"required"`
+	FolderID         *uint    `json:"folderId"`
+	ImageID          *uint    `json:"imageId"`
+	Sizes            []string `json:"sizes" validate:"dive,oneof=xs sm md lg xl xxl"`
+	Quality          int      `json:"quality" validate:"min=0,max=100"`
+}
@@ -0,0 +1,8 @@
+package requests
+
+// RepairAppStoragePath struct for repairing the files of an AppStoragePath.
+type RepairAppStoragePath struct {
+	DeleteOrphans   bool `json:"deleteOrphans"`
+	MarkBroken      bool `json:"markBroken"`
+	RegenerateSizes bool `json:"regenerateSizes"`
+}
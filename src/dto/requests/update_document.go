@@ -3,8 +3,9 @@ package requests
 import "time"
 
 // UpdateDocument struct for updating a document.
+// Without data the document is only renamed.
 type UpdateDocument struct {
 	Name      string    `json:"name" validate:"required"`
-	Data      string    `json:"data" validate:"required"`
+	Data      string    `json:"data"`
 	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
 }
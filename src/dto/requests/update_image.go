@@ -3,11 +3,14 @@ package requests
 import "time"
 
 // UpdateImage struct to update the image.
+// A name without data only renames the image.
 type UpdateImage struct {
-	Name           *string   `json:"name"`
-	Data           *string   `json:"data"`
-	Description    *string   `json:"description"`
-	UpdatedAt      time.Time `json:"updatedAt" validate:"required"`
-	Quality        *int      `json:"quality"`
-	IsNotResizable *bool     `json:"isNotResizable"`
+	Name             *string     `json:"name"`
+	Data             *string     `json:"data"`
+	Description      *string     `json:"description"`
+	UpdatedAt        time.Time   `json:"updatedAt" validate:"required"`
+	Quality          *int        `json:"quality"`
+	IsNotResizable   *bool       `json:"isNotResizable"`
+	FocalPoint       *FocalPoint `json:"focalPoint"`
+	RemoveFocalPoint bool        `json:"removeFocalPoint"`
 }
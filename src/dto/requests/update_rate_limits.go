@@ -0,0 +1,16 @@
+package requests
+
+// UpdateRateLimits request DTO to replace the rate limits of an app.
+type UpdateRateLimits struct {
+	RateLimits []RateLimit `json:"rateLimits" validate:"dive"`
+}
+
+// RateLimit struct for the limits of a group of routes, or of every group with "*".
+// A limit of 0 is unlimited, the window is in seconds and 60 by default.
+type RateLimit struct {
+	Group         string `json:"group" validate:"required"`
+	Requests      int64  `json:"requests" validate:"min=0"`
+	UploadBytes   int64  `json:"uploadBytes" validate:"min=0"`
+	DownloadBytes int64  `json:"downloadBytes" validate:"min=0"`
+	Window        int    `json:"window" validate:"omitempty,min=1"`
+}
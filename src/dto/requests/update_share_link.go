@@ -0,0 +1,11 @@
+package requests
+
+import "time"
+
+// UpdateShareLink struct for replacing the rules of a share link.
+// A rule that is left out is removed, so the password has to be sent again to keep it.
+type UpdateShareLink struct {
+	Password     *string    `json:"password" validate:"omitempty,min=4,max=72"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	MaxDownloads *int64     `json:"maxDownloads" validate:"omitempty,min=1"`
+}
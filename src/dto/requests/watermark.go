@@ -0,0 +1,12 @@
+package requests
+
+// Watermark struct for the watermark of the public renditions of an AppStoragePath.
+// The image is a base64 data URL. Opacity and scale are fractions, scale of the width of a rendition.
+type Watermark struct {
+	Text     string   `json:"text" validate:"required_without=Image,excluded_with=Image,max=100"`
+	Image    string   `json:"image" validate:"required_without=Text"`
+	Position string   `json:"position" validate:"omitempty,oneof=topLeft top topRight left center right bottomLeft bottom bottomRight"`
+	Opacity  float64  `json:"opacity" validate:"omitempty,gt=0,max=1"`
+	Scale    float64  `json:"scale" validate:"omitempty,gt=0,max=1"`
+	Sizes    []string `json:"sizes" validate:"omitempty,dive,oneof=xs sm md lg xl xxl avatar card banner wide"`
+}
@@ -4,12 +4,18 @@ import "api-file/main/src/models"
 
 // AppStoragePath struct for the AppStoragePath response.
 type AppStoragePath struct {
-	ID      uint     `json:"id"`
-	AppName string   `json:"appName"`
-	Path    string   `json:"path"`
-	Limit   *int64   `json:"limit"`
-	Used    int64    `json:"used"`
-	Folders []Folder `json:"folders"`
+	ID              uint       `json:"id"`
+	AppName         string     `json:"appName"`
+	Path            string     `json:"path"`
+	Limit           *int64     `json:"limit"`
+	StripMetadata   string     `json:"stripMetadata"`
+	AnimationMode   string     `json:"animationMode"`
+	AnimationPoster bool       `json:"animationPoster"`
+	Encrypted       bool       `json:"encrypted"`
+	Watermark       *Watermark `json:"watermark"`
+	Used            int64      `json:"used"`
+	Folders         []Folder   `json:"folders"`
+	Job             *Job       `json:"job,omitempty"`
 }
 
 // SetAppStoragePath sets the AppStoragePath response.
@@ -17,6 +23,15 @@ func (response *AppStoragePath) SetAppStoragePath(appStoragePath *models.AppStor
 	response.ID = appStoragePath.ID
 	response.AppName = appStoragePath.AppName
 	response.Path = appStoragePath.Path
+	response.StripMetadata = appStoragePath.StripMetadata.String()
+	response.AnimationMode = appStoragePath.AnimationMode.String()
+	response.AnimationPoster = appStoragePath.AnimationPoster
+	response.Encrypted = appStoragePath.Encrypted
+
+	if appStoragePath.Watermark.IsEnabled() {
+		response.Watermark = &Watermark{}
+		response.Watermark.SetWatermark(&appStoragePath.Watermark)
+	}
 
 	if appStoragePath.Limit.Valid {
 		response.Limit = &appStoragePath.Limit.Int64
@@ -4,10 +4,15 @@ import "api-file/main/src/models"
 
 // AppStoragePathPaginate struct for the AppStoragePath response.
 type AppStoragePathPaginate struct {
-	ID      uint   `json:"id"`
-	AppName string `json:"appName"`
-	Path    string `json:"path"`
-	Limit   *int64 `json:"limit"`
+	ID              uint       `json:"id"`
+	AppName         string     `json:"appName"`
+	Path            string     `json:"path"`
+	Limit           *int64     `json:"limit"`
+	StripMetadata   string     `json:"stripMetadata"`
+	AnimationMode   string     `json:"animationMode"`
+	AnimationPoster bool       `json:"animationPoster"`
+	Encrypted       bool       `json:"encrypted"`
+	Watermark       *Watermark `json:"watermark"`
 }
 
 // SetAppStoragePathPaginate sets the AppStoragePath response.
@@ -15,6 +20,15 @@ func (response *AppStoragePathPaginate) SetAppStoragePathPaginate(appStoragePath
 	response.ID = appStoragePath.ID
 	response.AppName = appStoragePath.AppName
 	response.Path = appStoragePath.Path
+	response.StripMetadata = appStoragePath.StripMetadata.String()
+	response.AnimationMode = appStoragePath.AnimationMode.String()
+	response.AnimationPoster = appStoragePath.AnimationPoster
+	response.Encrypted = appStoragePath.Encrypted
+
+	if appStoragePath.Watermark.IsEnabled() {
+		response.Watermark = &Watermark{}
+		response.Watermark.SetWatermark(&appStoragePath.Watermark)
+	}
 
 	if appStoragePath.Limit.Valid {
 		response.Limit = &appStoragePath.Limit.Int64
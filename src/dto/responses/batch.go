@@ -0,0 +1,46 @@
+package responses
+
+import "api-file/main/src/models"
+
+// Batch struct for the results of the operations of a batch, in the order of the operations.
+type Batch struct {
+	Atomic    bool          `json:"atomic"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchResult struct for the result of an operation of a batch.
+// The status is the status the operation would get as a single request.
+type BatchResult struct {
+	Operation string `json:"operation"`
+	Type      string `json:"type"`
+	ID        uint   `json:"id"`
+	Status    int    `json:"status"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// SetBatch sets the Batch response, the results need to be added with AddResult.
+func (b *Batch) SetBatch(atomic bool, size int) {
+	b.Atomic = atomic
+	b.Results = make([]BatchResult, 0, size)
+}
+
+// AddResult adds the result of the next operation.
+func (b *Batch) AddResult(item *models.BatchItem, status int, code, message string) {
+	if status < 300 {
+		b.Succeeded++
+	} else {
+		b.Failed++
+	}
+
+	b.Results = append(b.Results, BatchResult{
+		Operation: item.Operation.String(),
+		Type:      item.Type.String(),
+		ID:        item.ID,
+		Status:    status,
+		Code:      code,
+		Message:   message,
+	})
+}
@@ -6,14 +6,20 @@ import (
 )
 
 type Document struct {
-	ID               uint      `json:"id"`
-	FolderID         uint      `json:"folderId"`
-	AppStoragePathID uint      `json:"appStoragePathId"`
-	Name             string    `json:"name"`
-	Extension        string    `json:"extension"`
-	Size             int       `json:"size"`
-	CreatedAt        time.Time `json:"createdAt"`
-	UpdatedAt        time.Time `json:"updatedAt"`
+	ID               uint       `json:"id"`
+	FolderID         uint       `json:"folderId"`
+	AppStoragePathID uint       `json:"appStoragePathId"`
+	Name             string     `json:"name"`
+	Extension        string     `json:"extension"`
+	Size             int        `json:"size"`
+	Broken           bool       `json:"broken"`
+	PageCount        int        `json:"pageCount"`
+	HasPreview       bool       `json:"hasPreview"`
+	ScanStatus       string     `json:"scanStatus"`
+	ScanSignature    *string    `json:"scanSignature"`
+	ScannedAt        *time.Time `json:"scannedAt"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
 }
 
 // SetDocument sets the document properties.
@@ -23,6 +29,16 @@ func (d *Document) SetDocument(document *models.Document, appStoragePathID *uint
 	d.Name = document.Name
 	d.Extension = document.Extension
 	d.Size = document.Size
+	d.Broken = document.Broken
+	d.PageCount = document.PageCount
+	d.HasPreview = document.HasPreview
+	d.ScanStatus = document.ScanStatus.String()
+	if document.ScanSignature.Valid {
+		d.ScanSignature = &document.ScanSignature.String
+	}
+	if document.ScannedAt.Valid {
+		d.ScannedAt = &document.ScannedAt.Time
+	}
 	d.CreatedAt = document.CreatedAt
 	d.UpdatedAt = document.UpdatedAt
 
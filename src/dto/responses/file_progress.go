@@ -9,6 +9,7 @@ type FileProgress struct {
 	Type     enums.FileType `json:"type"`
 	Filename string         `json:"filename"`
 	Progress float64        `json:"progress"`
+	JobID    string         `json:"jobId,omitempty"`
 }
 
 // SetFileProgress sets the file progress response.
@@ -0,0 +1,7 @@
+package responses
+
+// FocalPoint struct for the point of interest of an image as x and y percentages.
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
@@ -0,0 +1,20 @@
+package responses
+
+import "api-file/main/src/models"
+
+// FolderCopy struct for the copy of a folder with the job that copies its files.
+// The job is nil when the copy is skipped.
+type FolderCopy struct {
+	Folder Folder `json:"folder"`
+	Job    *Job   `json:"job"`
+}
+
+// SetFolderCopy sets the folder copy response.
+func (f *FolderCopy) SetFolderCopy(folder *models.Folder, job *models.Job) {
+	f.Folder.SetFolder(folder)
+
+	if job != nil {
+		f.Job = &Job{}
+		f.Job.SetJob(job)
+	}
+}
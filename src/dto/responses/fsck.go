@@ -0,0 +1,50 @@
+package responses
+
+import (
+	"api-file/main/src/models"
+	"os"
+	"strings"
+)
+
+// Fsck struct for the consistency check response of an AppStoragePath.
+type Fsck struct {
+	AppStoragePathID uint        `json:"appStoragePathId"`
+	Issues           []FsckIssue `json:"issues"`
+}
+
+// FsckIssue struct for a single inconsistency between disk and database.
+type FsckIssue struct {
+	Type     string `json:"type"`
+	FileType string `json:"fileType,omitempty"`
+	ID       uint   `json:"id,omitempty"`
+	Size     string `json:"size,omitempty"`
+	Crop     string `json:"crop,omitempty"`
+	Path     string `json:"path"`
+	Expected int64  `json:"expected"`
+	Actual   int64  `json:"actual"`
+	Repaired bool   `json:"repaired"`
+}
+
+// SetFsck sets the consistency check response.
+func (f *Fsck) SetFsck(appStoragePathID uint, issues []models.FsckIssue) {
+	f.AppStoragePathID = appStoragePathID
+	f.Issues = make([]FsckIssue, len(issues))
+
+	for i := range issues {
+		f.Issues[i] = FsckIssue{}
+		f.Issues[i].SetFsckIssue(&issues[i])
+	}
+}
+
+// SetFsckIssue sets the issue with a path relative to the files root.
+func (fi *FsckIssue) SetFsckIssue(issue *models.FsckIssue) {
+	fi.Type = issue.Type.String()
+	fi.FileType = issue.FileType.String()
+	fi.ID = issue.ID
+	fi.Size = issue.Size.String()
+	fi.Crop = issue.Crop.String()
+	fi.Path = strings.TrimPrefix(issue.Path, os.Getenv("PATH_FILES"))
+	fi.Expected = issue.Expected
+	fi.Actual = issue.Actual
+	fi.Repaired = issue.Repaired
+}
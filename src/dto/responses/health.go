@@ -0,0 +1,67 @@
+package responses
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"os"
+	"strings"
+)
+
+// Health struct for the health of the service, with the checks for machine callers.
+type Health struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck struct for the health of a dependency of the service.
+type HealthCheck struct {
+	Name         string  `json:"name"`
+	Status       string  `json:"status"`
+	Error        *string `json:"error"`
+	DurationMs   float64 `json:"durationMs"`
+	Path         *string `json:"path,omitempty"`
+	FreeBytes    *uint64 `json:"freeBytes,omitempty"`
+	TotalBytes   *uint64 `json:"totalBytes,omitempty"`
+	MinFreeBytes *uint64 `json:"minFreeBytes,omitempty"`
+}
+
+// SetHealth sets the health response. The checks are only added when detailed is set.
+func (h *Health) SetHealth(healthy bool, checks []models.HealthCheck, detailed bool) {
+	h.Status = healthStatus(healthy)
+	if !detailed {
+		return
+	}
+
+	h.Checks = make([]HealthCheck, len(checks))
+	for i := range checks {
+		h.Checks[i] = HealthCheck{}
+		h.Checks[i].SetHealthCheck(&checks[i])
+	}
+}
+
+// SetHealthCheck sets the check with a path relative to the files root.
+func (hc *HealthCheck) SetHealthCheck(check *models.HealthCheck) {
+	hc.Name = check.Name
+	hc.Status = healthStatus(check.Healthy)
+	hc.DurationMs = float64(check.Duration.Microseconds()) / 1000
+	hc.FreeBytes = check.FreeBytes
+	hc.TotalBytes = check.TotalBytes
+	hc.MinFreeBytes = check.MinFreeBytes
+
+	if check.Error != "" {
+		hc.Error = &check.Error
+	}
+	if check.Path != "" {
+		path := "/" + strings.TrimPrefix(strings.TrimPrefix(check.Path, os.Getenv("PATH_FILES")), "/")
+		hc.Path = &path
+	}
+}
+
+// healthStatus gets the status of a healthy or unhealthy check.
+func healthStatus(healthy bool) string {
+	if healthy {
+		return enums.Up.String()
+	}
+
+	return enums.Down.String()
+}
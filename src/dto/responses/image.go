@@ -6,18 +6,29 @@ import (
 )
 
 type Image struct {
-	ID               uint        `json:"id"`
-	FolderID         uint        `json:"folderId"`
-	AppStoragePathID uint        `json:"appStoragePathId"`
-	Name             string      `json:"name"`
-	Extension        string      `json:"extension"`
-	Size             int         `json:"size"`
-	Width            int         `json:"width"`
-	Height           int         `json:"height"`
-	Description      *string     `json:"description"`
-	CreatedAt        time.Time   `json:"createdAt"`
-	UpdatedAt        time.Time   `json:"updatedAt"`
-	ImageSizes       []ImageSize `json:"sizes"`
+	ID               uint                 `json:"id"`
+	FolderID         uint                 `json:"folderId"`
+	AppStoragePathID uint                 `json:"appStoragePathId"`
+	Name             string               `json:"name"`
+	Extension        string               `json:"extension"`
+	Size             int                  `json:"size"`
+	Width            int                  `json:"width"`
+	Height           int                  `json:"height"`
+	Description      *string              `json:"description"`
+	Broken           bool                 `json:"broken"`
+	Metadata         models.ImageMetadata `json:"metadata"`
+	BlurHash         string               `json:"blurHash"`
+	LQIP             string               `json:"lqip"`
+	DominantColor    string               `json:"dominantColor"`
+	FocalPoint       *FocalPoint          `json:"focalPoint"`
+	FrameCount       int                  `json:"frameCount"`
+	Duration         int                  `json:"duration"`
+	HasPoster        bool                 `json:"hasPoster"`
+	Edit             *ImageEdit           `json:"edit"`
+	CreatedAt        time.Time            `json:"createdAt"`
+	UpdatedAt        time.Time            `json:"updatedAt"`
+	ImageSizes       []ImageSize          `json:"sizes"`
+	ImageCrops       []ImageCrop          `json:"crops"`
 }
 
 // SetImage method to set an image.
@@ -29,9 +40,18 @@ func (i *Image) SetImage(image *models.Image, appStoragePathID *uint) {
 	i.Size = image.Size
 	i.Width = image.Width
 	i.Height = image.Height
+	i.Broken = image.Broken
+	i.Metadata = image.Metadata
+	i.BlurHash = image.Placeholder.BlurHash
+	i.LQIP = image.Placeholder.LQIP
+	i.DominantColor = image.Placeholder.DominantColor
+	i.FrameCount = image.Animation.FrameCount
+	i.Duration = image.Animation.Duration
+	i.HasPoster = image.Animation.HasPoster
 	i.CreatedAt = image.CreatedAt
 	i.UpdatedAt = image.UpdatedAt
 	i.ImageSizes = []ImageSize{}
+	i.ImageCrops = []ImageCrop{}
 
 	if appStoragePathID != nil {
 		i.AppStoragePathID = *appStoragePathID
@@ -43,9 +63,24 @@ func (i *Image) SetImage(image *models.Image, appStoragePathID *uint) {
 		i.Description = &image.Description.String
 	}
 
+	if image.Edit.Edited {
+		i.Edit = &ImageEdit{}
+		i.Edit.SetImageEdit(&image.Edit)
+	}
+
+	if image.FocalPointX.Valid && image.FocalPointY.Valid {
+		i.FocalPoint = &FocalPoint{X: image.FocalPointX.Float64, Y: image.FocalPointY.Float64}
+	}
+
 	for index := range image.ImageSizes {
 		imageSize := ImageSize{}
 		imageSize.SetImageSize(&image.ImageSizes[index])
 		i.ImageSizes = append(i.ImageSizes, imageSize)
 	}
+
+	for index := range image.ImageCrops {
+		imageCrop := ImageCrop{}
+		imageCrop.SetImageCrop(&image.ImageCrops[index])
+		i.ImageCrops = append(i.ImageCrops, imageCrop)
+	}
 }
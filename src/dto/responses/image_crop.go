@@ -0,0 +1,27 @@
+package responses
+
+import (
+	"api-file/main/src/models"
+	"time"
+)
+
+type ImageCrop struct {
+	ID        uint      `json:"id"`
+	ImageID   uint      `json:"imageId"`
+	Crop      string    `json:"crop"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetImageCrop method to set an image crop.
+func (ic *ImageCrop) SetImageCrop(imageCrop *models.ImageCrop) {
+	ic.ID = imageCrop.ID
+	ic.ImageID = imageCrop.ImageID
+	ic.Crop = imageCrop.Crop.String()
+	ic.Width = imageCrop.Width
+	ic.Height = imageCrop.Height
+	ic.CreatedAt = imageCrop.CreatedAt
+	ic.UpdatedAt = imageCrop.UpdatedAt
+}
@@ -0,0 +1,38 @@
+package responses
+
+import "api-file/main/src/models"
+
+// ImageEdit struct for the edit recipe of an image and the size of its edited rendition.
+type ImageEdit struct {
+	Crop           *ImageEditCrop `json:"crop"`
+	Rotation       int            `json:"rotation"`
+	FlipHorizontal bool           `json:"flipHorizontal"`
+	FlipVertical   bool           `json:"flipVertical"`
+	Brightness     float64        `json:"brightness"`
+	Contrast       float64        `json:"contrast"`
+	Width          int            `json:"width"`
+	Height         int            `json:"height"`
+}
+
+// ImageEditCrop struct for the crop box of an image edit.
+type ImageEditCrop struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// SetImageEdit method to set an image edit.
+func (e *ImageEdit) SetImageEdit(edit *models.ImageEdit) {
+	e.Rotation = edit.Rotation
+	e.FlipHorizontal = edit.FlipHorizontal
+	e.FlipVertical = edit.FlipVertical
+	e.Brightness = edit.Brightness
+	e.Contrast = edit.Contrast
+	e.Width = edit.Width
+	e.Height = edit.Height
+
+	if edit.HasCrop() {
+		e.Crop = &ImageEditCrop{Left: edit.CropLeft, Top: edit.CropTop, Width: edit.CropWidth, Height: edit.CropHeight}
+	}
+}
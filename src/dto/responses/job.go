@@ -0,0 +1,37 @@
+package responses
+
+import (
+	"api-file/main/src/models"
+	"time"
+)
+
+// Job struct for the state of a background job.
+type Job struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Total     int       `json:"total"`
+	Done      int       `json:"done"`
+	Failed    int       `json:"failed"`
+	Progress  float64   `json:"progress"`
+	Error     *string   `json:"error"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetJob sets the job response.
+func (j *Job) SetJob(job *models.Job) {
+	j.ID = job.ID
+	j.Type = job.Type.String()
+	j.Status = job.Status.String()
+	j.Total = job.Total
+	j.Done = job.Done
+	j.Failed = job.Failed
+	j.Progress = job.Progress()
+	j.CreatedAt = job.CreatedAt
+	j.UpdatedAt = job.UpdatedAt
+
+	if job.Error != "" {
+		j.Error = &job.Error
+	}
+}
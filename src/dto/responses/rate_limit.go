@@ -0,0 +1,21 @@
+package responses
+
+import "api-file/main/src/models"
+
+// RateLimit struct for the limits of an app for a group of routes.
+type RateLimit struct {
+	Group         string `json:"group"`
+	Requests      int64  `json:"requests"`
+	UploadBytes   int64  `json:"uploadBytes"`
+	DownloadBytes int64  `json:"downloadBytes"`
+	Window        int    `json:"window"`
+}
+
+// SetRateLimit method to set the rate limit.
+func (r *RateLimit) SetRateLimit(rateLimit *models.RateLimit) {
+	r.Group = rateLimit.RouteGroup
+	r.Requests = rateLimit.Requests
+	r.UploadBytes = rateLimit.UploadBytes
+	r.DownloadBytes = rateLimit.DownloadBytes
+	r.Window = rateLimit.Window
+}
@@ -0,0 +1,49 @@
+package responses
+
+import (
+	"api-file/main/src/models"
+	"time"
+)
+
+// ShareLink struct for the share link response.
+type ShareLink struct {
+	ID           uint       `json:"id"`
+	Token        string     `json:"token"`
+	URL          string     `json:"url"`
+	FileType     string     `json:"fileType"`
+	ImageID      *uint      `json:"imageId"`
+	DocumentID   *uint      `json:"documentId"`
+	FolderID     *uint      `json:"folderId"`
+	HasPassword  bool       `json:"hasPassword"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	MaxDownloads *int64     `json:"maxDownloads"`
+	Downloads    int64      `json:"downloads"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// SetShareLink sets the share link response.
+func (s *ShareLink) SetShareLink(shareLink *models.ShareLink) {
+	s.ID = shareLink.ID
+	s.Token = shareLink.Token
+	s.URL = "/v1/share/" + shareLink.Token
+	s.FileType = shareLink.FileType().String()
+	s.ImageID = shareLink.ImageID
+	s.DocumentID = shareLink.DocumentID
+	s.FolderID = shareLink.FolderID
+	s.HasPassword = shareLink.HasPassword()
+	s.Downloads = shareLink.Downloads
+	s.CreatedAt = shareLink.CreatedAt
+	s.UpdatedAt = shareLink.UpdatedAt
+
+	if shareLink.ExpiresAt.Valid {
+		s.ExpiresAt = &shareLink.ExpiresAt.Time
+	}
+	if shareLink.MaxDownloads.Valid {
+		s.MaxDownloads = &shareLink.MaxDownloads.Int64
+	}
+	if shareLink.RevokedAt.Valid {
+		s.RevokedAt = &shareLink.RevokedAt.Time
+	}
+}
@@ -0,0 +1,59 @@
+package responses
+
+import (
+	"api-file/main/src/models"
+	"fmt"
+)
+
+// SharedFolder struct for the listing of a shared folder with everything inside it.
+type SharedFolder struct {
+	Name      string         `json:"name"`
+	Folders   []SharedFolder `json:"folders"`
+	Images    []SharedFile   `json:"images"`
+	Documents []SharedFile   `json:"documents"`
+}
+
+// SharedFile struct for a file inside a shared folder with the URL to download it.
+type SharedFile struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Extension string `json:"extension"`
+	Size      int    `json:"size"`
+	URL       string `json:"url"`
+}
+
+// SetSharedFolder sets the shared folder response of the folder tree of the share link token.
+func (f *SharedFolder) SetSharedFolder(tree *models.FolderTree, token string) {
+	f.Name = tree.Folder.Name
+	f.Folders = make([]SharedFolder, len(tree.Folders))
+	f.Images = make([]SharedFile, len(tree.Images))
+	f.Documents = make([]SharedFile, len(tree.Documents))
+
+	for i := range tree.Folders {
+		f.Folders[i].SetSharedFolder(tree.Folders[i], token)
+	}
+	for i := range tree.Images {
+		f.Images[i].SetSharedImage(&tree.Images[i], token)
+	}
+	for i := range tree.Documents {
+		f.Documents[i].SetSharedDocument(&tree.Documents[i], token)
+	}
+}
+
+// SetSharedImage sets the shared file response of an image.
+func (f *SharedFile) SetSharedImage(image *models.Image, token string) {
+	f.ID = image.ID
+	f.Name = image.Name
+	f.Extension = image.Extension
+	f.Size = image.Size
+	f.URL = fmt.Sprintf("/v1/share/%s/image/%d", token, image.ID)
+}
+
+// SetSharedDocument sets the shared file response of a document.
+func (f *SharedFile) SetSharedDocument(document *models.Document, token string) {
+	f.ID = document.ID
+	f.Name = document.Name
+	f.Extension = document.Extension
+	f.Size = document.Size
+	f.URL = fmt.Sprintf("/v1/share/%s/document/%d", token, document.ID)
+}
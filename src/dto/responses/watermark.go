@@ -0,0 +1,23 @@
+package responses
+
+import "api-file/main/src/models"
+
+// Watermark struct for the watermark of an AppStoragePath. The image is not returned.
+type Watermark struct {
+	Text     string   `json:"text"`
+	HasImage bool     `json:"hasImage"`
+	Position string   `json:"position"`
+	Opacity  float64  `json:"opacity"`
+	Scale    float64  `json:"scale"`
+	Sizes    []string `json:"sizes"`
+}
+
+// SetWatermark sets the Watermark response.
+func (w *Watermark) SetWatermark(watermark *models.Watermark) {
+	w.Text = watermark.Text
+	w.HasImage = len(watermark.Image) > 0
+	w.Position = watermark.Position.String()
+	w.Opacity = watermark.Opacity
+	w.Scale = watermark.Scale
+	w.Sizes = watermark.SizeList()
+}
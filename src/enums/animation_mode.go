@@ -0,0 +1,24 @@
+package enums
+
+import "database/sql/driver"
+
+type AnimationMode string
+
+const (
+	AnimationAnimate  AnimationMode = "animate"
+	AnimationStill    AnimationMode = "still"
+	AnimationOriginal AnimationMode = "original"
+)
+
+func (m *AnimationMode) Scan(value interface{}) error {
+	*m = AnimationMode(value.(string))
+	return nil
+}
+
+func (m AnimationMode) Value() (driver.Value, error) {
+	return string(m), nil
+}
+
+func (m AnimationMode) String() string {
+	return string(m)
+}
@@ -0,0 +1,14 @@
+package enums
+
+type BatchOperation string
+
+const (
+	BatchDelete  BatchOperation = "delete"
+	BatchRestore BatchOperation = "restore"
+	BatchMove    BatchOperation = "move"
+	BatchUpdate  BatchOperation = "update"
+)
+
+func (o BatchOperation) String() string {
+	return string(o)
+}
@@ -0,0 +1,14 @@
+package enums
+
+type ConflictStrategy string
+
+const (
+	ConflictFail      ConflictStrategy = "fail"
+	ConflictRename    ConflictStrategy = "rename"
+	ConflictSkip      ConflictStrategy = "skip"
+	ConflictOverwrite ConflictStrategy = "overwrite"
+)
+
+func (s ConflictStrategy) String() string {
+	return string(s)
+}
@@ -0,0 +1,65 @@
+package enums
+
+import "database/sql/driver"
+
+type Crop string
+
+const (
+	Avatar Crop = "avatar"
+	Card   Crop = "card"
+	Banner Crop = "banner"
+	Wide   Crop = "wide"
+)
+
+// Crops lists every crop preset with a fixed aspect ratio.
+var Crops = []Crop{Avatar, Card, Banner, Wide}
+
+func (c *Crop) Scan(value interface{}) error {
+	*c = Crop(value.(string))
+	return nil
+}
+
+func (c Crop) Value() (driver.Value, error) {
+	return string(c), nil
+}
+
+func (c Crop) String() string {
+	return string(c)
+}
+
+// Width returns the target width in pixels of the crop preset.
+func (c Crop) Width() int {
+	switch c {
+	case Avatar:
+		return 400
+	case Card:
+		return 960
+	case Banner:
+		return 1920
+	case Wide:
+		return 1920
+	default:
+		return 0
+	}
+}
+
+// Height returns the target height in pixels of the crop preset.
+func (c Crop) Height() int {
+	switch c {
+	case Avatar:
+		return 400
+	case Card:
+		return 720
+	case Banner:
+		return 640
+	case Wide:
+		return 1080
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the crop is one of the known crop presets.
+func (c Crop) IsValid() bool {
+	return c.Width() > 0
+}
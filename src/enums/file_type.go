@@ -5,6 +5,7 @@ type FileType string
 const (
 	Image    FileType = "image"
 	Document FileType = "document"
+	Folder   FileType = "folder"
 )
 
 func (t FileType) String() string {
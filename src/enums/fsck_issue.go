@@ -0,0 +1,15 @@
+package enums
+
+type FsckIssue string
+
+const (
+	OrphanFile   FsckIssue = "orphanFile"
+	MissingFile  FsckIssue = "missingFile"
+	MissingSize  FsckIssue = "missingSize"
+	MissingCrop  FsckIssue = "missingCrop"
+	SizeMismatch FsckIssue = "sizeMismatch"
+)
+
+func (i FsckIssue) String() string {
+	return string(i)
+}
@@ -0,0 +1,12 @@
+package enums
+
+type HealthStatus string
+
+const (
+	Up   HealthStatus = "up"
+	Down HealthStatus = "down"
+)
+
+func (s HealthStatus) String() string {
+	return string(s)
+}
@@ -0,0 +1,14 @@
+package enums
+
+type JobStatus string
+
+const (
+	Pending   JobStatus = "pending"
+	Running   JobStatus = "running"
+	Completed JobStatus = "completed"
+	Failed    JobStatus = "failed"
+)
+
+func (s JobStatus) String() string {
+	return string(s)
+}
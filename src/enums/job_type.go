@@ -0,0 +1,14 @@
+package enums
+
+type JobType string
+
+const (
+	RegenerateSizes      JobType = "regenerateSizes"
+	GeneratePlaceholders JobType = "generatePlaceholders"
+	CopyFolder           JobType = "copyFolder"
+	Rewatermark          JobType = "rewatermark"
+)
+
+func (t JobType) String() string {
+	return string(t)
+}
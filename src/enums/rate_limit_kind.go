@@ -0,0 +1,13 @@
+package enums
+
+type RateLimitKind string
+
+const (
+	Requests      RateLimitKind = "requests"
+	UploadBytes   RateLimitKind = "upload"
+	DownloadBytes RateLimitKind = "download"
+)
+
+func (k RateLimitKind) String() string {
+	return string(k)
+}
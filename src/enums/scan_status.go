@@ -0,0 +1,24 @@
+package enums
+
+import "database/sql/driver"
+
+type ScanStatus string
+
+const (
+	Unscanned ScanStatus = "unscanned"
+	Clean     ScanStatus = "clean"
+	Infected  ScanStatus = "infected"
+)
+
+func (s *ScanStatus) Scan(value interface{}) error {
+	*s = ScanStatus(value.(string))
+	return nil
+}
+
+func (s ScanStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s ScanStatus) String() string {
+	return string(s)
+}
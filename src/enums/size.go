@@ -13,6 +13,9 @@ const (
 	XXL Size = "xxl"
 )
 
+// Sizes lists every web size from small to large.
+var Sizes = []Size{XS, SM, MD, LG, XL, XXL}
+
 func (s *Size) Scan(value interface{}) error {
 	*s = Size(value.(string))
 	return nil
@@ -25,3 +28,28 @@ func (s Size) Value() (driver.Value, error) {
 func (s Size) String() string {
 	return string(s)
 }
+
+// Width returns the target width in pixels of the web size.
+func (s Size) Width() int {
+	switch s {
+	case XS:
+		return 600
+	case SM:
+		return 960
+	case MD:
+		return 1280
+	case LG:
+		return 1920
+	case XL:
+		return 2560
+	case XXL:
+		return 3840
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the size is one of the known web sizes.
+func (s Size) IsValid() bool {
+	return s.Width() > 0
+}
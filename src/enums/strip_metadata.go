@@ -0,0 +1,24 @@
+package enums
+
+import "database/sql/driver"
+
+type StripMetadata string
+
+const (
+	StripNone     StripMetadata = "none"
+	StripLocation StripMetadata = "location"
+	StripAll      StripMetadata = "all"
+)
+
+func (s *StripMetadata) Scan(value interface{}) error {
+	*s = StripMetadata(value.(string))
+	return nil
+}
+
+func (s StripMetadata) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s StripMetadata) String() string {
+	return string(s)
+}
@@ -0,0 +1,55 @@
+package enums
+
+import "database/sql/driver"
+
+type WatermarkPosition string
+
+const (
+	WatermarkTopLeft     WatermarkPosition = "topLeft"
+	WatermarkTop         WatermarkPosition = "top"
+	WatermarkTopRight    WatermarkPosition = "topRight"
+	WatermarkLeft        WatermarkPosition = "left"
+	WatermarkCenter      WatermarkPosition = "center"
+	WatermarkRight       WatermarkPosition = "right"
+	WatermarkBottomLeft  WatermarkPosition = "bottomLeft"
+	WatermarkBottom      WatermarkPosition = "bottom"
+	WatermarkBottomRight WatermarkPosition = "bottomRight"
+)
+
+func (p *WatermarkPosition) Scan(value interface{}) error {
+	*p = WatermarkPosition(value.(string))
+	return nil
+}
+
+func (p WatermarkPosition) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (p WatermarkPosition) String() string {
+	return string(p)
+}
+
+// Offset returns where the watermark is placed as fractions of the free space
+// to the left and above it, so 0 is at the start and 1 at the end.
+func (p WatermarkPosition) Offset() (x, y float64) {
+	switch p {
+	case WatermarkTopLeft:
+		return 0, 0
+	case WatermarkTop:
+		return 0.5, 0
+	case WatermarkTopRight:
+		return 1, 0
+	case WatermarkLeft:
+		return 0, 0.5
+	case WatermarkCenter:
+		return 0.5, 0.5
+	case WatermarkRight:
+		return 1, 0.5
+	case WatermarkBottomLeft:
+		return 0, 1
+	case WatermarkBottom:
+		return 0.5, 1
+	default:
+		return 1, 1
+	}
+}
@@ -8,18 +8,44 @@ const (
 	StoragePathFull      = "storagePathFull"
 	FolderExists         = "folderExists"
 	FolderImmutable      = "folderImmutable"
+	FolderInside         = "folderInside"
 	ImageExists          = "imageExists"
 	ImageTypeInvalid     = "imageTypeInvalid"
+	ImageLocked          = "imageLocked"
 	ParseBase64          = "parseBase64"
 	ParseFilename        = "parseFilename"
 	DeleteImage          = "deleteImage"
 	UploadImage          = "uploadImage"
 	ConvertImage         = "convertImage"
+	EditImage            = "editImage"
 	CodeInvalid          = "codeInvalid"
 	CodeExists           = "codeExists"
 	DocumentExist        = "documentExist"
 	DocumentTypeInvalid  = "documentTypeInvalid"
 	UploadDocument       = "uploadDocument"
 	DeleteDocument       = "deleteDocument"
+	DocumentPageExists   = "documentPageExists"
+	PreviewDocument      = "previewDocument"
+	ScanDocument         = "scanDocument"
+	DocumentInfected     = "documentInfected"
+	EncryptStorage       = "encryptStorage"
+	Watermark            = "watermark"
+	DecryptFile          = "decryptFile"
+	CheckStorage         = "checkStorage"
+	CopyFile             = "copyFile"
+	MoveFile             = "moveFile"
+	RenameFile           = "renameFile"
+	JobExists            = "jobExists"
+	ShareLinkExists      = "shareLinkExists"
+	ShareLinkExpired     = "shareLinkExpired"
+	ShareLinkPassword    = "shareLinkPassword"
+	ShareLinkDownloads   = "shareLinkDownloads"
+	RateLimited          = "rateLimited"
+	IdempotencyKey       = "idempotencyKey"
+	IdempotencyReused    = "idempotencyReused"
+	IdempotencyPending   = "idempotencyPending"
+	BatchOperation       = "batchOperation"
+	BatchRolledBack      = "batchRolledBack"
+	NotReady             = "notReady"
 	// Add more error codes as needed.
 )
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "file"
+
+var (
+	// HttpRequests counts the handled requests per route and status.
+	HttpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HttpRequestDuration observes the duration of the requests per route.
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the handled HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// BytesUploaded counts the bytes of the uploaded images and documents per app.
+	BytesUploaded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes of the uploaded images and documents.",
+	}, []string{"app"})
+
+	// BytesServed counts the bytes of the files that are sent per app.
+	BytesServed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "served_bytes_total",
+		Help:      "Bytes of the served files.",
+	}, []string{"app"})
+
+	// ImageConvertDuration observes the duration of converting and uploading an image per size.
+	ImageConvertDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_convert_duration_seconds",
+		Help:      "Duration of converting and uploading a web size of an image.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"size"})
+
+	// PathCacheLookups counts the lookups of file paths in the cache by result: hit, miss or error.
+	PathCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "path_cache_lookups_total",
+		Help:      "Lookups of file paths in the cache.",
+	}, []string{"result"})
+
+	// WebSocketConnections is the number of open progress connections.
+	WebSocketConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Number of open progress WebSocket connections.",
+	})
+
+	// JobQueueDepth is the number of jobs that are not finished per type.
+	JobQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_queue_depth",
+		Help:      "Number of background jobs that are not finished.",
+	}, []string{"type"})
+)
+
+// Path cache lookup results.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StorageUsage is the used space and the limit of a storage path.
+type StorageUsage struct {
+	App   string
+	Path  string
+	Used  int64
+	Limit *int64
+}
+
+// storageUsageCollector collects the storage usage of every storage path while it is scraped.
+type storageUsageCollector struct {
+	used   *prometheus.Desc
+	limit  *prometheus.Desc
+	usages func() ([]StorageUsage, error)
+}
+
+// RegisterStorageUsage func to register the storage usage gauges, which are read with usages on each scrape.
+func RegisterStorageUsage(usages func() ([]StorageUsage, error)) {
+	prometheus.MustRegister(&storageUsageCollector{
+		used: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "used_bytes"),
+			"Bytes used by the images and documents of a storage path.",
+			[]string{"app", "path"}, nil,
+		),
+		limit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "limit_bytes"),
+			"Limit in bytes of a storage path, absent when it has no limit.",
+			[]string{"app", "path"}, nil,
+		),
+		usages: usages,
+	})
+}
+
+// Describe method to send the descriptions of the gauges.
+func (c *storageUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.used
+	ch <- c.limit
+}
+
+// Collect method to send the usage of every storage path.
+func (c *storageUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	usages, err := c.usages()
+	if err != nil {
+		log.Printf("Error collecting storage usage: %v", err)
+		return
+	}
+
+	for _, usage := range usages {
+		ch <- prometheus.MustNewConstMetric(c.used, prometheus.GaugeValue, float64(usage.Used), usage.App, usage.Path)
+		if usage.Limit != nil {
+			ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(*usage.Limit), usage.App, usage.Path)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/database"
+	"api-file/main/src/docs"
+	"api-file/main/src/errors"
+	"strings"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// connectionRetryAfter is the seconds after which a client can retry while the service connects.
+const connectionRetryAfter = "5"
+
+// connectionMiddleware answers with 503 Service Unavailable until the database and the cache are connected.
+// The health, metrics and documentation routes do not need them, so they are always served.
+func connectionMiddleware(c *fiber.Ctx) error {
+	if database.IsConnected() && cache.IsConnected() {
+		return c.Next()
+	}
+
+	path := c.Path()
+	if strings.HasPrefix(path, "/health/") || path == "/metrics" ||
+		path == "/"+docs.Version+"/openapi.json" || path == "/"+docs.Version+"/docs" {
+		return c.Next()
+	}
+
+	c.Set(fiber.HeaderRetryAfter, connectionRetryAfter)
+	return errorutil.Response(c, fiber.StatusServiceUnavailable, errors.NotReady, "Service is not connected to its database and cache yet.")
+}
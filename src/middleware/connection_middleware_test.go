@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestConnectionMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(connectionMiddleware)
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/health/ready", status: fiber.StatusOK},
+		{path: "/metrics", status: fiber.StatusOK},
+		{path: "/v1/openapi.json", status: fiber.StatusOK},
+		{path: "/v1/images/1", status: fiber.StatusServiceUnavailable},
+		{path: "/v1/share/token", status: fiber.StatusServiceUnavailable},
+	}
+
+	// The database and the cache are not connected in the tests.
+	for _, test := range tests {
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, test.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.StatusCode != test.status {
+			t.Errorf("%s: got status %d, want %d", test.path, response.StatusCode, test.status)
+		}
+		if test.status == fiber.StatusServiceUnavailable && response.Header.Get(fiber.HeaderRetryAfter) == "" {
+			t.Errorf("%s: got no Retry-After header", test.path)
+		}
+	}
+}
@@ -24,14 +24,28 @@ func FiberMiddleware(a *fiber.App) {
 				fiber.MethodHead,
 				fiber.MethodOptions,
 			}, ","),
-			AllowHeaders: "Accept,Content-Type",
+			AllowHeaders: strings.Join([]string{
+				fiber.HeaderAccept,
+				fiber.HeaderContentType,
+				"X-Share-Password",
+				HeaderIdempotencyKey,
+			}, ","),
 		}),
 
 		// Add simple logger.
 		logger.New(),
 
+		// Trace each request as a child of the trace context of the caller.
+		tracingMiddleware,
+
+		// Count the requests per route, including the ones that panic.
+		metricsMiddleware,
+
 		// Catch a panic and return a 500 response.
 		recover.New(),
+
+		// Refuse the requests that need the database and the cache until they are connected.
+		connectionMiddleware,
 	)
 	a.Use("/ws", webSocketMiddleware)
 }
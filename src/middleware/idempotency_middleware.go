@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"api-file/main/src/errors"
+	"api-file/main/src/services"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of idempotent requests.
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is the longest idempotency key that is accepted.
+const maxIdempotencyKeyLength = 255
+
+// Idempotency func to replay the response of a POST or PUT request with an Idempotency-Key header
+// when the request is retried, so a retry does not create the item again. The key is kept per app of the rate limit,
+// a retry with another method, URL or body is rejected, as is a retry while the first request is handled.
+// Responses with a server error are not kept, so the request can be retried.
+func Idempotency() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		idempotencyKey := c.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" || (c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPut) {
+			return c.Next()
+		} else if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return errorutil.Response(c, fiber.StatusBadRequest, errors.IdempotencyKey, "The idempotency key is longer than 255 characters.")
+		}
+
+		app, _ := c.Locals(localsApp).(string)
+		key := app + "\x00" + idempotencyKey
+		fingerprint := requestFingerprint(c)
+
+		// Claim the key or replay the response of the first request.
+		ctx := c.UserContext()
+		response, err := services.StartIdempotentRequest(ctx, key, fingerprint)
+		if err != nil {
+			log.Printf("idempotency: claim key: %v", err)
+			return c.Next()
+		} else if response != nil {
+			if response.Fingerprint != fingerprint {
+				return errorutil.Response(c, fiber.StatusUnprocessableEntity, errors.IdempotencyReused, "The idempotency key is used for another request.")
+			} else if !response.Done {
+				return errorutil.Response(c, fiber.StatusConflict, errors.IdempotencyPending, "The request of the idempotency key is still handled.")
+			}
+
+			c.Set(HeaderIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, response.ContentType)
+			return c.Status(response.Status).Send(response.Body)
+		}
+
+		// Release the key when the request fails or panics.
+		saved := false
+		defer func() {
+			if !saved {
+				if err := services.DeleteIdempotentRequest(ctx, key); err != nil {
+					log.Printf("idempotency: release key: %v", err)
+				}
+			}
+		}()
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError || c.Response().IsBodyStream() {
+			return err
+		}
+
+		if err := services.SaveIdempotentResponse(ctx, key, &services.IdempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      c.Response().StatusCode(),
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		}); err != nil {
+			log.Printf("idempotency: save response: %v", err)
+			return nil
+		}
+		saved = true
+
+		return nil
+	}
+}
+
+// requestFingerprint hashes the method, the URL and the body of the request.
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
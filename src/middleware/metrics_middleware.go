@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"api-file/main/src/metrics"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// metricsMiddleware counts the requests and observes their duration per route.
+// The route is the registered path, so the metrics do not grow with the IDs in the URL.
+func metricsMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	route := c.Route().Path
+	metrics.HttpRequests.WithLabelValues(c.Method(), route, strconv.Itoa(responseStatus(c, err))).Inc()
+	metrics.HttpRequestDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// responseStatus gets the status of the response, which is set by the error handler when the handler returns an error.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return fiber.StatusInternalServerError
+}
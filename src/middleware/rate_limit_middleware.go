@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/errors"
+	"api-file/main/src/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers of the rate limit of the requests.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// localsApp is the key of the locals with the name of the app of the request.
+const localsApp = "app"
+
+// AppResolver finds the name of the app of a request. Requests without an app get the default limits.
+// The app is found by the item of the request and never sent by the client, so a client can not pick
+// the limits of another app or reset its counters by naming another app.
+type AppResolver func(c *fiber.Ctx) (string, error)
+
+// RateLimit func to limit the requests, the uploaded bytes and the downloaded bytes of each client of the app
+// within a window for the group of routes. The counters are kept in the cache, so the limits hold across replicas.
+// The downloaded bytes are a quota, not a throttle: a response is sent at full speed and its bytes are counted
+// once it is sent, so only the requests after the quota is used up are refused.
+// The route params are only known when the middleware is added to the route itself.
+// Requests are not limited when the limits can not be checked.
+func RateLimit(group string, app AppResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name, err := app(c)
+		if err != nil {
+			log.Printf("rate limit: app of %s: %v", c.Path(), err)
+		}
+		c.Locals(localsApp, name)
+
+		limit, err := services.GetRateLimit(name, group)
+		if err != nil {
+			log.Printf("rate limit: limits of %s: %v", name, err)
+		}
+		if limit.IsUnlimited() {
+			return c.Next()
+		}
+
+		window := limit.WindowDuration()
+		client := c.IP()
+		take := func(kind enums.RateLimitKind, amount, threshold int64, force bool) (services.RateLimitResult, bool) {
+			result, err := services.TakeRateLimit(c.UserContext(), kind, name, group, client, amount, threshold, window, force)
+			if err != nil {
+				log.Printf("rate limit: %s of %s: %v", kind, name, err)
+				return result, false
+			}
+
+			return result, true
+		}
+
+		// Limit the number of requests.
+		if limit.Requests > 0 {
+			if result, ok := take(enums.Requests, 1, limit.Requests, false); ok {
+				c.Set(HeaderRateLimitLimit, strconv.FormatInt(limit.Requests, 10))
+				c.Set(HeaderRateLimitRemaining, strconv.FormatInt(max(limit.Requests-result.Used, 0), 10))
+				c.Set(HeaderRateLimitReset, strconv.Itoa(resetSeconds(result.Reset)))
+				if !result.Allowed {
+					return rateLimited(c, result, "Too many requests.")
+				}
+			}
+		}
+
+		// Limit the uploaded bytes, a body larger than the limit never fits in a window.
+		if limit.UploadBytes > 0 && c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			size := int64(c.Request().Header.ContentLength())
+			if size < 0 {
+				size = int64(len(c.Body()))
+			}
+			if size > limit.UploadBytes {
+				return errorutil.Response(c, fiber.StatusRequestEntityTooLarge, errors.RateLimited, fmt.Sprintf("The upload is larger than the limit of %d bytes.", limit.UploadBytes))
+			}
+			if result, ok := take(enums.UploadBytes, size, limit.UploadBytes, false); ok && !result.Allowed {
+				return rateLimited(c, result, "Too many bytes uploaded.")
+			}
+		}
+
+		// Limit the downloaded bytes by a quota, the size is known after the response is made.
+		download := limit.DownloadBytes > 0 && c.Method() == fiber.MethodGet
+		if download {
+			if result, ok := take(enums.DownloadBytes, 0, limit.DownloadBytes, false); ok && !result.Allowed {
+				return rateLimited(c, result, "Too many bytes downloaded.")
+			}
+		}
+
+		err = c.Next()
+
+		if download {
+			if size := responseSize(c); size > 0 {
+				take(enums.DownloadBytes, size, limit.DownloadBytes, true)
+			}
+		}
+
+		return err
+	}
+}
+
+// ItemApp func to find the app of the item of the id that follows the path of the group, like the 12 of /v1/images/12/original.
+// Requests without an id are found by their body with BodyApp.
+func ItemApp(find func(id uint) (string, error)) AppResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		param := groupParam(c)
+		if param == "" {
+			return BodyApp(c)
+		}
+
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil || id < 1 {
+			return BodyApp(c)
+		}
+
+		return find(uint(id))
+	}
+}
+
+// BodyApp func to find the app of a JSON body by its storage path, folder, image or document,
+// or by the item of the first operation of a batch.
+func BodyApp(c *fiber.Ctx) (string, error) {
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead || len(c.Body()) == 0 {
+		return "", nil
+	}
+
+	var body struct {
+		AppStoragePathID uint   `json:"appStoragePathId"`
+		FolderID         uint   `json:"folderId"`
+		ImageID          uint   `json:"imageId"`
+		DocumentID       uint   `json:"documentId"`
+		ImageIDs         []uint `json:"imageIds"`
+		DocumentIDs      []uint `json:"documentIds"`
+		Operations       []struct {
+			Type string `json:"type"`
+			ID   uint   `json:"id"`
+		} `json:"operations"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return "", nil
+	}
+
+	switch {
+	case body.AppStoragePathID > 0:
+		return services.GetAppStoragePathAppName(body.AppStoragePathID)
+	case body.FolderID > 0:
+		return services.GetFolderAppName(body.FolderID)
+	case body.ImageID > 0:
+		return services.GetImageAppName(body.ImageID)
+	case body.DocumentID > 0:
+		return services.GetDocumentAppName(body.DocumentID)
+	case len(body.ImageIDs) > 0:
+		return services.GetImageAppName(body.ImageIDs[0])
+	case len(body.DocumentIDs) > 0:
+		return services.GetDocumentAppName(body.DocumentIDs[0])
+	case len(body.Operations) > 0:
+		switch body.Operations[0].Type {
+		case "image":
+			return services.GetImageAppName(body.Operations[0].ID)
+		case "document":
+			return services.GetDocumentAppName(body.Operations[0].ID)
+		case "folder":
+			return services.GetFolderAppName(body.Operations[0].ID)
+		}
+	}
+
+	return "", nil
+}
+
+// AppNameApp func to find the app of the name that follows the path of the group, like the my-app of /v1/apps/my-app/rate-limits.
+// A name of an app that does not exist gets the default limits.
+func AppNameApp(c *fiber.Ctx) (string, error) {
+	name := groupParam(c)
+	if name == "" {
+		return "", nil
+	}
+
+	if available, err := services.IsAppAvailable(name); err != nil || !available {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// JobApp func to find the app of the storage path of the job of the id that follows the path of the group.
+func JobApp(c *fiber.Ctx) (string, error) {
+	id := groupParam(c)
+	if id == "" {
+		return "", nil
+	}
+
+	job, err := services.GetJob(id)
+	if err != nil || job == nil || job.AppStoragePathID == 0 {
+		return "", err
+	}
+
+	return services.GetAppStoragePathAppName(job.AppStoragePathID)
+}
+
+// StoragePathQueryApp func to find the app of the storage path of the id query, like the handshake.
+func StoragePathQueryApp(c *fiber.Ctx) (string, error) {
+	id := c.QueryInt("id")
+	if id < 1 {
+		return "", nil
+	}
+
+	return services.GetAppStoragePathAppName(uint(id))
+}
+
+// ImageApp func to find the app of the image of the id param.
+func ImageApp(c *fiber.Ctx) (string, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return "", nil
+	}
+
+	return services.GetImageAppName(uint(id))
+}
+
+// DocumentApp func to find the app of the document of the id param.
+func DocumentApp(c *fiber.Ctx) (string, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return "", nil
+	}
+
+	return services.GetDocumentAppName(uint(id))
+}
+
+// ShareLinkApp func to find the app of the item shared by the share link of the token param.
+func ShareLinkApp(c *fiber.Ctx) (string, error) {
+	return services.GetShareLinkAppName(c.Params("token"))
+}
+
+// groupParam gets the first segment of the path that follows the path of the group of the middleware.
+func groupParam(c *fiber.Ctx) string {
+	param := strings.TrimPrefix(strings.TrimPrefix(c.Path(), c.Route().Path), "/")
+	if i := strings.IndexByte(param, '/'); i >= 0 {
+		param = param[:i]
+	}
+
+	return param
+}
+
+// responseSize gets the size of the response body. The length is only in the header for files that are sent
+// with SendFile, other bodies are counted. A stream of an unknown length, like a ZIP archive, is not read.
+func responseSize(c *fiber.Ctx) int64 {
+	if size := c.Response().Header.ContentLength(); size > 0 {
+		return int64(size)
+	} else if c.Response().IsBodyStream() {
+		return 0
+	}
+
+	return int64(len(c.Response().Body()))
+}
+
+// rateLimited responds that the limit is reached and when the client can retry.
+func rateLimited(c *fiber.Ctx, result services.RateLimitResult, message string) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetSeconds(result.Reset)))
+
+	return errorutil.Response(c, fiber.StatusTooManyRequests, errors.RateLimited, message)
+}
+
+// resetSeconds rounds the time until the window resets up to whole seconds.
+func resetSeconds(reset time.Duration) int {
+	return int(math.Ceil(reset.Seconds()))
+}
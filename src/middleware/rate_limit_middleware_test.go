@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestItemApp(t *testing.T) {
+	var found uint
+	resolver := ItemApp(func(id uint) (string, error) {
+		found = id
+		return "app", nil
+	})
+
+	app := fiber.New()
+	var name string
+	images := app.Group("/v1/images", func(c *fiber.Ctx) error {
+		name, _ = resolver(c)
+		return c.Next()
+	})
+	images.Get("/*", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		path string
+		id   uint
+		app  string
+	}{
+		{path: "/v1/images/12", id: 12, app: "app"},
+		{path: "/v1/images/12/original", id: 12, app: "app"},
+		{path: "/v1/images/move", app: ""},
+		{path: "/v1/images/0", app: ""},
+		{path: "/v1/images", app: ""},
+	}
+
+	for _, test := range tests {
+		found, name = 0, ""
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, test.path, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if found != test.id || name != test.app {
+			t.Errorf("%s: got id %d and app %q, want id %d and app %q", test.path, found, name, test.id, test.app)
+		}
+	}
+}
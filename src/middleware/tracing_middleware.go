@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"api-file/main/src/tracing"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// headerCarrier reads the trace context of the caller from the request headers.
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+// Get method to get the value of a header.
+func (h headerCarrier) Get(key string) string {
+	return string(h.header.Peek(key))
+}
+
+// Set method to set the value of a header.
+func (h headerCarrier) Set(key, value string) {
+	h.header.Set(key, value)
+}
+
+// Keys method to get the names of the headers.
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, h.header.Len())
+	h.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
+
+// tracingMiddleware starts a span for each request as a child of the trace context of the caller.
+// The context with the span is the user context of the request, so the handlers can pass it on.
+func tracingMiddleware(c *fiber.Ctx) error {
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{header: &c.Request().Header})
+	ctx, span := tracing.StartServer(ctx, c.Method(),
+		semconv.HTTPRequestMethodKey.String(c.Method()),
+		semconv.URLPath(c.Path()),
+	)
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	status := responseStatus(c, err)
+	route := c.Route().Path
+	span.SetName(fmt.Sprintf("%s %s", c.Method(), route))
+	span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, utils.StatusMessage(status))
+	}
+
+	return err
+}
@@ -1,12 +1,22 @@
 package models
 
-import "database/sql"
+import (
+	"api-file/main/src/enums"
+	"database/sql"
+)
 
 type AppStoragePath struct {
-	ID      uint   `gorm:"primaryKey"`
-	AppName string `gorm:"not null;index:idx_app_storage_path,unique,priority:1"`
-	Path    string `gorm:"not null;index:idx_app_storage_path,unique,priority:2"`
-	Limit   sql.NullInt64
+	ID              uint   `gorm:"primaryKey"`
+	AppName         string `gorm:"not null;index:idx_app_storage_path,unique,priority:1"`
+	Path            string `gorm:"not null;index:idx_app_storage_path,unique,priority:2"`
+	Limit           sql.NullInt64
+	StripMetadata   enums.StripMetadata `gorm:"default:none;not null"`
+	AnimationMode   enums.AnimationMode `gorm:"default:animate;not null"`
+	AnimationPoster bool                `gorm:"default:false;not null"`
+	Encrypted       bool                `gorm:"default:false;not null"`
+	DataKey         []byte
+	MasterKeyID     sql.NullString
+	Watermark       Watermark `gorm:"embedded;embeddedPrefix:watermark_"`
 
 	// Relationships.
 	App     App      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppName;references:Name"`
@@ -0,0 +1,14 @@
+package models
+
+import "api-file/main/src/enums"
+
+// BatchItem is an operation of a batch on an image, document or folder.
+// Items are moved into the folder, images are updated with the description and folders with the color.
+type BatchItem struct {
+	Operation   enums.BatchOperation
+	Type        enums.FileType
+	ID          uint
+	FolderID    uint
+	Description *string
+	Color       *string
+}
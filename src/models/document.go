@@ -1,14 +1,25 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"api-file/main/src/enums"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
 
 type Document struct {
 	gorm.Model
-	FolderID  uint   `gorm:"not null"`
-	Name      string `gorm:"not null"`
-	Extension string `gorm:"not null"`
-	MimeType  string `gorm:"not null"`
-	Size      int    `gorm:"not null"`
+	FolderID      uint             `gorm:"not null"`
+	Name          string           `gorm:"not null"`
+	Extension     string           `gorm:"not null"`
+	MimeType      string           `gorm:"not null"`
+	Size          int              `gorm:"not null"`
+	Broken        bool             `gorm:"default:false;not null"`
+	PageCount     int              `gorm:"default:0;not null"`
+	HasPreview    bool             `gorm:"default:false;not null"`
+	ScanStatus    enums.ScanStatus `gorm:"default:unscanned;not null"`
+	ScanSignature sql.NullString
+	ScannedAt     sql.NullTime
 
 	// Relationships.
 	Folder Folder `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:FolderID;references:ID"`
@@ -0,0 +1,41 @@
+package models
+
+// FolderTree is a folder with the sub folders, images and documents inside it that are not deleted.
+type FolderTree struct {
+	Folder    Folder
+	Folders   []*FolderTree
+	Images    []Image
+	Documents []Document
+}
+
+// FindImage method to find an image inside the folder tree.
+func (t *FolderTree) FindImage(id uint) *Image {
+	for i := range t.Images {
+		if t.Images[i].ID == id {
+			return &t.Images[i]
+		}
+	}
+	for _, folder := range t.Folders {
+		if image := folder.FindImage(id); image != nil {
+			return image
+		}
+	}
+
+	return nil
+}
+
+// FindDocument method to find a document inside the folder tree.
+func (t *FolderTree) FindDocument(id uint) *Document {
+	for i := range t.Documents {
+		if t.Documents[i].ID == id {
+			return &t.Documents[i]
+		}
+	}
+	for _, folder := range t.Folders {
+		if document := folder.FindDocument(id); document != nil {
+			return document
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package models
+
+import "api-file/main/src/enums"
+
+// FsckIssue is an inconsistency between the files on disk and the database.
+type FsckIssue struct {
+	Type     enums.FsckIssue
+	FileType enums.FileType
+	ID       uint
+	Size     enums.Size
+	Crop     enums.Crop
+	Path     string
+	Expected int64
+	Actual   int64
+	Repaired bool
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// HealthCheck is the result of checking a dependency of the service.
+type HealthCheck struct {
+	Name         string
+	Healthy      bool
+	Error        string
+	Duration     time.Duration
+	Path         string
+	FreeBytes    *uint64
+	TotalBytes   *uint64
+	MinFreeBytes *uint64
+}
@@ -15,8 +15,16 @@ type Image struct {
 	Width       int    `gorm:"not null"`
 	Height      int    `gorm:"not null"`
 	Description sql.NullString
+	Metadata    ImageMetadata    `gorm:"type:jsonb;default:'{}';not null"`
+	Broken      bool             `gorm:"default:false;not null"`
+	Placeholder ImagePlaceholder `gorm:"embedded"`
+	Animation   ImageAnimation   `gorm:"embedded"`
+	Edit        ImageEdit        `gorm:"embedded;embeddedPrefix:edit_"`
+	FocalPointX sql.NullFloat64
+	FocalPointY sql.NullFloat64
 
 	// Relationships.
 	Folder     Folder      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:FolderID;references:ID"`
 	ImageSizes []ImageSize `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ImageID"`
+	ImageCrops []ImageCrop `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ImageID"`
 }
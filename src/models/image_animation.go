@@ -0,0 +1,13 @@
+package models
+
+// ImageAnimation describes the frames of an animated GIF or WebP image.
+type ImageAnimation struct {
+	FrameCount int  `gorm:"default:1;not null"`
+	Duration   int  `gorm:"default:0;not null"`
+	HasPoster  bool `gorm:"default:false;not null"`
+}
+
+// IsAnimated checks if the image has more than one frame.
+func (a *ImageAnimation) IsAnimated() bool {
+	return a.FrameCount > 1
+}
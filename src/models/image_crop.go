@@ -0,0 +1,17 @@
+package models
+
+import (
+	"api-file/main/src/enums"
+	"gorm.io/gorm"
+)
+
+type ImageCrop struct {
+	gorm.Model
+	ImageID uint       `gorm:"not null"`
+	Crop    enums.Crop `gorm:"not null;type:crop"`
+	Width   int        `gorm:"not null"`
+	Height  int        `gorm:"not null"`
+
+	// Relationships.
+	Image Image `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ImageID;references:ID"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// ImageEdit is the recipe of a non-destructive edit of an image. The original stays untouched,
+// the edited rendition and the derivatives are rendered from it.
+type ImageEdit struct {
+	Edited         bool    `gorm:"default:false;not null"`
+	CropLeft       int     `gorm:"default:0;not null"`
+	CropTop        int     `gorm:"default:0;not null"`
+	CropWidth      int     `gorm:"default:0;not null"`
+	CropHeight     int     `gorm:"default:0;not null"`
+	Rotation       int     `gorm:"default:0;not null"`
+	FlipHorizontal bool    `gorm:"default:false;not null"`
+	FlipVertical   bool    `gorm:"default:false;not null"`
+	Brightness     float64 `gorm:"default:0;not null"`
+	Contrast       float64 `gorm:"default:0;not null"`
+	Width          int     `gorm:"default:0;not null"`
+	Height         int     `gorm:"default:0;not null"`
+}
+
+// HasCrop checks if the edit crops the image.
+func (e *ImageEdit) HasCrop() bool {
+	return e.CropWidth > 0 && e.CropHeight > 0
+}
+
+// IsEmpty checks if the edit leaves the image as it is.
+func (e *ImageEdit) IsEmpty() bool {
+	return !e.HasCrop() && e.Rotation == 0 && !e.FlipHorizontal && !e.FlipVertical && e.Brightness == 0 && e.Contrast == 0
+}
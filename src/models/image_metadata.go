@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ImageMetadata is the camera metadata of an image, stored as JSON.
+type ImageMetadata struct {
+	TakenAt     *time.Time `json:"takenAt,omitempty"`
+	CameraMake  string     `json:"cameraMake,omitempty"`
+	CameraModel string     `json:"cameraModel,omitempty"`
+	Orientation int        `json:"orientation,omitempty"`
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+	Altitude    *float64   `json:"altitude,omitempty"`
+	Creator     string     `json:"creator,omitempty"`
+	Copyright   string     `json:"copyright,omitempty"`
+}
+
+func (m *ImageMetadata) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = ImageMetadata{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return errors.New("invalid image metadata")
+	}
+}
+
+func (m ImageMetadata) Value() (driver.Value, error) {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(value), nil
+}
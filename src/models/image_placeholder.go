@@ -0,0 +1,13 @@
+package models
+
+// ImagePlaceholder is shown by the frontends while the web size of an image loads.
+type ImagePlaceholder struct {
+	BlurHash      string `gorm:"default:'';not null"`
+	LQIP          string `gorm:"column:lqip;default:'';not null"`
+	DominantColor string `gorm:"default:'';not null"`
+}
+
+// IsEmpty checks if the placeholder is not generated yet.
+func (p *ImagePlaceholder) IsEmpty() bool {
+	return p.BlurHash == "" && p.LQIP == "" && p.DominantColor == ""
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"api-file/main/src/enums"
+	"time"
+)
+
+// Job is a background task of which the state is kept in the cache.
+// The job holds the files of the storage path it writes to while it runs.
+type Job struct {
+	ID               string          `json:"id"`
+	Type             enums.JobType   `json:"type"`
+	Status           enums.JobStatus `json:"status"`
+	AppStoragePathID uint            `json:"appStoragePathId,omitempty"`
+	Total            int             `json:"total"`
+	Done             int             `json:"done"`
+	Failed           int             `json:"failed"`
+	Error            string          `json:"error,omitempty"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+}
+
+// Progress returns the percentage of processed items of the job.
+func (j *Job) Progress() float64 {
+	if j.Total == 0 {
+		return 100.0
+	}
+
+	return float64(j.Done+j.Failed) * 100.0 / float64(j.Total)
+}
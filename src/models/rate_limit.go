@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RateLimit limits the requests, the uploaded bytes and the downloaded bytes of each client of an app
+// within a window, for a group of routes or for every group ("*"). Zero is unlimited.
+type RateLimit struct {
+	ID            uint   `gorm:"primaryKey"`
+	AppName       string `gorm:"not null;index:idx_rate_limit,unique,priority:1"`
+	RouteGroup    string `gorm:"not null;index:idx_rate_limit,unique,priority:2"`
+	Requests      int64  `gorm:"default:0;not null"`
+	UploadBytes   int64  `gorm:"default:0;not null"`
+	DownloadBytes int64  `gorm:"default:0;not null"`
+	Window        int    `gorm:"default:60;not null"`
+
+	// Relationships.
+	App App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppName;references:Name"`
+}
+
+// WindowDuration method to get the window of the rate limit.
+func (r *RateLimit) WindowDuration() time.Duration {
+	return time.Duration(r.Window) * time.Second
+}
+
+// IsUnlimited method to check if the rate limit does not limit anything.
+func (r *RateLimit) IsUnlimited() bool {
+	return r.Requests <= 0 && r.UploadBytes <= 0 && r.DownloadBytes <= 0
+}
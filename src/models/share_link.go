@@ -0,0 +1,46 @@
+package models
+
+import (
+	"api-file/main/src/enums"
+	"database/sql"
+	"time"
+)
+
+// ShareLink gives an external party access to an image, document or folder by its token.
+// The password is stored as a bcrypt hash.
+type ShareLink struct {
+	ID           uint   `gorm:"primaryKey"`
+	Token        string `gorm:"not null;uniqueIndex"`
+	ImageID      *uint
+	DocumentID   *uint
+	FolderID     *uint
+	PasswordHash sql.NullString
+	ExpiresAt    sql.NullTime
+	MaxDownloads sql.NullInt64
+	Downloads    int64 `gorm:"default:0;not null"`
+	RevokedAt    sql.NullTime
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	// Relationships.
+	Image    *Image    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ImageID;references:ID"`
+	Document *Document `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:DocumentID;references:ID"`
+	Folder   *Folder   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:FolderID;references:ID"`
+}
+
+// FileType returns the type of the item that is shared.
+func (s *ShareLink) FileType() enums.FileType {
+	switch {
+	case s.ImageID != nil:
+		return enums.Image
+	case s.DocumentID != nil:
+		return enums.Document
+	default:
+		return enums.Folder
+	}
+}
+
+// HasPassword checks if the share link is protected with a password.
+func (s *ShareLink) HasPassword() bool {
+	return s.PasswordHash.Valid
+}
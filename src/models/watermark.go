@@ -0,0 +1,48 @@
+package models
+
+import (
+	"api-file/main/src/enums"
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// Watermark describes the text or image that is drawn on the public renditions of a storage path.
+// Sizes lists the web sizes and crops that are watermarked, separated by commas, or is empty for all of them.
+type Watermark struct {
+	Text     string                  `gorm:"default:'';not null"`
+	Image    []byte                  `gorm:"type:bytea"`
+	Position enums.WatermarkPosition `gorm:"default:bottomRight;not null"`
+	Opacity  float64                 `gorm:"default:0.5;not null"`
+	Scale    float64                 `gorm:"default:0.25;not null"`
+	Sizes    string                  `gorm:"default:'';not null"`
+}
+
+// IsEnabled checks if the watermark has a text or an image.
+func (w *Watermark) IsEnabled() bool {
+	return w.Text != "" || len(w.Image) > 0
+}
+
+// SizeList returns the web sizes and crops that are watermarked, empty for all of them.
+func (w *Watermark) SizeList() []string {
+	if w.Sizes == "" {
+		return []string{}
+	}
+
+	return strings.Split(w.Sizes, ",")
+}
+
+// AppliesTo checks if the web size or crop with the name is watermarked.
+func (w *Watermark) AppliesTo(name string) bool {
+	return w.IsEnabled() && (w.Sizes == "" || slices.Contains(w.SizeList(), name))
+}
+
+// Equal checks if both watermarks render the same.
+func (w *Watermark) Equal(other *Watermark) bool {
+	return w.Text == other.Text &&
+		bytes.Equal(w.Image, other.Image) &&
+		w.Position == other.Position &&
+		w.Opacity == other.Opacity &&
+		w.Scale == other.Scale &&
+		w.Sizes == other.Sizes
+}
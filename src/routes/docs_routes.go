@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"api-file/main/src/controllers"
+	"api-file/main/src/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DocsRoutes func for describe group of routes of the OpenAPI document.
+func DocsRoutes(a *fiber.App) {
+	route := a.Group("/" + docs.Version)
+	route.Get("/openapi.json", controllers.GetOpenAPI)
+	route.Get("/docs", controllers.GetDocs)
+}
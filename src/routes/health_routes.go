@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"api-file/main/src/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthRoutes func for describe group of health routes for the orchestrator.
+func HealthRoutes(a *fiber.App) {
+	route := a.Group("/health")
+	route.Get("/live", controllers.GetLiveness)
+	route.Get("/ready", controllers.GetReadiness)
+}
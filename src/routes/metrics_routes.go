@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/ArnoldPMolenaar/api-utils/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsRoutes func for describe the route of the Prometheus metrics.
+func MetricsRoutes(a *fiber.App) {
+	a.Get("/metrics", middleware.MachineProtected(), adaptor.HTTPHandler(promhttp.Handler()))
+}
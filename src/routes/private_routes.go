@@ -2,6 +2,8 @@ package routes
 
 import (
 	"api-file/main/src/controllers"
+	appmiddleware "api-file/main/src/middleware"
+	"api-file/main/src/services"
 
 	"github.com/ArnoldPMolenaar/api-utils/middleware"
 	"github.com/gofiber/fiber/v2"
@@ -12,43 +14,79 @@ func PrivateRoutes(a *fiber.App) {
 	// Create private routes group.
 	route := a.Group("/v1")
 
-	// Register route for /v1/apps.
-	route.Post("/apps", middleware.MachineProtected(), controllers.CreateApp)
+	// Register routes for /v1/apps.
+	apps := route.Group("/apps", middleware.MachineProtected(), rateLimit("apps", appmiddleware.AppNameApp), appmiddleware.Idempotency())
+	apps.Post("/", controllers.CreateApp)
+	apps.Get("/:name/rate-limits", controllers.GetRateLimits)
+	apps.Put("/:name/rate-limits", controllers.UpdateRateLimits)
 
 	// Register CRU routes for /v1/storage-paths.
-	storagePaths := route.Group("/storage-paths", middleware.MachineProtected())
+	storagePaths := route.Group("/storage-paths", middleware.MachineProtected(), rateLimit("storage-paths", appmiddleware.ItemApp(services.GetAppStoragePathAppName)), appmiddleware.Idempotency())
 	storagePaths.Get("/", controllers.GetStoragePaths)
 	storagePaths.Post("/", controllers.CreateStoragePath)
 	storagePaths.Get("/id", controllers.GetStoragePathIDByApp)
 	storagePaths.Get("/:id", controllers.GetStoragePath)
 	storagePaths.Put("/:id", controllers.UpdateStoragePath)
+	storagePaths.Get("/:id/fsck", controllers.CheckStoragePath)
+	storagePaths.Post("/:id/fsck", controllers.RepairStoragePath)
 
 	// Register CRUD routes for /v1/folders.
-	folders := route.Group("/folders", middleware.MachineProtected())
+	folders := route.Group("/folders", middleware.MachineProtected(), rateLimit("folders", appmiddleware.ItemApp(services.GetFolderAppName)), appmiddleware.Idempotency())
 	folders.Post("/", controllers.CreateFolder)
 	folders.Get("/:id", controllers.GetFolder)
 	folders.Put("/:id", controllers.UpdateFolder)
 	folders.Delete("/:id", controllers.DeleteFolder)
 	folders.Put("/:id/restore", controllers.RestoreFolder)
+	folders.Post("/:id/copy", controllers.CopyFolder)
 
 	// Register CRUD routes for /v1/images.
-	images := route.Group("/images", middleware.MachineProtected())
+	images := route.Group("/images", middleware.MachineProtected(), rateLimit("images", appmiddleware.ItemApp(services.GetImageAppName)), appmiddleware.Idempotency())
 	images.Post("/", controllers.CreateImage)
+	images.Post("/regenerate", controllers.RegenerateImages)
+	images.Post("/placeholders", controllers.GenerateImagePlaceholders)
+	images.Put("/move", controllers.MoveImages)
 	images.Get("/:id", controllers.GetImage)
 	images.Put("/:id", controllers.UpdateImage)
 	images.Delete("/:id", controllers.DeleteImage)
 	images.Delete("/:id/hard", controllers.DeleteImageHard)
 	images.Put("/:id/restore", controllers.RestoreImage)
+	images.Get("/:id/original", controllers.GetImageFileOriginal)
+	images.Put("/:id/edit", controllers.EditImage)
+	images.Delete("/:id/edit", controllers.RevertImageEdit)
+	images.Post("/:id/copy", controllers.CopyImage)
+	images.Put("/:id/move", controllers.MoveImage)
 
 	// Register CRUD routes for /v1/documents.
-	documents := route.Group("/documents", middleware.MachineProtected())
+	documents := route.Group("/documents", middleware.MachineProtected(), rateLimit("documents", appmiddleware.ItemApp(services.GetDocumentAppName)), appmiddleware.Idempotency())
 	documents.Post("/", controllers.CreateDocument)
+	documents.Put("/move", controllers.MoveDocuments)
 	documents.Get("/:id", controllers.GetDocument)
 	documents.Put("/:id", controllers.UpdateDocument)
 	documents.Delete("/:id", controllers.DeleteDocument)
 	documents.Delete("/:id/hard", controllers.DeleteDocumentHard)
 	documents.Put("/:id/restore", controllers.RestoreDocument)
+	documents.Post("/:id/copy", controllers.CopyDocument)
+	documents.Put("/:id/move", controllers.MoveDocument)
+
+	// Register CRUD routes for /v1/share-links.
+	shareLinks := route.Group("/share-links", middleware.MachineProtected(), rateLimit("share-links", appmiddleware.ItemApp(services.GetShareLinkAppNameByID)), appmiddleware.Idempotency())
+	shareLinks.Post("/", controllers.CreateShareLink)
+	shareLinks.Get("/:id", controllers.GetShareLink)
+	shareLinks.Put("/:id", controllers.UpdateShareLink)
+	shareLinks.Delete("/:id", controllers.RevokeShareLink)
+
+	// Register route for /v1/batch.
+	route.Post("/batch", middleware.MachineProtected(), rateLimit("batch", appmiddleware.BodyApp), appmiddleware.Idempotency(), controllers.RunBatch)
+
+	// Register routes for /v1/jobs.
+	jobs := route.Group("/jobs", middleware.MachineProtected(), rateLimit("jobs", appmiddleware.JobApp))
+	jobs.Get("/:id", controllers.GetJob)
 
 	// Register handshake route for websocket.
-	route.Get("/handshake", middleware.MachineProtected(), controllers.Handshake)
+	route.Get("/handshake", middleware.MachineProtected(), rateLimit("handshake", appmiddleware.StoragePathQueryApp), controllers.Handshake)
+}
+
+// rateLimit limits the requests of the apps to the group of private routes, the app is found by the item of the request.
+func rateLimit(group string, app appmiddleware.AppResolver) fiber.Handler {
+	return appmiddleware.RateLimit(group, app)
 }
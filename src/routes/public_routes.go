@@ -2,6 +2,7 @@ package routes
 
 import (
 	"api-file/main/src/controllers"
+	"api-file/main/src/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -11,11 +12,26 @@ func PublicRoutes(a *fiber.App) {
 	route := a.Group("/v1")
 
 	// Register CRUD routes for /v1/image.
+	// The rate limits need the params of the route, so they are added to each route.
 	image := route.Group("/image")
-	image.Get("/:id", controllers.GetImageFile)
-	image.Get("/:id/:size", controllers.GetImageFileSize)
+	imageLimit := middleware.RateLimit("image", middleware.ImageApp)
+	image.Get("/:id", imageLimit, controllers.GetImageFile)
+	image.Get("/:id/poster", imageLimit, controllers.GetImageFilePoster)
+	image.Get("/:id/:size", imageLimit, controllers.GetImageFileSize)
+	image.Get("/:id/crop/:crop", imageLimit, controllers.GetImageFileCrop)
 
 	// Register CRUD routes for /v1/document.
 	document := route.Group("/document")
-	document.Get("/:id", controllers.GetDocumentFile)
+	documentLimit := middleware.RateLimit("document", middleware.DocumentApp)
+	document.Get("/:id", documentLimit, controllers.GetDocumentFile)
+	document.Get("/:id/thumbnail", documentLimit, controllers.GetDocumentThumbnail)
+	document.Get("/:id/preview/:page", documentLimit, controllers.GetDocumentPreview)
+
+	// Register routes for /v1/share.
+	share := route.Group("/share")
+	shareLimit := middleware.RateLimit("share", middleware.ShareLinkApp)
+	share.Get("/:token", shareLimit, controllers.GetSharedFile)
+	share.Get("/:token/zip", shareLimit, controllers.GetSharedFolderZip)
+	share.Get("/:token/image/:id", shareLimit, controllers.GetSharedFolderImage)
+	share.Get("/:token/document/:id", shareLimit, controllers.GetSharedFolderDocument)
 }
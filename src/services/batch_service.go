@@ -0,0 +1,288 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Errors of the operations of a batch.
+var (
+	ErrBatchItemMissing   = errors.New("item does not exist")
+	ErrBatchFolderMissing = errors.New("target folder does not exist")
+	ErrBatchImmutable     = errors.New("folder is immutable")
+	ErrBatchConflict      = errors.New("item already exists in the target folder")
+	ErrBatchStorageFull   = errors.New("storage path of the target folder is full")
+	ErrBatchUnsupported   = errors.New("operation is not supported for the type")
+	ErrBatchRolledBack    = errors.New("operation is rolled back, because another operation failed")
+)
+
+// errBatchFailed stops the transaction of an atomic batch when an operation fails.
+var errBatchFailed = errors.New("batch operation failed")
+
+// batchCommit keeps the moved files and the work after the transaction of the operations that succeeded.
+type batchCommit struct {
+	files []*storageFileMove
+	after []func()
+}
+
+// RunBatch method to run the operations in one transaction. An operation that fails only rolls back itself,
+// or every operation when the batch is atomic. The moved files are put back with the operations.
+// It returns the error of each operation, which is nil when it succeeded.
+func RunBatch(items []models.BatchItem, atomic bool) ([]error, error) {
+	results := make([]error, len(items))
+	batch := batchCommit{}
+
+	err := database.Pg.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			// Each operation gets a savepoint, so a failing operation does not break the transaction.
+			operation := batchCommit{}
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				return runBatchItem(tx, &items[i], &operation)
+			}); err != nil {
+				operation.rollback()
+				results[i] = err
+				if atomic {
+					return errBatchFailed
+				}
+				continue
+			}
+
+			batch.files = append(batch.files, operation.files...)
+			batch.after = append(batch.after, operation.after...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		batch.rollback()
+		if !errors.Is(err, errBatchFailed) {
+			return nil, err
+		}
+
+		for i := range results {
+			if results[i] == nil {
+				results[i] = ErrBatchRolledBack
+			}
+		}
+
+		return results, nil
+	}
+	batch.commit()
+
+	return results, nil
+}
+
+// runBatchItem runs an operation with the query.
+func runBatchItem(tx *gorm.DB, item *models.BatchItem, batch *batchCommit) error {
+	switch item.Operation {
+	case enums.BatchDelete:
+		return deleteBatchItem(tx, item, batch)
+	case enums.BatchRestore:
+		return restoreBatchItem(tx, item)
+	case enums.BatchMove:
+		return moveBatchItem(tx, item, batch)
+	case enums.BatchUpdate:
+		return updateBatchItem(tx, item)
+	default:
+		return ErrBatchUnsupported
+	}
+}
+
+// deleteBatchItem deletes the image, document or folder, which can be restored.
+func deleteBatchItem(tx *gorm.DB, item *models.BatchItem, batch *batchCommit) error {
+	switch item.Type {
+	case enums.Image:
+		image := models.Image{}
+		if result := tx.Preload("ImageSizes").Preload("ImageCrops").Find(&image, "id = ?", item.ID); result.Error != nil {
+			return result.Error
+		} else if image.ID == 0 {
+			return ErrBatchItemMissing
+		}
+
+		if err := deleteImage(tx, &image, false); err != nil {
+			return err
+		}
+		batch.after = append(batch.after, func() { DeleteImageFilesFromCache(&image) })
+
+		return nil
+	case enums.Document:
+		document := models.Document{}
+		if result := tx.Find(&document, "id = ?", item.ID); result.Error != nil {
+			return result.Error
+		} else if document.ID == 0 {
+			return ErrBatchItemMissing
+		}
+
+		return tx.Delete(&document).Error
+	default:
+		folder := models.Folder{}
+		if result := tx.Find(&folder, "id = ?", item.ID); result.Error != nil {
+			return result.Error
+		} else if folder.ID == 0 {
+			return ErrBatchItemMissing
+		} else if folder.Immutable {
+			return ErrBatchImmutable
+		}
+
+		return tx.Delete(&folder).Error
+	}
+}
+
+// restoreBatchItem restores the deleted image, document or folder.
+func restoreBatchItem(tx *gorm.DB, item *models.BatchItem) error {
+	var model interface{}
+	switch item.Type {
+	case enums.Image:
+		model = &models.Image{}
+	case enums.Document:
+		model = &models.Document{}
+	default:
+		model = &models.Folder{}
+	}
+
+	result := tx.Model(model).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", item.ID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrBatchItemMissing
+	}
+
+	return nil
+}
+
+// moveBatchItem moves the image or document into the folder. An item that is already in the folder stays as it is.
+func moveBatchItem(tx *gorm.DB, item *models.BatchItem, batch *batchCommit) error {
+	if item.Type == enums.Folder {
+		return ErrBatchUnsupported
+	}
+
+	target := models.Folder{}
+	if result := tx.Preload("AppStoragePath").Find(&target, "id = ?", item.FolderID); result.Error != nil {
+		return result.Error
+	} else if target.ID == 0 {
+		return ErrBatchFolderMissing
+	}
+
+	if item.Type == enums.Image {
+		images := make([]models.Image, 0, 1)
+		if result := tx.Preload("Folder").Preload("Folder.AppStoragePath").Preload("ImageSizes").Preload("ImageCrops").
+			Find(&images, "id = ?", item.ID); result.Error != nil {
+			return result.Error
+		} else if len(images) == 0 {
+			return ErrBatchItemMissing
+		} else if images[0].FolderID == target.ID {
+			return nil
+		}
+
+		if exists, err := isImageAvailable(tx, target.ID, images[0].Name, images[0].Extension); err != nil {
+			return err
+		} else if exists {
+			return ErrBatchConflict
+		}
+		if err := checkBatchStorageSpace(&images[0].Folder, &target, int64(images[0].Size)); err != nil {
+			return err
+		}
+
+		files, err := moveImages(tx, images, &target)
+		if err != nil {
+			return err
+		}
+		batch.files = append(batch.files, files)
+		batch.after = append(batch.after, func() { _ = imagesMoved(images, &target) })
+
+		return nil
+	}
+
+	documents := make([]models.Document, 0, 1)
+	if result := tx.Preload("Folder").Preload("Folder.AppStoragePath").Find(&documents, "id = ?", item.ID); result.Error != nil {
+		return result.Error
+	} else if len(documents) == 0 {
+		return ErrBatchItemMissing
+	} else if documents[0].FolderID == target.ID {
+		return nil
+	}
+
+	if exists, err := isDocumentAvailable(tx, target.ID, documents[0].Name, documents[0].Extension); err != nil {
+		return err
+	} else if exists {
+		return ErrBatchConflict
+	}
+	if err := checkBatchStorageSpace(&documents[0].Folder, &target, int64(documents[0].Size)); err != nil {
+		return err
+	}
+
+	files, err := moveDocuments(tx, documents, &target)
+	if err != nil {
+		return err
+	}
+	batch.files = append(batch.files, files)
+	batch.after = append(batch.after, func() { documentsMoved(documents, &target) })
+
+	return nil
+}
+
+// updateBatchItem updates the description of the image or the color of the folder.
+func updateBatchItem(tx *gorm.DB, item *models.BatchItem) error {
+	var result *gorm.DB
+	switch {
+	case item.Type == enums.Image && item.Description != nil:
+		result = tx.Model(&models.Image{}).Where("id = ?", item.ID).
+			Update("description", sql.NullString{Valid: true, String: *item.Description})
+	case item.Type == enums.Folder && item.Color != nil:
+		result = tx.Model(&models.Folder{}).Where("id = ?", item.ID).Update("color", *item.Color)
+	default:
+		return ErrBatchUnsupported
+	}
+
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrBatchItemMissing
+	}
+
+	return nil
+}
+
+// checkBatchStorageSpace checks if the storage path of the target folder has space for an item of another storage path.
+func checkBatchStorageSpace(source, target *models.Folder, size int64) error {
+	if source.AppStoragePathID == target.AppStoragePathID {
+		return nil
+	}
+
+	if available, err := IsStorageSpaceAvailableFor(target.AppStoragePathID, size); err != nil {
+		return err
+	} else if !available {
+		return ErrBatchStorageFull
+	}
+
+	return nil
+}
+
+// rollback puts the moved files back in reverse order.
+func (b *batchCommit) rollback() {
+	for i := len(b.files) - 1; i >= 0; i-- {
+		b.files[i].rollback()
+	}
+	b.files = nil
+	b.after = nil
+}
+
+// commit removes the sources of the copied files and runs the work after the transaction.
+func (b *batchCommit) commit() {
+	for _, files := range b.files {
+		files.commit()
+	}
+	for _, after := range b.after {
+		after()
+	}
+	b.files = nil
+	b.after = nil
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// CopyImage method to copy an image with its web sizes, crops and poster into the target folder.
+// The image needs its folder, sizes and crops and the target folder its storage path.
+// It reports false when the copy is skipped by the conflict strategy, with the existing image.
+// A copy into a storage path with another watermark gets its public renditions rendered again.
+func CopyImage(image *models.Image, target *models.Folder, name string, conflict enums.ConflictStrategy) (models.Image, bool, error) {
+	name, existing, err := resolveImageConflict(image, target.ID, name, conflict)
+	if err != nil {
+		return models.Image{}, false, err
+	} else if existing != nil {
+		return *existing, false, nil
+	}
+
+	sourcePath, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return models.Image{}, false, err
+	}
+	targetPath, err := GetPath(&target.AppStoragePath, target.ID)
+	if err != nil {
+		return models.Image{}, false, err
+	}
+	if err := os.MkdirAll(targetPath, os.ModePerm); err != nil {
+		return models.Image{}, false, err
+	}
+
+	imageCopy := models.Image{
+		FolderID:    target.ID,
+		Name:        name,
+		Extension:   image.Extension,
+		MimeType:    image.MimeType,
+		Size:        image.Size,
+		Width:       image.Width,
+		Height:      image.Height,
+		Description: image.Description,
+		Metadata:    image.Metadata,
+		Broken:      image.Broken,
+		Placeholder: image.Placeholder,
+		Animation:   image.Animation,
+		Edit:        image.Edit,
+		FocalPointX: image.FocalPointX,
+		FocalPointY: image.FocalPointY,
+	}
+
+	files := storageFileCopy{source: &image.Folder.AppStoragePath, target: &target.AppStoragePath}
+	if err := files.copyImageFiles(image, &imageCopy, sourcePath, targetPath); err != nil {
+		files.rollback()
+		return models.Image{}, false, err
+	}
+
+	if result := database.Pg.Create(&imageCopy); result.Error != nil {
+		files.rollback()
+		return models.Image{}, false, result.Error
+	}
+	imageCopy.Folder = *target
+
+	if err := rewatermarkImage(&imageCopy, &image.Folder.AppStoragePath); err != nil {
+		return imageCopy, true, err
+	}
+
+	return imageCopy, true, nil
+}
+
+// CopyDocument method to copy a document with its previews into the target folder.
+// The document needs its folder and the target folder its storage path.
+// It reports false when the copy is skipped by the conflict strategy, with the existing document.
+func CopyDocument(document *models.Document, target *models.Folder, name string, conflict enums.ConflictStrategy) (models.Document, bool, error) {
+	name, existing, err := resolveDocumentConflict(document, target.ID, name, conflict)
+	if err != nil {
+		return models.Document{}, false, err
+	} else if existing != nil {
+		return *existing, false, nil
+	}
+
+	sourcePath, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return models.Document{}, false, err
+	}
+	targetPath, err := GetPath(&target.AppStoragePath, target.ID)
+	if err != nil {
+		return models.Document{}, false, err
+	}
+	if err := os.MkdirAll(targetPath, os.ModePerm); err != nil {
+		return models.Document{}, false, err
+	}
+
+	documentCopy := models.Document{
+		FolderID:      target.ID,
+		Name:          name,
+		Extension:     document.Extension,
+		MimeType:      document.MimeType,
+		Size:          document.Size,
+		Broken:        document.Broken,
+		PageCount:     document.PageCount,
+		HasPreview:    document.HasPreview,
+		ScanStatus:    document.ScanStatus,
+		ScanSignature: document.ScanSignature,
+		ScannedAt:     document.ScannedAt,
+	}
+
+	files := storageFileCopy{source: &document.Folder.AppStoragePath, target: &target.AppStoragePath}
+	if err := files.copyDocumentFiles(document, &documentCopy, sourcePath, targetPath); err != nil {
+		files.rollback()
+		return models.Document{}, false, err
+	}
+
+	if result := database.Pg.Create(&documentCopy); result.Error != nil {
+		files.rollback()
+		return models.Document{}, false, result.Error
+	}
+	documentCopy.Folder = *target
+
+	return documentCopy, true, nil
+}
+
+// CopyFolder method to copy the images, documents and sub folders of the source folder into
+// the target folder as the work of a job. Sub folders that already exist in the target are merged.
+// A failing file does not stop the job. The onProgress callback is called after each file.
+func CopyFolder(job *models.Job, source, target *models.Folder, conflict enums.ConflictStrategy, onProgress func(fileType enums.FileType, filename string)) error {
+	folder, folders, err := GetFolder(source.ID, true)
+	if err != nil {
+		return err
+	}
+	folder.AppStoragePath = source.AppStoragePath
+
+	for i := range folder.Images {
+		image := &folder.Images[i]
+		image.Folder = *folder
+
+		if _, _, err := CopyImage(image, target, image.Name, conflict); err != nil {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: %v", image.ID, err)
+		} else {
+			job.Done++
+		}
+
+		if err := SaveJob(job); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(enums.Image, fmt.Sprintf("%s.%s", image.Name, image.Extension))
+		}
+	}
+
+	for i := range folder.Documents {
+		document := &folder.Documents[i]
+		document.Folder = *folder
+
+		if _, _, err := CopyDocument(document, target, document.Name, conflict); err != nil {
+			job.Failed++
+			job.Error = fmt.Sprintf("document %d: %v", document.ID, err)
+		} else {
+			job.Done++
+		}
+
+		if err := SaveJob(job); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(enums.Document, fmt.Sprintf("%s.%s", document.Name, document.Extension))
+		}
+	}
+
+	for _, child := range folders {
+		child.AppStoragePath = source.AppStoragePath
+
+		childTarget, err := GetChildFolder(target.AppStoragePathID, child.Name, target.ID)
+		if err != nil {
+			return err
+		}
+		if childTarget.ID == 0 {
+			// A deleted folder with the same name keeps its directory, so its files can not be merged.
+			if exists, err := IsFolderAvailable(target.AppStoragePathID, child.Name, "", target.ID); err != nil {
+				return err
+			} else if exists {
+				count, _, err := GetFolderFilesSize(child.AppStoragePathID, child.ID)
+				if err != nil {
+					return err
+				}
+				job.Failed += count
+				job.Error = fmt.Sprintf("folder %d: %s is deleted in the target folder", child.ID, child.Name)
+				if err := SaveJob(job); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if childTarget, err = CreateFolder(target.AppStoragePathID, child.Name, child.Color, false, target.ID); err != nil {
+				return err
+			}
+		}
+		childTarget.AppStoragePath = target.AppStoragePath
+
+		if err := CopyFolder(job, child, childTarget, conflict, onProgress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsFolderInside method to check if the folder is the parent folder or one of its descendants.
+func IsFolderInside(folder, parent *models.Folder) (bool, error) {
+	if folder.ID == parent.ID {
+		return true, nil
+	} else if folder.AppStoragePathID != parent.AppStoragePathID {
+		return false, nil
+	}
+
+	ids, err := GetFolderDescendantIDs(parent.AppStoragePathID, parent.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == folder.ID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CopyName method to get the first name that is not taken, like "name (2)".
+func CopyName(name string, exists func(name string) (bool, error)) (string, error) {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if taken, err := exists(candidate); err != nil {
+			return "", err
+		} else if !taken {
+			return candidate, nil
+		}
+	}
+}
+
+// resolveImageConflict gets the name of the copy of the image in the target folder by the conflict strategy.
+// The existing image is returned when the copy is skipped. An overwritten image is deleted.
+func resolveImageConflict(image *models.Image, folderID uint, name string, conflict enums.ConflictStrategy) (string, *models.Image, error) {
+	existing := models.Image{}
+	if result := database.Pg.
+		Unscoped().
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Limit(1).
+		Find(&existing, "folder_id = ? AND name = ? AND extension = ?", folderID, name, image.Extension); result.Error != nil {
+		return "", nil, result.Error
+	} else if existing.ID == 0 {
+		return name, nil, nil
+	}
+
+	switch conflict {
+	case enums.ConflictRename:
+		name, err := CopyName(name, func(name string) (bool, error) {
+			return IsImageAvailable(folderID, name, image.Extension)
+		})
+		return name, nil, err
+	case enums.ConflictSkip:
+		return "", &existing, nil
+	case enums.ConflictOverwrite:
+		// An image can not overwrite itself.
+		if existing.ID == image.ID {
+			return "", &existing, nil
+		}
+		if err := DeleteImage(&existing, true); err != nil {
+			return "", nil, err
+		}
+		if err := DeleteImageFiles(&existing); err != nil {
+			return "", nil, err
+		}
+		DeleteImageFilesFromCache(&existing)
+		return name, nil, nil
+	}
+
+	return "", nil, fmt.Errorf("image %s.%s already exists", name, image.Extension)
+}
+
+// resolveDocumentConflict gets the name of the copy of the document in the target folder by the conflict strategy.
+// The existing document is returned when the copy is skipped. An overwritten document is deleted.
+func resolveDocumentConflict(document *models.Document, folderID uint, name string, conflict enums.ConflictStrategy) (string, *models.Document, error) {
+	existing := models.Document{}
+	if result := database.Pg.
+		Unscoped().
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Limit(1).
+		Find(&existing, "folder_id = ? AND name = ? AND extension = ?", folderID, name, document.Extension); result.Error != nil {
+		return "", nil, result.Error
+	} else if existing.ID == 0 {
+		return name, nil, nil
+	}
+
+	switch conflict {
+	case enums.ConflictRename:
+		name, err := CopyName(name, func(name string) (bool, error) {
+			return IsDocumentAvailable(folderID, name, document.Extension)
+		})
+		return name, nil, err
+	case enums.ConflictSkip:
+		return "", &existing, nil
+	case enums.ConflictOverwrite:
+		// A document can not overwrite itself.
+		if existing.ID == document.ID {
+			return "", &existing, nil
+		}
+		if err := DeleteDocument(&existing, true); err != nil {
+			return "", nil, err
+		}
+		if err := DeleteDocumentFile(&existing); err != nil {
+			return "", nil, err
+		}
+		DeleteDocumentFilesFromCache(&existing)
+		return name, nil, nil
+	}
+
+	return "", nil, fmt.Errorf("document %s.%s already exists", name, document.Extension)
+}
+
+// storageFileCopy copies files between storage paths and keeps them,
+// so they can be removed again when the copy fails.
+type storageFileCopy struct {
+	source *models.AppStoragePath
+	target *models.AppStoragePath
+	files  []string
+}
+
+// copyImageFiles copies the original, edited and watermarked renditions, web sizes, crops and poster of the image
+// and adds the sizes and crops to the copy.
+func (f *storageFileCopy) copyImageFiles(image, imageCopy *models.Image, sourcePath, targetPath string) error {
+	if err := f.copy(ImageFilePath(sourcePath, image), ImageFilePath(targetPath, imageCopy)); err != nil {
+		return err
+	}
+
+	for _, size := range image.ImageSizes {
+		if err := f.copy(ImageSizeFilePath(sourcePath, image.Name, size.Size), ImageSizeFilePath(targetPath, imageCopy.Name, size.Size)); err != nil {
+			return err
+		}
+		imageCopy.ImageSizes = append(imageCopy.ImageSizes, models.ImageSize{Size: size.Size, Width: size.Width, Height: size.Height})
+	}
+
+	for _, crop := range image.ImageCrops {
+		if err := f.copy(ImageCropFilePath(sourcePath, image.Name, crop.Crop), ImageCropFilePath(targetPath, imageCopy.Name, crop.Crop)); err != nil {
+			return err
+		}
+		imageCopy.ImageCrops = append(imageCopy.ImageCrops, models.ImageCrop{Crop: crop.Crop, Width: crop.Width, Height: crop.Height})
+	}
+
+	if image.Animation.HasPoster {
+		if err := f.copy(ImagePosterFilePath(sourcePath, image.Name), ImagePosterFilePath(targetPath, imageCopy.Name)); err != nil {
+			return err
+		}
+	}
+	if image.Edit.Edited {
+		if err := f.copy(ImageEditedFilePath(sourcePath, image.Name), ImageEditedFilePath(targetPath, imageCopy.Name)); err != nil {
+			return err
+		}
+	}
+
+	return f.copyIfExists(ImageWatermarkedFilePath(sourcePath, image.Name), ImageWatermarkedFilePath(targetPath, imageCopy.Name))
+}
+
+// copyDocumentFiles copies the document with its thumbnail, converted PDF and the pages that are rendered.
+func (f *storageFileCopy) copyDocumentFiles(document, documentCopy *models.Document, sourcePath, targetPath string) error {
+	if err := f.copy(DocumentFilePath(sourcePath, document), DocumentFilePath(targetPath, documentCopy)); err != nil {
+		return err
+	}
+
+	if !document.HasPreview {
+		return nil
+	}
+
+	if err := f.copyIfExists(DocumentThumbnailFilePath(sourcePath, document), DocumentThumbnailFilePath(targetPath, documentCopy)); err != nil {
+		return err
+	}
+	if err := f.copyIfExists(DocumentPdfFilePath(sourcePath, document), DocumentPdfFilePath(targetPath, documentCopy)); err != nil {
+		return err
+	}
+	for page := 1; page <= document.PageCount; page++ {
+		if err := f.copyIfExists(DocumentPreviewFilePath(sourcePath, document, page), DocumentPreviewFilePath(targetPath, documentCopy, page)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copy copies a file of the source storage path to the target storage path. The file is linked
+// when it is stored the same way in both, otherwise it is decrypted and written again,
+// so it is encrypted with the data key of the target storage path.
+func (f *storageFileCopy) copy(sourceFilePath, targetFilePath string) error {
+	encrypted, err := IsEncryptedFile(sourceFilePath)
+	if err != nil {
+		return err
+	}
+
+	if encrypted == f.target.Encrypted && (!encrypted || f.source.ID == f.target.ID) {
+		err = LinkFile(sourceFilePath, targetFilePath)
+	} else {
+		var data []byte
+		if data, err = ReadStorageFile(sourceFilePath); err == nil {
+			err = WriteStorageFile(f.target, targetFilePath, data, nil)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	f.files = append(f.files, targetFilePath)
+
+	return nil
+}
+
+// copyIfExists copies a file that is generated on demand and can be missing.
+func (f *storageFileCopy) copyIfExists(sourceFilePath, targetFilePath string) error {
+	if _, err := os.Stat(sourceFilePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return f.copy(sourceFilePath, targetFilePath)
+}
+
+// rollback removes the files that are copied.
+func (f *storageFileCopy) rollback() {
+	for _, filePath := range f.files {
+		_ = RemoveFile(filePath)
+	}
+	f.files = nil
+}
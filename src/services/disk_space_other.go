@@ -0,0 +1,8 @@
+//go:build !unix
+
+package services
+
+// diskSpace is only supported on Unix systems, elsewhere the free space is not checked.
+func diskSpace(path string) (free, total uint64, err error) {
+	return 0, 0, ErrDiskSpaceUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package services
+
+import "golang.org/x/sys/unix"
+
+// diskSpace gets the free space for unprivileged users and the total space of the file system of the path.
+func diskSpace(path string) (free, total uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), uint64(stat.Blocks) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"api-file/main/src/metrics"
+	"api-file/main/src/models"
+	"context"
+	"fmt"
+	"os"
+)
+
+// DocumentFilePath method to get the file path of the document.
+func DocumentFilePath(path string, document *models.Document) string {
+	return fmt.Sprintf("%s%s.%s", path, document.Name, document.Extension)
+}
+
+// UploadDocumentFile method to write the document to the storage path.
+// The filename includes the extension.
+func UploadDocumentFile(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, onProgress func(percentage float64)) error {
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := WriteStorageFileContext(ctx, appStoragePath, path+filename, data, onProgress); err != nil {
+		return err
+	}
+	metrics.BytesUploaded.WithLabelValues(appStoragePath.AppName).Add(float64(len(data)))
+
+	return nil
+}
+
+// DeleteDocumentFile method to delete the document and its previews from the storage path.
+// A file that is already missing is skipped.
+func DeleteDocumentFile(document *models.Document) error {
+	path, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return err
+	}
+
+	if err := DeleteDocumentPreviewFiles(path, document); err != nil {
+		return err
+	}
+
+	return RemoveFile(DocumentFilePath(path, document))
+}
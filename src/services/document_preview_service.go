@@ -0,0 +1,248 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	documentThumbnailWidth   = 400
+	documentPreviewWidth     = 1280
+	documentConverterTimeout = time.Minute
+)
+
+// DocumentThumbnailCacheSuffix is the cache key suffix of the thumbnail of a document.
+const DocumentThumbnailCacheSuffix = "thumbnail"
+
+// ErrDocumentConverterMissing is returned when an office document can not be
+// converted, because the headless converter is not installed.
+var ErrDocumentConverterMissing = errors.New("document converter is not available")
+
+// officeMimeTypes are the documents that are converted to a PDF before their pages are rendered.
+var officeMimeTypes = map[string]bool{
+	"application/msword": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"application/vnd.ms-excel": true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.ms-powerpoint":                                             true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/rtf": true,
+	"text/plain":      true,
+	"application/vnd.oasis.opendocument.text":         true,
+	"application/vnd.oasis.opendocument.spreadsheet":  true,
+	"application/vnd.oasis.opendocument.presentation": true,
+}
+
+// DocumentThumbnailFilePath method to get the file path of the first page thumbnail of the document.
+func DocumentThumbnailFilePath(path string, document *models.Document) string {
+	return fmt.Sprintf("%s%s.%s.thumbnail.webp", path, document.Name, document.Extension)
+}
+
+// DocumentPreviewFilePath method to get the file path of a rendered page of the document.
+func DocumentPreviewFilePath(path string, document *models.Document, page int) string {
+	return fmt.Sprintf("%s%s.%s.page-%d.webp", path, document.Name, document.Extension, page)
+}
+
+// DocumentPdfFilePath method to get the file path of the PDF that an office document is converted to.
+func DocumentPdfFilePath(path string, document *models.Document) string {
+	return fmt.Sprintf("%s%s.%s.preview.pdf", path, document.Name, document.Extension)
+}
+
+// DocumentPreviewCacheSuffix method to get the cache key suffix of a rendered page.
+func DocumentPreviewCacheSuffix(page int) string {
+	return fmt.Sprintf("page-%d", page)
+}
+
+// IsPdfDocument method to check if the document is a PDF.
+func IsPdfDocument(mimeType string) bool {
+	return strings.EqualFold(mimeType, "application/pdf")
+}
+
+// IsOfficeDocument method to check if the document is converted to a PDF for its previews.
+func IsOfficeDocument(mimeType string) bool {
+	return officeMimeTypes[strings.ToLower(mimeType)]
+}
+
+// GenerateDocumentPreview method to count the pages of a document and to render the thumbnail
+// of its first page. Office documents are converted to a PDF with the headless converter first,
+// which is stored next to the document so the pages can be rendered later.
+// The page count and the preview state are saved, also when the preview fails.
+func GenerateDocumentPreview(appStoragePath *models.AppStoragePath, document *models.Document) error {
+	pageCount, hasPreview := 0, false
+	defer func() {
+		if result := database.Pg.Model(document).Unscoped().Updates(map[string]interface{}{
+			"page_count":  pageCount,
+			"has_preview": hasPreview,
+		}); result.Error == nil {
+			document.PageCount = pageCount
+			document.HasPreview = hasPreview
+		}
+	}()
+
+	if !IsPdfDocument(document.MimeType) && !IsOfficeDocument(document.MimeType) || document.ScanStatus == enums.Infected {
+		return nil
+	}
+
+	path, err := GetPath(appStoragePath, document.FolderID)
+	if err != nil {
+		return err
+	}
+
+	data, err := ReadStorageFile(DocumentFilePath(path, document))
+	if err != nil {
+		return err
+	}
+
+	if IsOfficeDocument(document.MimeType) {
+		if data, err = convertDocumentToPdf(data, document.Extension); err != nil {
+			return err
+		}
+		if err := WriteStorageFile(appStoragePath, DocumentPdfFilePath(path, document), data, nil); err != nil {
+			return err
+		}
+	}
+
+	pages, err := countPages(data)
+	if err != nil {
+		return err
+	}
+
+	thumbnail, _, _, err := thumbnailWebp(data, documentThumbnailWidth, 0, "page=0", true)
+	if err != nil {
+		return err
+	}
+	if err := WriteStorageFile(appStoragePath, DocumentThumbnailFilePath(path, document), thumbnail, nil); err != nil {
+		return err
+	}
+	_ = DeleteDocumentFromCache(document.ID, DocumentThumbnailCacheSuffix)
+
+	pageCount, hasPreview = pages, true
+
+	return nil
+}
+
+// GetDocumentPreviewFile method to get the file path of a rendered page of the document.
+// Pages are rendered on the first request and kept on disk afterwards.
+func GetDocumentPreviewFile(document *models.Document, page int) (string, error) {
+	path, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := DocumentPreviewFilePath(path, document, page)
+	if _, err := os.Stat(filePath); err == nil {
+		return filePath, nil
+	}
+
+	source := DocumentFilePath(path, document)
+	if IsOfficeDocument(document.MimeType) {
+		source = DocumentPdfFilePath(path, document)
+	}
+	data, err := ReadStorageFile(source)
+	if err != nil {
+		return "", err
+	}
+
+	preview, _, _, err := thumbnailWebp(data, documentPreviewWidth, 0, fmt.Sprintf("page=%d", page-1), true)
+	if err != nil {
+		return "", err
+	}
+	if preview, err = EncryptData(&document.Folder.AppStoragePath, preview); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first, so a concurrent request never sends a partial page.
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(preview); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// DeleteDocumentPreviewFiles method to delete the thumbnail, the rendered pages and
+// the converted PDF of a document. Files that are already missing are skipped.
+func DeleteDocumentPreviewFiles(path string, document *models.Document) error {
+	if err := RemoveFile(DocumentThumbnailFilePath(path, document)); err != nil {
+		return err
+	}
+	_ = DeleteDocumentFromCache(document.ID, DocumentThumbnailCacheSuffix)
+
+	if err := RemoveFile(DocumentPdfFilePath(path, document)); err != nil {
+		return err
+	}
+
+	for page := 1; page <= document.PageCount; page++ {
+		if err := RemoveFile(DocumentPreviewFilePath(path, document, page)); err != nil {
+			return err
+		}
+		_ = DeleteDocumentFromCache(document.ID, DocumentPreviewCacheSuffix(page))
+	}
+
+	return nil
+}
+
+// convertDocumentToPdf converts an office document to a PDF with the headless converter,
+// LibreOffice by default. Every conversion uses its own profile, so conversions can run at once.
+func convertDocumentToPdf(data []byte, extension string) ([]byte, error) {
+	converter := os.Getenv("DOCUMENT_CONVERTER")
+	if converter == "" {
+		converter = "soffice"
+	}
+	binary, err := exec.LookPath(converter)
+	if err != nil {
+		return nil, ErrDocumentConverterMissing
+	}
+
+	timeout := documentConverterTimeout
+	if value := os.Getenv("DOCUMENT_CONVERTER_TIMEOUT"); value != "" {
+		if timeout, err = time.ParseDuration(value); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "document-preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "document."+extension)
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary,
+		"-env:UserInstallation=file://"+filepath.Join(dir, "profile"),
+		"--headless", "--convert-to", "pdf", "--outdir", dir, input)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("converting the document failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return os.ReadFile(filepath.Join(dir, "document.pdf"))
+}
@@ -1,13 +1,27 @@
 package services
 
 import (
+	"api-file/main/src/cache"
 	"api-file/main/src/database"
+	"api-file/main/src/enums"
 	"api-file/main/src/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // IsDocumentAvailable method to check if a document is available within the app.
 func IsDocumentAvailable(folderId uint, name, extension string) (bool, error) {
-	if result := database.Pg.
+	return isDocumentAvailable(database.Pg, folderId, name, extension)
+}
+
+// isDocumentAvailable checks with the query if the folder has a document with the name, also when it is deleted.
+func isDocumentAvailable(db *gorm.DB, folderId uint, name, extension string) (bool, error) {
+	if result := db.
 		Unscoped().
 		Limit(1).
 		Find(&models.Document{}, "folder_id = ? AND name = ? AND extension = ?", folderId, name, extension); result.Error != nil {
@@ -44,8 +58,107 @@ func GetDocumentById(id uint) (models.Document, error) {
 	return document, nil
 }
 
-// CreateDocument method to create a new document.
-func CreateDocument(folderID uint, name, extension, mimeType string, size int) (models.Document, error) {
+// GetDocumentsByStoragePath method to get all documents of a storage path.
+func GetDocumentsByStoragePath(appStoragePathID uint) ([]models.Document, error) {
+	documents := make([]models.Document, 0)
+
+	if result := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Joins("JOIN folders ON documents.folder_id = folders.id").
+		Where("folders.app_storage_path_id = ?", appStoragePathID).
+		Order("documents.id").
+		Find(&documents); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return documents, nil
+}
+
+// GetDocumentsByIDs method to get the documents with their folder.
+func GetDocumentsByIDs(ids []uint) ([]models.Document, error) {
+	documents := make([]models.Document, 0)
+
+	if result := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&documents); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return documents, nil
+}
+
+// GetDocumentFromCache method to get a file path of the document from the cache.
+func GetDocumentFromCache(id uint, suffix ...string) (string, error) {
+	key := DocumentCacheKey(id, suffix...)
+
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(key).Build())
+	if result.Error() != nil {
+		return "", result.Error()
+	}
+
+	value, err := result.ToString()
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// SaveDocumentToCache method to save a file path of the document to the cache.
+func SaveDocumentToCache(documentId uint, path string, suffix ...string) error {
+	key := DocumentCacheKey(documentId, suffix...)
+
+	expiration := os.Getenv("VALKEY_EXPIRATION_DOCUMENT")
+	duration, err := time.ParseDuration(expiration)
+	if err != nil {
+		return err
+	}
+
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(key).Value(path).Ex(duration).Build())
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	return nil
+}
+
+// DeleteDocumentFromCache method to delete a file path of the document from the cache.
+func DeleteDocumentFromCache(id uint, suffix ...string) error {
+	key := DocumentCacheKey(id, suffix...)
+
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Del().Key(key).Build())
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	return nil
+}
+
+// DeleteDocumentFilesFromCache method to delete the cached paths of the document with its previews.
+func DeleteDocumentFilesFromCache(document *models.Document) {
+	_ = DeleteDocumentFromCache(document.ID)
+	_ = DeleteDocumentFromCache(document.ID, DocumentThumbnailCacheSuffix)
+	for page := 1; page <= document.PageCount; page++ {
+		_ = DeleteDocumentFromCache(document.ID, DocumentPreviewCacheSuffix(page))
+	}
+	_ = DeleteDocumentFromCache(document.ID, AppCacheSuffix)
+}
+
+// DocumentCacheKey method to get the cache key of a document file.
+func DocumentCacheKey(id uint, suffix ...string) string {
+	if len(suffix) > 0 {
+		return fmt.Sprintf("document:%d:%s", id, suffix[0])
+	}
+
+	return fmt.Sprintf("document:%d", id)
+}
+
+// CreateDocument method to create a new document with the result of its virus scan.
+func CreateDocument(folderID uint, name, extension, mimeType string, size int, scan ScanResult) (models.Document, error) {
 	document := models.Document{
 		FolderID:  folderID,
 		Name:      name,
@@ -53,6 +166,7 @@ func CreateDocument(folderID uint, name, extension, mimeType string, size int) (
 		MimeType:  mimeType,
 		Size:      size,
 	}
+	setDocumentScan(&document, scan)
 
 	if result := database.Pg.Create(&document); result.Error != nil {
 		return models.Document{}, result.Error
@@ -65,12 +179,13 @@ func CreateDocument(folderID uint, name, extension, mimeType string, size int) (
 	return document, nil
 }
 
-// UpdateDocument method to update a document.
-func UpdateDocument(document *models.Document, name, extension, mimeType string, size int) (models.Document, error) {
+// UpdateDocument method to update a document with the result of the virus scan of its new file.
+func UpdateDocument(document *models.Document, name, extension, mimeType string, size int, scan ScanResult) (models.Document, error) {
 	document.Name = name
 	document.Extension = extension
 	document.MimeType = mimeType
 	document.Size = size
+	setDocumentScan(document, scan)
 
 	if result := database.Pg.Save(document); result.Error != nil {
 		return *document, result.Error
@@ -104,3 +219,10 @@ func RestoreDocument(id uint) error {
 
 	return nil
 }
+
+// setDocumentScan sets the result of a virus scan on the document.
+func setDocumentScan(document *models.Document, scan ScanResult) {
+	document.ScanStatus = scan.Status
+	document.ScanSignature = sql.NullString{Valid: scan.Signature != "", String: scan.Signature}
+	document.ScannedAt = sql.NullTime{Valid: scan.Status != enums.Unscanned, Time: time.Now()}
+}
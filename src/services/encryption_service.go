@@ -0,0 +1,342 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const dataKeySize = 32
+
+// encryptedFileMagic starts every encrypted file. It is followed by the ID of the storage path
+// whose data key encrypted the file, so a file can be decrypted with only its path.
+var encryptedFileMagic = []byte("AFE1")
+
+// EncryptionOverhead is the amount of bytes an encrypted file is larger than its content.
+const EncryptionOverhead = 4 + 4 + 12 + 16
+
+// ErrMasterKeyMissing is returned when encryption is used without a configured master key.
+var ErrMasterKeyMissing = errors.New("encryption master key is not configured")
+
+// dataKeys caches the unwrapped data keys by storage path ID.
+// Rotating the master key only re-wraps the data keys, so the cache stays valid.
+var dataKeys sync.Map
+
+// IsEncryptionConfigured method to check if a valid master key is configured to wrap new data keys.
+func IsEncryptionConfigured() bool {
+	_, _, err := currentMasterKey()
+	return err == nil
+}
+
+// CreateDataKey method to create a random data key, wrapped by the current master key.
+func CreateDataKey() ([]byte, string, error) {
+	masterKeyID, masterKey, err := currentMasterKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", err
+	}
+
+	wrapped, err := seal(masterKey, dataKey, []byte(masterKeyID))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return wrapped, masterKeyID, nil
+}
+
+// EnableStorageEncryption method to give the storage path a data key when it is encrypted
+// and has none yet. The storage path is not saved.
+func EnableStorageEncryption(appStoragePath *models.AppStoragePath) error {
+	if !appStoragePath.Encrypted || len(appStoragePath.DataKey) > 0 {
+		return nil
+	}
+
+	wrapped, masterKeyID, err := CreateDataKey()
+	if err != nil {
+		return err
+	}
+
+	appStoragePath.DataKey = wrapped
+	appStoragePath.MasterKeyID = sql.NullString{Valid: true, String: masterKeyID}
+
+	return nil
+}
+
+// IsDataKeyRotated method to check if the data key of the storage path is missing or
+// already wrapped by the current master key.
+func IsDataKeyRotated(appStoragePath *models.AppStoragePath) bool {
+	masterKeyID, _, err := currentMasterKey()
+	return len(appStoragePath.DataKey) == 0 || err == nil && appStoragePath.MasterKeyID.String == masterKeyID
+}
+
+// RotateDataKey method to wrap the data key of the storage path with the current master key.
+// The files are not rewritten. It reports if the data key is re-wrapped.
+func RotateDataKey(appStoragePath *models.AppStoragePath) (bool, error) {
+	masterKeyID, masterKey, err := currentMasterKey()
+	if err != nil {
+		return false, err
+	}
+	if IsDataKeyRotated(appStoragePath) {
+		return false, nil
+	}
+
+	dataKey, err := unwrapDataKey(appStoragePath)
+	if err != nil {
+		return false, err
+	}
+
+	wrapped, err := seal(masterKey, dataKey, []byte(masterKeyID))
+	if err != nil {
+		return false, err
+	}
+
+	if result := database.Pg.Model(appStoragePath).Updates(map[string]interface{}{
+		"data_key":      wrapped,
+		"master_key_id": masterKeyID,
+	}); result.Error != nil {
+		return false, result.Error
+	}
+	appStoragePath.DataKey = wrapped
+	appStoragePath.MasterKeyID = sql.NullString{Valid: true, String: masterKeyID}
+
+	return true, nil
+}
+
+// WriteStorageFile method to write a file of the storage path, encrypted when the storage path
+// is encrypted. The onProgress callback receives the written percentage after each chunk.
+func WriteStorageFile(appStoragePath *models.AppStoragePath, filePath string, data []byte, onProgress func(percentage float64)) error {
+	return WriteStorageFileContext(context.Background(), appStoragePath, filePath, data, onProgress)
+}
+
+// WriteStorageFileContext method to write a file of the storage path in a span that is a child of the context.
+func WriteStorageFileContext(ctx context.Context, appStoragePath *models.AppStoragePath, filePath string, data []byte, onProgress func(percentage float64)) (err error) {
+	_, span := tracing.Start(ctx, "fs.write",
+		attribute.String("file.path", filePath),
+		attribute.Int("file.size", len(data)),
+		attribute.Bool("file.encrypted", appStoragePath.Encrypted),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	data, err = EncryptData(appStoragePath, data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filePath, data, onProgress)
+}
+
+// ReadStorageFile method to read a file of a storage path and to decrypt it when it is encrypted.
+func ReadStorageFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptData(data)
+}
+
+// IsEncryptedFile method to check if the file is encrypted.
+func IsEncryptedFile(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(encryptedFileMagic))
+	if _, err := io.ReadFull(file, header); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(header, encryptedFileMagic), nil
+}
+
+// EncryptData method to encrypt the data with the data key of the storage path.
+// The data is returned as is when the storage path is not encrypted.
+func EncryptData(appStoragePath *models.AppStoragePath, data []byte) ([]byte, error) {
+	if !appStoragePath.Encrypted {
+		return data, nil
+	}
+
+	dataKey, err := storageDataKey(appStoragePath.ID, appStoragePath)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(encryptedFileMagic)+4)
+	copy(header, encryptedFileMagic)
+	binary.BigEndian.PutUint32(header[len(encryptedFileMagic):], uint32(appStoragePath.ID))
+
+	sealed, err := seal(dataKey, data, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, sealed...), nil
+}
+
+// DecryptData method to decrypt data with the data key of the storage path in its header.
+// Data that is not encrypted, like files written before encryption was enabled, is returned as is.
+func DecryptData(data []byte) ([]byte, error) {
+	headerSize := len(encryptedFileMagic) + 4
+	if len(data) < headerSize || !bytes.Equal(data[:len(encryptedFileMagic)], encryptedFileMagic) {
+		return data, nil
+	}
+
+	id := uint(binary.BigEndian.Uint32(data[len(encryptedFileMagic):headerSize]))
+	dataKey, err := storageDataKey(id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataKey, data[headerSize:], data[:headerSize])
+}
+
+// storageDataKey gets the unwrapped data key of the storage path with the ID.
+// The storage path is loaded when it is not given.
+func storageDataKey(id uint, appStoragePath *models.AppStoragePath) ([]byte, error) {
+	if dataKey, ok := dataKeys.Load(id); ok {
+		return dataKey.([]byte), nil
+	}
+
+	if appStoragePath == nil {
+		appStoragePath = &models.AppStoragePath{}
+		if result := database.Pg.Find(appStoragePath, "id = ?", id); result.Error != nil {
+			return nil, result.Error
+		} else if appStoragePath.ID == 0 {
+			return nil, fmt.Errorf("storage path %d of the encrypted file does not exist", id)
+		}
+	}
+
+	dataKey, err := unwrapDataKey(appStoragePath)
+	if err != nil {
+		return nil, err
+	}
+	dataKeys.Store(id, dataKey)
+
+	return dataKey, nil
+}
+
+// unwrapDataKey decrypts the data key of the storage path with the master key that wrapped it.
+func unwrapDataKey(appStoragePath *models.AppStoragePath) ([]byte, error) {
+	if len(appStoragePath.DataKey) == 0 {
+		return nil, fmt.Errorf("storage path %d has no data key", appStoragePath.ID)
+	}
+
+	masterKeys, err := masterKeys()
+	if err != nil {
+		return nil, err
+	}
+	masterKey, ok := masterKeys[appStoragePath.MasterKeyID.String]
+	if !ok {
+		return nil, fmt.Errorf("master key %q of storage path %d is not configured", appStoragePath.MasterKeyID.String, appStoragePath.ID)
+	}
+
+	return open(masterKey, appStoragePath.DataKey, []byte(appStoragePath.MasterKeyID.String))
+}
+
+// currentMasterKey gets the master key that wraps new data keys, set with ENCRYPTION_MASTER_KEY_ID.
+func currentMasterKey() (string, []byte, error) {
+	masterKeys, err := masterKeys()
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := os.Getenv("ENCRYPTION_MASTER_KEY_ID")
+	masterKey, ok := masterKeys[id]
+	if !ok {
+		return "", nil, ErrMasterKeyMissing
+	}
+
+	return id, masterKey, nil
+}
+
+// masterKeys parses ENCRYPTION_MASTER_KEYS, a comma separated list of id:base64 keys of 32 bytes.
+// Old master keys stay in the list until every data key is rotated.
+func masterKeys() (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+
+	for _, entry := range strings.Split(os.Getenv("ENCRYPTION_MASTER_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, value, found := strings.Cut(entry, ":")
+		if !found || id == "" {
+			return nil, errors.New("ENCRYPTION_MASTER_KEYS must be a list of id:key")
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes in base64", id, dataKeySize)
+		}
+		keys[id] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrMasterKeyMissing
+	}
+
+	return keys, nil
+}
+
+// seal encrypts the plaintext with AES-256-GCM and puts the random nonce in front of it.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the ciphertext of seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], additionalData)
+}
+
+// newGCM creates the AES-GCM cipher of the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package services
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates the target as a reflink of the source, which shares the blocks
+// of the source until one of them is written. It fails on file systems without reflinks.
+func cloneFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	if err := unix.IoctlFileClone(int(out.Fd()), int(in.Fd())); err != nil {
+		_ = out.Close()
+		_ = os.Remove(target)
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package services
+
+import "errors"
+
+// cloneFile is only supported on Linux, other systems use a hard link or a copy.
+func cloneFile(source, target string) error {
+	return errors.New("reflinks are not supported on this system")
+}
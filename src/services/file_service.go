@@ -0,0 +1,94 @@
+package services
+
+import (
+	upload "api-file/main/src/utils"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// WriteFile method to write the data in chunks to the file.
+// An existing file is replaced instead of rewritten, so a hard linked copy keeps its content.
+// The onProgress callback receives the written percentage after each chunk.
+func WriteFile(filePath string, data []byte, onProgress func(percentage float64)) error {
+	if err := RemoveFile(filePath); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chunks := upload.ChunkBytes(data)
+
+	var seeker int64
+	for i, chunk := range chunks {
+		if _, err := file.WriteAt(chunk, seeker); err != nil {
+			return err
+		}
+		seeker += int64(len(chunk))
+		if onProgress != nil {
+			onProgress(float64(i) * 100.0 / float64(len(chunks)))
+		}
+	}
+
+	if onProgress != nil {
+		onProgress(100.0)
+	}
+
+	return nil
+}
+
+// RemoveFile method to remove a file.
+// A file that does not exist is not an error, so a partially deleted
+// record can still be cleaned up.
+func RemoveFile(filePath string) error {
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// LinkFile method to put a copy of the source file at the target path without copying its
+// content when the file system allows it: as a reflink, which copies on write, or as a
+// hard link. Otherwise the content is copied. An existing target file is replaced.
+func LinkFile(source, target string) error {
+	if err := RemoveFile(target); err != nil {
+		return err
+	}
+
+	if err := cloneFile(source, target); err == nil {
+		return nil
+	}
+	if err := os.Link(source, target); err == nil {
+		return nil
+	}
+
+	return copyFile(source, target)
+}
+
+// copyFile copies the content of the source file to a new target file.
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(target)
+		return err
+	}
+
+	return out.Close()
+}
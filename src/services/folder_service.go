@@ -3,6 +3,7 @@ package services
 import (
 	"api-file/main/src/database"
 	"api-file/main/src/models"
+	"context"
 )
 
 // IsFolderAvailable method to check if a folder already exists inside the same path.
@@ -52,15 +53,17 @@ func IsFolderDeleted(id uint) (bool, error) {
 }
 
 // GetFolderPath method to get the path of a folder.
+// Deleted folders are included, because their files stay on disk until restored.
 // It returns the path of the folder like:
 //
 //	folder1/folder2/folder3
-func GetFolderPath(appStoragePathID, folderID uint) (string, error) {
+func GetFolderPath(ctx context.Context, appStoragePathID, folderID uint) (string, error) {
 	var folders []*models.FolderFolder
 	parentFolderID := folderID
 	var path string
 
-	if result := database.Pg.Preload("Folder").
+	if result := database.Pg.WithContext(ctx).Unscoped().
+		Preload("Folder").
 		Preload("ParentFolder").
 		Find(&folders, "app_storage_path_id = ?", appStoragePathID); result.Error != nil {
 		return "", result.Error
@@ -73,11 +76,11 @@ func GetFolderPath(appStoragePathID, folderID uint) (string, error) {
 		folder = searchFolderByID(folders, folder.ParentFolderID)
 	}
 
-	if mainFolder, _, err := GetFolder(parentFolderID); err != nil {
-		return "", err
-	} else {
-		path = mainFolder.Name + "/" + path
+	mainFolder := models.Folder{}
+	if result := database.Pg.WithContext(ctx).Unscoped().Find(&mainFolder, "id = ?", parentFolderID); result.Error != nil {
+		return "", result.Error
 	}
+	path = mainFolder.Name + "/" + path
 
 	return path, nil
 }
@@ -88,7 +91,7 @@ func GetFolder(id uint, preload ...bool) (folder *models.Folder, folders []*mode
 	query := database.Pg
 
 	if len(preload) > 0 && preload[0] {
-		query = query.Preload("Folders").Preload("Images.ImageSizes").Preload("Documents")
+		query = query.Preload("Folders").Preload("Images.ImageSizes").Preload("Images.ImageCrops").Preload("Documents")
 	}
 
 	if result := query.Find(folder, "id = ?", id); result.Error != nil {
@@ -108,6 +111,73 @@ func GetFolder(id uint, preload ...bool) (folder *models.Folder, folders []*mode
 	return folder, folders, nil
 }
 
+// GetFolderWithStoragePath method to get a folder with its storage path.
+func GetFolderWithStoragePath(id uint) (*models.Folder, error) {
+	folder := &models.Folder{}
+
+	if result := database.Pg.Preload("AppStoragePath").Find(folder, "id = ?", id); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return folder, nil
+}
+
+// GetFolderFilesSize method to count the images and documents of a folder with its sub folders
+// and to sum their size. Deleted folders and files are left out.
+func GetFolderFilesSize(appStoragePathID, folderID uint) (int, int64, error) {
+	var imagesCount, documentsCount, imagesSize, documentsSize int64
+
+	folderIDs, err := getFolderDescendantIDs(appStoragePathID, folderID, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	folderIDs = append(folderIDs, folderID)
+
+	if result := database.Pg.Model(&models.Image{}).
+		Where("folder_id IN ?", folderIDs).
+		Count(&imagesCount); result.Error != nil {
+		return 0, 0, result.Error
+	}
+	if result := database.Pg.Model(&models.Image{}).
+		Where("folder_id IN ?", folderIDs).
+		Select("COALESCE(SUM(size), 0)").Scan(&imagesSize); result.Error != nil {
+		return 0, 0, result.Error
+	}
+
+	if result := database.Pg.Model(&models.Document{}).
+		Where("folder_id IN ?", folderIDs).
+		Count(&documentsCount); result.Error != nil {
+		return 0, 0, result.Error
+	}
+	if result := database.Pg.Model(&models.Document{}).
+		Where("folder_id IN ?", folderIDs).
+		Select("COALESCE(SUM(size), 0)").Scan(&documentsSize); result.Error != nil {
+		return 0, 0, result.Error
+	}
+
+	return int(imagesCount + documentsCount), imagesSize + documentsSize, nil
+}
+
+// GetChildFolder method to get a folder by its name inside the parent folder.
+func GetChildFolder(appStoragePathID uint, name string, parentFolderID uint) (*models.Folder, error) {
+	folder := &models.Folder{}
+
+	if result := database.Pg.
+		Joins("JOIN folder_folders ON folder_folders.folder_id = folders.id").
+		Where("folders.app_storage_path_id = ? AND folders.name = ? AND folder_folders.parent_folder_id = ?", appStoragePathID, name, parentFolderID).
+		Limit(1).
+		Find(folder); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return folder, nil
+}
+
+// GetFolderDescendantIDs method to get the IDs of all folders below a folder.
+func GetFolderDescendantIDs(appStoragePathID, folderID uint) ([]uint, error) {
+	return getFolderDescendantIDs(appStoragePathID, folderID, true)
+}
+
 // CreateFolder method to create a folder.
 func CreateFolder(appStoragePathID uint, name, color string, immutable bool, parentFolderID ...uint) (*models.Folder, error) {
 	folder := &models.Folder{AppStoragePathID: appStoragePathID, Name: name, Color: color, Immutable: immutable}
@@ -160,6 +230,37 @@ func RestoreFolder(id uint) error {
 	return nil
 }
 
+// getFolderDescendantIDs gets the IDs of the folders below a folder. Without includeDeleted,
+// the folders that are deleted, or inside a deleted folder, are left out.
+func getFolderDescendantIDs(appStoragePathID, folderID uint, includeDeleted bool) ([]uint, error) {
+	var folders []*models.FolderFolder
+	var ids []uint
+
+	query := database.Pg
+	if !includeDeleted {
+		query = query.Joins("JOIN folders ON folders.id = folder_folders.folder_id AND folders.deleted_at IS NULL")
+	}
+	if result := query.Find(&folders, "folder_folders.app_storage_path_id = ?", appStoragePathID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	parents := []uint{folderID}
+	for len(parents) > 0 {
+		var children []uint
+		for _, folder := range folders {
+			for _, parent := range parents {
+				if folder.ParentFolderID == parent {
+					children = append(children, folder.FolderID)
+				}
+			}
+		}
+		ids = append(ids, children...)
+		parents = children
+	}
+
+	return ids, nil
+}
+
 // searchInFoldersByID searches for a folder in the array by FolderID.
 func searchFolderByID(folders []*models.FolderFolder, id uint) *models.FolderFolder {
 	for _, folder := range folders {
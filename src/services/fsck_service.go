@@ -0,0 +1,307 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fsckOrphanGracePeriod is the age of a file before it can be an orphan. A newer file can be an upload
+// of which the record is not committed yet, or a temporary file of a job.
+const fsckOrphanGracePeriod = time.Hour
+
+// FsckOptions defines which repairs are done while checking a storage path.
+type FsckOptions struct {
+	DeleteOrphans   bool
+	MarkBroken      bool
+	RegenerateSizes bool
+}
+
+// expectedFiles are the paths of the files that belong to the database. The paths are cleaned,
+// because the root and the path of a storage path can have doubled or trailing separators.
+type expectedFiles map[string]bool
+
+// add expects the file.
+func (e expectedFiles) add(filePath string) {
+	e[filepath.Clean(filePath)] = true
+}
+
+// has checks if the file is expected.
+func (e expectedFiles) has(filePath string) bool {
+	return e[filepath.Clean(filePath)]
+}
+
+// CheckStoragePath method to compare the files of a storage path with the database.
+// It reports orphan files, missing files, missing web sizes and crops and size mismatches
+// and repairs them according to the options.
+func CheckStoragePath(appStoragePath *models.AppStoragePath, options FsckOptions) ([]models.FsckIssue, error) {
+	issues := make([]models.FsckIssue, 0)
+	expected := make(expectedFiles)
+
+	var folders []models.Folder
+	if result := database.Pg.Unscoped().Find(&folders, "app_storage_path_id = ?", appStoragePath.ID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i := range folders {
+		path, err := GetPath(appStoragePath, folders[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		imageIssues, err := checkImages(appStoragePath, path, folders[i].ID, options, expected)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, imageIssues...)
+
+		documentIssues, err := checkDocuments(path, folders[i].ID, options, expected)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, documentIssues...)
+	}
+
+	// The files of a running job are not in the database yet, so the orphans are only reported then.
+	held, err := HasRunningJobs(appStoragePath.ID)
+	if err != nil {
+		return nil, err
+	}
+	orphanOptions := options
+	orphanOptions.DeleteOrphans = options.DeleteOrphans && !held
+
+	orphanIssues, err := checkOrphans(os.Getenv("PATH_FILES")+appStoragePath.Path, orphanOptions, expected, time.Now().Add(-fsckOrphanGracePeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(issues, orphanIssues...), nil
+}
+
+// checkImages compares the images of a folder and their web sizes with the files on disk.
+func checkImages(appStoragePath *models.AppStoragePath, path string, folderID uint, options FsckOptions, expected expectedFiles) ([]models.FsckIssue, error) {
+	var issues []models.FsckIssue
+	var images []models.Image
+
+	if result := database.Pg.Unscoped().
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Find(&images, "folder_id = ?", folderID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i := range images {
+		image := &images[i]
+		filePath := ImageFilePath(path, image)
+		expected.add(filePath)
+		if image.Animation.HasPoster {
+			expected.add(ImagePosterFilePath(path, image.Name))
+		}
+		if image.Edit.Edited {
+			expected.add(ImageEditedFilePath(path, image.Name))
+		}
+		if appStoragePath.Watermark.IsEnabled() {
+			expected.add(ImageWatermarkedFilePath(path, image.Name))
+		}
+
+		issue := checkFile(filePath, int64(image.Size))
+		broken := issue != nil
+		if broken {
+			issue.FileType = enums.Image
+			issue.ID = image.ID
+		}
+
+		if options.MarkBroken && image.Broken != broken {
+			if result := database.Pg.Model(image).Unscoped().UpdateColumn("broken", broken); result.Error != nil {
+				return nil, result.Error
+			}
+			if issue != nil {
+				issue.Repaired = true
+			}
+		}
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+
+		for j := range image.ImageSizes {
+			imageSize := &image.ImageSizes[j]
+			sizePath := ImageSizeFilePath(path, image.Name, imageSize.Size)
+			expected.add(sizePath)
+
+			if _, err := os.Stat(sizePath); err == nil {
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+
+			sizeIssue := models.FsckIssue{Type: enums.MissingSize, FileType: enums.Image, ID: image.ID, Size: imageSize.Size, Path: sizePath}
+			if options.RegenerateSizes && !broken {
+				if err := regenerateImageSize(appStoragePath, path, image, imageSize); err != nil {
+					return nil, err
+				}
+				sizeIssue.Repaired = true
+			}
+			issues = append(issues, sizeIssue)
+		}
+
+		var cropIssues []models.FsckIssue
+		for j := range image.ImageCrops {
+			cropPath := ImageCropFilePath(path, image.Name, image.ImageCrops[j].Crop)
+			expected.add(cropPath)
+
+			if _, err := os.Stat(cropPath); err == nil {
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+
+			cropIssues = append(cropIssues, models.FsckIssue{Type: enums.MissingCrop, FileType: enums.Image, ID: image.ID, Crop: image.ImageCrops[j].Crop, Path: cropPath})
+		}
+		if len(cropIssues) > 0 && options.RegenerateSizes && !broken {
+			if _, err := regenerateImageCrops(appStoragePath, path, image, 0); err != nil {
+				return nil, err
+			}
+			for j := range cropIssues {
+				cropIssues[j].Repaired = true
+			}
+		}
+		issues = append(issues, cropIssues...)
+	}
+
+	return issues, nil
+}
+
+// checkDocuments compares the documents of a folder with the files on disk.
+func checkDocuments(path string, folderID uint, options FsckOptions, expected expectedFiles) ([]models.FsckIssue, error) {
+	var issues []models.FsckIssue
+	var documents []models.Document
+
+	if result := database.Pg.Unscoped().Find(&documents, "folder_id = ?", folderID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i := range documents {
+		document := &documents[i]
+		filePath := DocumentFilePath(path, document)
+		expected.add(filePath)
+
+		// The previews are derivatives of the document, the pages are only rendered on request.
+		if document.HasPreview {
+			expected.add(DocumentThumbnailFilePath(path, document))
+			expected.add(DocumentPdfFilePath(path, document))
+			for page := 1; page <= document.PageCount; page++ {
+				expected.add(DocumentPreviewFilePath(path, document, page))
+			}
+		}
+
+		issue := checkFile(filePath, int64(document.Size))
+		broken := issue != nil
+		if broken {
+			issue.FileType = enums.Document
+			issue.ID = document.ID
+		}
+
+		if options.MarkBroken && document.Broken != broken {
+			if result := database.Pg.Model(document).Unscoped().UpdateColumn("broken", broken); result.Error != nil {
+				return nil, result.Error
+			}
+			if issue != nil {
+				issue.Repaired = true
+			}
+		}
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// checkOrphans walks the root of the storage path and reports every file that is not expected.
+// Files that are changed after the time are skipped, they can still be written.
+func checkOrphans(root string, options FsckOptions, expected expectedFiles, before time.Time) ([]models.FsckIssue, error) {
+	var issues []models.FsckIssue
+
+	root = filepath.Clean(root)
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filePath == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || expected.has(filePath) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if info.ModTime().After(before) {
+			return nil
+		}
+
+		issue := models.FsckIssue{Type: enums.OrphanFile, Path: filePath, Actual: info.Size()}
+
+		if options.DeleteOrphans {
+			if err := RemoveFile(filePath); err != nil {
+				return err
+			}
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// checkFile checks if the file exists with the expected size.
+// It returns nil when the file is consistent.
+func checkFile(filePath string, size int64) *models.FsckIssue {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return &models.FsckIssue{Type: enums.MissingFile, Path: filePath, Expected: size}
+	}
+
+	// Encrypted files are larger than their content by a fixed amount.
+	if info.Size() != size && info.Size() != size+EncryptionOverhead {
+		return &models.FsckIssue{Type: enums.SizeMismatch, Path: filePath, Expected: size, Actual: info.Size()}
+	}
+
+	return nil
+}
+
+// regenerateImageSize creates a missing web size again from the source of the image.
+func regenerateImageSize(appStoragePath *models.AppStoragePath, path string, image *models.Image, imageSize *models.ImageSize) error {
+	data, err := ReadImageSource(path, image)
+	if err != nil {
+		return err
+	}
+
+	regenerated, err := ConvertAndUploadImageFile(context.Background(), appStoragePath, path, image.Name, data, imageSize.Size, 0, IsAnimatedSize(appStoragePath, &image.Animation))
+	if err != nil {
+		return err
+	}
+
+	if result := database.Pg.Model(imageSize).Unscoped().Updates(map[string]interface{}{
+		"width":  regenerated.Width,
+		"height": regenerated.Height,
+	}); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"api-file/main/src/enums"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckOrphans(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathFiles  string
+		path       string
+		folderPath string
+	}{
+		{name: "clean", pathFiles: "", path: "/app", folderPath: "/photos"},
+		{name: "trailing separator", pathFiles: "/", path: "/app/", folderPath: "/photos/"},
+		{name: "doubled separator", pathFiles: "//", path: "//app//", folderPath: "//photos"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "app", "photos"), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"image.png", "orphan.png"} {
+				if err := os.WriteFile(filepath.Join(dir, "app", "photos", name), []byte("data"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The paths are made like GetPath makes them, without cleaning them.
+			root := dir + test.pathFiles + test.path
+			expected := make(expectedFiles)
+			expected.add(root + test.folderPath + "/image.png")
+
+			issues, err := checkOrphans(root, FsckOptions{}, expected, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %d issues, want only the orphan: %+v", len(issues), issues)
+			}
+			if issues[0].Type != enums.OrphanFile || filepath.Base(issues[0].Path) != "orphan.png" {
+				t.Errorf("got issue %+v, want the orphan", issues[0])
+			}
+		})
+	}
+}
+
+func TestCheckOrphansMissingRoot(t *testing.T) {
+	issues, err := checkOrphans(filepath.Join(t.TempDir(), "missing")+"/", FsckOptions{DeleteOrphans: true}, make(expectedFiles), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("got %d issues, want none", len(issues))
+	}
+}
+
+func TestCheckOrphansGracePeriod(t *testing.T) {
+	root := t.TempDir()
+	fresh := filepath.Join(root, "fresh.png")
+	old := filepath.Join(root, "old.png")
+	for _, filePath := range []string{fresh, old} {
+		if err := os.WriteFile(filePath, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldTime := time.Now().Add(-2 * fsckOrphanGracePeriod)
+	if err := os.Chtimes(old, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := checkOrphans(root, FsckOptions{DeleteOrphans: true}, make(expectedFiles), time.Now().Add(-fsckOrphanGracePeriod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Path != old || !issues[0].Repaired {
+		t.Fatalf("got issues %+v, want only the old orphan repaired", issues)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("fresh orphan did not survive the repair: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old orphan is not deleted: %v", err)
+	}
+}
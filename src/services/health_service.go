@@ -0,0 +1,213 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// healthTimeout is how long the checks of the health may take together.
+const healthTimeout = 5 * time.Second
+
+// defaultHealthMinFreeBytes is the free space a storage root needs when HEALTH_MIN_FREE_BYTES is not set.
+const defaultHealthMinFreeBytes = 1 << 30
+
+// ErrDiskSpaceUnsupported is returned when the free space of a file system can not be read on the system.
+var ErrDiskSpaceUnsupported = errors.New("disk space is not supported on this system")
+
+// ErrNotConnected is returned when the connection to a dependency is not opened yet.
+var ErrNotConnected = errors.New("not connected yet")
+
+// CheckHealth method to check if the service is ready: the database and the cache are reachable,
+// and the root of the files and of each storage path is mounted and writable with enough free space.
+// The checks run in parallel, a check that does not finish in time is unhealthy.
+func CheckHealth(ctx context.Context) []models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	checks := []func(ctx context.Context) models.HealthCheck{checkDatabaseHealth, checkCacheHealth}
+	checks = append(checks, storageHealthChecks(ctx)...)
+
+	results := make([]models.HealthCheck, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// IsHealthy method to check if every check is healthy.
+func IsHealthy(checks []models.HealthCheck) bool {
+	if len(checks) == 0 {
+		return false
+	}
+
+	for i := range checks {
+		if !checks[i].Healthy {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkDatabaseHealth pings the database.
+func checkDatabaseHealth(ctx context.Context) models.HealthCheck {
+	return timedHealthCheck("postgres", func() error {
+		if !database.IsConnected() {
+			return ErrNotConnected
+		}
+
+		db, err := database.Pg.DB()
+		if err != nil {
+			return err
+		}
+
+		return db.PingContext(ctx)
+	})
+}
+
+// checkCacheHealth pings the cache.
+func checkCacheHealth(ctx context.Context) models.HealthCheck {
+	return timedHealthCheck("valkey", func() error {
+		if !cache.IsConnected() {
+			return ErrNotConnected
+		}
+
+		return cache.Valkey.Do(ctx, cache.Valkey.B().Ping().Build()).Error()
+	})
+}
+
+// storageHealthChecks creates a check for the root of the files and for the root of each storage path.
+// The storage paths are left out when the database can not be read, that is reported by its own check.
+func storageHealthChecks(ctx context.Context) []func(ctx context.Context) models.HealthCheck {
+	checks := []func(ctx context.Context) models.HealthCheck{
+		func(ctx context.Context) models.HealthCheck {
+			return checkStorageHealth(ctx, "files", os.Getenv("PATH_FILES"), true)
+		},
+	}
+
+	var storagePaths []models.AppStoragePath
+	if !database.IsConnected() {
+		return checks
+	} else if result := database.Pg.WithContext(ctx).Order("id").Find(&storagePaths); result.Error != nil {
+		return checks
+	}
+
+	for i := range storagePaths {
+		storagePath := storagePaths[i]
+		checks = append(checks, func(ctx context.Context) models.HealthCheck {
+			// A storage path gets its directory with its first file, until then it can not be missing.
+			usedSpace, err := GetUsedSpace(storagePath.ID)
+			if err != nil {
+				return models.HealthCheck{Name: storageHealthName(&storagePath), Error: err.Error()}
+			}
+
+			return checkStorageHealth(ctx, storageHealthName(&storagePath), os.Getenv("PATH_FILES")+storagePath.Path, usedSpace > 0)
+		})
+	}
+
+	return checks
+}
+
+// checkStorageHealth checks that the root is a directory, that a file can be written in it
+// and that its file system has more free space than the threshold. A root that is not required may be missing.
+func checkStorageHealth(ctx context.Context, name, root string, required bool) models.HealthCheck {
+	minFreeBytes := healthMinFreeBytes()
+
+	done := make(chan models.HealthCheck, 1)
+	go func() {
+		var free, total *uint64
+		check := timedHealthCheck(name, func() (err error) {
+			free, total, err = checkStorageRoot(root, required, minFreeBytes)
+			return err
+		})
+		check.FreeBytes, check.TotalBytes = free, total
+		done <- check
+	}()
+
+	// A file system that does not respond, like a lost network mount, blocks the calls.
+	var check models.HealthCheck
+	select {
+	case check = <-done:
+	case <-ctx.Done():
+		check = models.HealthCheck{Name: name, Error: ctx.Err().Error()}
+	}
+	check.Path = root
+	check.MinFreeBytes = &minFreeBytes
+
+	return check
+}
+
+// checkStorageRoot writes and removes a file in the root and reads the space of its file system.
+func checkStorageRoot(root string, required bool, minFreeBytes uint64) (free, total *uint64, err error) {
+	info, err := os.Stat(root)
+	if os.IsNotExist(err) && !required {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	} else if !info.IsDir() {
+		return nil, nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	file, err := os.CreateTemp(root, ".health-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return nil, nil, err
+	}
+
+	freeBytes, totalBytes, err := diskSpace(root)
+	if errors.Is(err, ErrDiskSpaceUnsupported) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if freeBytes < minFreeBytes {
+		return &freeBytes, &totalBytes, fmt.Errorf("%d bytes free, %d bytes needed", freeBytes, minFreeBytes)
+	}
+
+	return &freeBytes, &totalBytes, nil
+}
+
+// timedHealthCheck runs the check and measures how long it takes.
+func timedHealthCheck(name string, check func() error) models.HealthCheck {
+	start := time.Now()
+	err := check()
+
+	result := models.HealthCheck{Name: name, Healthy: err == nil, Duration: time.Since(start)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// storageHealthName gets the name of the check of a storage path.
+func storageHealthName(storagePath *models.AppStoragePath) string {
+	return fmt.Sprintf("storage:%s:%s", storagePath.AppName, storagePath.Path)
+}
+
+// healthMinFreeBytes gets the free space a storage root needs from HEALTH_MIN_FREE_BYTES.
+func healthMinFreeBytes() uint64 {
+	if value, err := strconv.ParseUint(os.Getenv("HEALTH_MIN_FREE_BYTES"), 10, 64); err == nil {
+		return value
+	}
+
+	return defaultHealthMinFreeBytes
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	// defaultIdempotencyExpiration is how long a response is replayed when VALKEY_EXPIRATION_IDEMPOTENCY is not set.
+	defaultIdempotencyExpiration = 24 * time.Hour
+	// defaultIdempotencyLockExpiration is how long a key is claimed by a request that is still handled
+	// when VALKEY_EXPIRATION_IDEMPOTENCY_LOCK is not set.
+	defaultIdempotencyLockExpiration = 5 * time.Minute
+)
+
+// IdempotentResponse is the response of a request with an idempotency key that is kept in the cache.
+// It is not done while the first request is handled.
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// StartIdempotentRequest method to claim the idempotency key for the request with the fingerprint.
+// It returns the response that is kept for the key when the key is already claimed.
+// The claim expires after the lock expiration, so the key is released when the request is never finished.
+func StartIdempotentRequest(ctx context.Context, key, fingerprint string) (*IdempotentResponse, error) {
+	value, err := json.Marshal(&IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	result := cache.Valkey.Do(ctx, cache.Valkey.B().Set().Key(idempotencyCacheKey(key)).Value(valkey.BinaryString(value)).Nx().Ex(idempotencyLockExpiration()).Build())
+	if err := result.Error(); err == nil {
+		return nil, nil
+	} else if !valkey.IsValkeyNil(err) {
+		return nil, err
+	}
+
+	result = cache.Valkey.Do(ctx, cache.Valkey.B().Get().Key(idempotencyCacheKey(key)).Build())
+	if valkey.IsValkeyNil(result.Error()) {
+		// The key expired in between, so it can be claimed again.
+		return StartIdempotentRequest(ctx, key, fingerprint)
+	}
+	value, err = result.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &IdempotentResponse{}
+	if err := json.Unmarshal(value, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// SaveIdempotentResponse method to keep the response of the request of the idempotency key to replay it.
+func SaveIdempotentResponse(ctx context.Context, key string, response *IdempotentResponse) error {
+	response.Done = true
+	value, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	result := cache.Valkey.Do(ctx, cache.Valkey.B().Set().Key(idempotencyCacheKey(key)).Value(valkey.BinaryString(value)).Ex(idempotencyExpiration()).Build())
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	return nil
+}
+
+// DeleteIdempotentRequest method to release the idempotency key, so the request can be retried.
+func DeleteIdempotentRequest(ctx context.Context, key string) error {
+	result := cache.Valkey.Do(ctx, cache.Valkey.B().Del().Key(idempotencyCacheKey(key)).Build())
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	return nil
+}
+
+// idempotencyExpiration gets how long a response is replayed from VALKEY_EXPIRATION_IDEMPOTENCY.
+func idempotencyExpiration() time.Duration {
+	if duration, err := time.ParseDuration(os.Getenv("VALKEY_EXPIRATION_IDEMPOTENCY")); err == nil && duration > 0 {
+		return duration
+	}
+
+	return defaultIdempotencyExpiration
+}
+
+// idempotencyLockExpiration gets how long a key is claimed by a request from VALKEY_EXPIRATION_IDEMPOTENCY_LOCK.
+func idempotencyLockExpiration() time.Duration {
+	if duration, err := time.ParseDuration(os.Getenv("VALKEY_EXPIRATION_IDEMPOTENCY_LOCK")); err == nil && duration > 0 {
+		return duration
+	}
+
+	return defaultIdempotencyLockExpiration
+}
+
+// idempotencyCacheKey creates the key of the idempotency cache. The key of the client is hashed,
+// so it can not clash with other keys.
+func idempotencyCacheKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/h2non/bimg"
+)
+
+// ReadImageAnimation method to count the frames and the total duration in milliseconds
+// of an animated GIF or WebP image. Other images have a single frame.
+func ReadImageAnimation(data []byte) models.ImageAnimation {
+	var frameCount, duration int
+
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		frameCount, duration = readGifAnimation(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		frameCount, duration = readWebpAnimation(data)
+	}
+
+	return models.ImageAnimation{FrameCount: max(frameCount, 1), Duration: duration}
+}
+
+// IsAnimatedSize method to check if the web sizes of the image are animated on the storage path.
+// A storage path with a watermark gets still web sizes, as the watermark is drawn on a single frame.
+func IsAnimatedSize(appStoragePath *models.AppStoragePath, animation *models.ImageAnimation) bool {
+	return animation.IsAnimated() && appStoragePath.AnimationMode == enums.AnimationAnimate && !appStoragePath.Watermark.IsEnabled()
+}
+
+// IsResizedAnimation method to check if web sizes are created for the image on the storage path.
+// Animated images are not resized when the storage path keeps only the original.
+func IsResizedAnimation(appStoragePath *models.AppStoragePath, animation *models.ImageAnimation) bool {
+	return !animation.IsAnimated() || appStoragePath.AnimationMode != enums.AnimationOriginal
+}
+
+// ImagePosterCacheSuffix is the cache key suffix of the poster of an image.
+const ImagePosterCacheSuffix = "poster"
+
+// ImagePosterFilePath method to get the file path of the still poster frame of an animated image.
+func ImagePosterFilePath(path, filename string) string {
+	return fmt.Sprintf("%s%s-poster.webp", path, filename)
+}
+
+// CreateImagePosterFile method to write the first frame of an animated image as a still
+// WebP poster when the storage path asks for it, with the watermark of the storage path.
+// The animation records if a poster exists.
+func CreateImagePosterFile(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, animation *models.ImageAnimation, quality int) error {
+	animation.HasPoster = false
+	if !animation.IsAnimated() || !appStoragePath.AnimationPoster {
+		return nil
+	}
+
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return err
+	}
+
+	poster, err := createImagePoster(ctx, appStoragePath, data, quality)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteStorageFileContext(ctx, appStoragePath, ImagePosterFilePath(path, filename), poster, nil); err != nil {
+		return err
+	}
+	animation.HasPoster = true
+
+	return nil
+}
+
+// createImagePoster converts the first frame of an animated image to WebP
+// and draws the watermark of the storage path on it.
+func createImagePoster(ctx context.Context, appStoragePath *models.AppStoragePath, data []byte, quality int) (poster []byte, err error) {
+	_, span := tracing.Start(ctx, "bimg.poster")
+	defer func() { tracing.End(span, err) }()
+
+	poster, err = bimg.NewImage(data).Process(bimg.Options{
+		Type:           bimg.WEBP,
+		Quality:        quality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil || !appStoragePath.Watermark.IsEnabled() {
+		return poster, err
+	}
+
+	return watermarkImage(&appStoragePath.Watermark, poster, quality)
+}
+
+// readGifAnimation walks the blocks of a GIF to count the frames and add their delays.
+// Delays below 20 milliseconds are counted as 100 milliseconds, as browsers play them.
+func readGifAnimation(data []byte) (frameCount, duration int) {
+	if len(data) < 13 {
+		return 0, 0
+	}
+
+	position := 13
+	if data[10]&0x80 != 0 {
+		position += 3 << (data[10]&0x07 + 1)
+	}
+
+	delay := 0
+	for position < len(data) {
+		switch data[position] {
+		case 0x21:
+			if position+1 >= len(data) {
+				return frameCount, duration
+			}
+			if data[position+1] == 0xF9 && position+6 < len(data) {
+				delay = int(binary.LittleEndian.Uint16(data[position+4:position+6])) * 10
+			}
+			position = skipGifSubBlocks(data, position+2)
+		case 0x2C:
+			if position+10 > len(data) {
+				return frameCount, duration
+			}
+			flags := data[position+9]
+			position += 10
+			if flags&0x80 != 0 {
+				position += 3 << (flags&0x07 + 1)
+			}
+			position = skipGifSubBlocks(data, position+1)
+
+			if delay < 20 {
+				delay = 100
+			}
+			frameCount++
+			duration += delay
+			delay = 0
+		default:
+			return frameCount, duration
+		}
+	}
+
+	return frameCount, duration
+}
+
+// skipGifSubBlocks returns the position after the data sub-blocks that start at the position.
+func skipGifSubBlocks(data []byte, position int) int {
+	for position < len(data) {
+		size := int(data[position])
+		position++
+		if size == 0 {
+			break
+		}
+		position += size
+	}
+
+	return position
+}
+
+// readWebpAnimation walks the chunks of a WebP to count the ANMF frames and add their durations.
+func readWebpAnimation(data []byte) (frameCount, duration int) {
+	position := 12
+	for position+8 <= len(data) {
+		fourCC := string(data[position : position+4])
+		size := int(binary.LittleEndian.Uint32(data[position+4 : position+8]))
+		payload := position + 8
+
+		if fourCC == "ANMF" && payload+15 <= len(data) {
+			frameCount++
+			duration += int(data[payload+12]) | int(data[payload+13])<<8 | int(data[payload+14])<<16
+		}
+
+		position = payload + size + size%2
+	}
+
+	return frameCount, duration
+}
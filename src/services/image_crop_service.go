@@ -0,0 +1,263 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"context"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/h2non/bimg"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// FocalPoint is the point of interest of an image as x and y percentages.
+type FocalPoint struct {
+	X float64
+	Y float64
+}
+
+// ImageCropFilePath method to get the file path of a crop of the image.
+func ImageCropFilePath(path, filename string, crop enums.Crop) string {
+	return fmt.Sprintf("%s%s-crop-%s.webp", path, filename, crop)
+}
+
+// ImageFocalPoint method to get the focal point of the image or nil when it is not set.
+func ImageFocalPoint(image *models.Image) *FocalPoint {
+	if !image.FocalPointX.Valid || !image.FocalPointY.Valid {
+		return nil
+	}
+
+	return &FocalPoint{X: image.FocalPointX.Float64, Y: image.FocalPointY.Float64}
+}
+
+// SetImageFocalPoint method to set the focal point of the image, or to remove it when nil.
+// The image is not saved. It reports if the focal point is changed.
+func SetImageFocalPoint(image *models.Image, focalPoint *FocalPoint) bool {
+	current := ImageFocalPoint(image)
+	if current == nil && focalPoint == nil || current != nil && focalPoint != nil && *current == *focalPoint {
+		return false
+	}
+
+	image.FocalPointX.Valid = focalPoint != nil
+	image.FocalPointY.Valid = focalPoint != nil
+	if focalPoint != nil {
+		image.FocalPointX.Float64 = focalPoint.X
+		image.FocalPointY.Float64 = focalPoint.Y
+	}
+
+	return true
+}
+
+// CropAndUploadImageFiles method to create the crops of the image in the storage path.
+// The crops are cut around the focal point, or around the most interesting area when it is nil.
+func CropAndUploadImageFiles(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, focalPoint *FocalPoint, quality int) ([]models.ImageCrop, error) {
+	var imageCrops []models.ImageCrop
+
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return imageCrops, err
+	}
+
+	for _, crop := range enums.Crops {
+		_, span := tracing.Start(ctx, "bimg.crop", attribute.String("image.crop", crop.String()))
+		processed, imageCrop, err := cropImage(data, crop, focalPoint, quality, &appStoragePath.Watermark)
+		tracing.End(span, err)
+		if err != nil {
+			return imageCrops, err
+		}
+
+		if err := WriteStorageFileContext(ctx, appStoragePath, ImageCropFilePath(path, filename, crop), processed, nil); err != nil {
+			return imageCrops, err
+		}
+		imageCrops = append(imageCrops, imageCrop)
+	}
+
+	return imageCrops, nil
+}
+
+// RegenerateImageCrops method to create the crops of an image again from the source,
+// for example after the focal point is changed. The new files are written next to
+// the old ones and only take their place after the database is updated.
+func RegenerateImageCrops(image *models.Image, quality int) ([]models.ImageCrop, error) {
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return regenerateImageCrops(&image.Folder.AppStoragePath, path, image, quality)
+}
+
+// regenerateImageCrops creates the crops of an image again in the path of its folder.
+func regenerateImageCrops(appStoragePath *models.AppStoragePath, path string, image *models.Image, quality int) ([]models.ImageCrop, error) {
+	data, err := ReadImageSource(path, image)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the new crops to temporary files.
+	var created []models.ImageCrop
+	removeTemporaryFiles := func() {
+		for i := range created {
+			_ = RemoveFile(ImageCropFilePath(path, image.Name, created[i].Crop) + ".tmp")
+		}
+	}
+	focalPoint := ImageFocalPoint(image)
+	for _, crop := range enums.Crops {
+		processed, imageCrop, err := cropImage(data, crop, focalPoint, quality, &appStoragePath.Watermark)
+		if err != nil {
+			removeTemporaryFiles()
+			return nil, err
+		}
+		created = append(created, imageCrop)
+
+		if err := WriteStorageFile(appStoragePath, ImageCropFilePath(path, image.Name, crop)+".tmp", processed, nil); err != nil {
+			removeTemporaryFiles()
+			return nil, err
+		}
+	}
+
+	if err := ReplaceImageCrops(image, created); err != nil {
+		removeTemporaryFiles()
+		return nil, err
+	}
+
+	// Move the new crops in place.
+	for i := range created {
+		cropPath := ImageCropFilePath(path, image.Name, created[i].Crop)
+		if err := os.Rename(cropPath+".tmp", cropPath); err != nil {
+			return nil, err
+		}
+		_ = DeleteImageFromCache(image.ID, ImageCropCacheSuffix(created[i].Crop))
+	}
+
+	return image.ImageCrops, nil
+}
+
+// ReplaceImageCrops method to replace the crops of an image in a single transaction.
+func ReplaceImageCrops(image *models.Image, crops []models.ImageCrop) error {
+	return database.Pg.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Unscoped().Delete(&models.ImageCrop{}, "image_id = ?", image.ID); result.Error != nil {
+			return result.Error
+		}
+
+		for i := range crops {
+			crops[i].ImageID = image.ID
+		}
+		if len(crops) > 0 {
+			if result := tx.Create(&crops); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		image.ImageCrops = crops
+
+		return nil
+	})
+}
+
+// GetImageCropById method to get a crop of an image by the image ID.
+func GetImageCropById(id uint, crop enums.Crop) (models.ImageCrop, error) {
+	imageCrop := models.ImageCrop{}
+
+	if result := database.Pg.
+		Preload("Image").
+		Preload("Image.Folder").
+		Preload("Image.Folder.AppStoragePath").
+		Find(&imageCrop, "image_id = ? AND crop = ?", id, crop); result.Error != nil {
+		return imageCrop, result.Error
+	}
+
+	return imageCrop, nil
+}
+
+// ImageCropCacheSuffix method to get the cache key suffix of a crop,
+// so crops and web sizes never share a cache key.
+func ImageCropCacheSuffix(crop enums.Crop) string {
+	return "crop-" + crop.String()
+}
+
+// cropImage cuts the image to the aspect ratio of the crop, converts it to WebP and draws
+// the watermark on it when the watermark applies to the crop.
+// Images smaller than the crop are not enlarged, the crop gets smaller instead.
+func cropImage(data []byte, crop enums.Crop, focalPoint *FocalPoint, quality int, watermark *models.Watermark) ([]byte, models.ImageCrop, error) {
+	originalSize, err := OrientedImageSize(data)
+	if err != nil {
+		return nil, models.ImageCrop{}, err
+	}
+
+	width, height := crop.Width(), crop.Height()
+	scale := math.Max(float64(width)/float64(originalSize.Width), float64(height)/float64(originalSize.Height))
+	if scale > 1 {
+		width = int(math.Round(float64(width) / scale))
+		height = int(math.Round(float64(height) / scale))
+		scale = 1
+	}
+
+	var processed []byte
+	if focalPoint == nil {
+		processed, err = bimg.NewImage(data).Process(bimg.Options{
+			Width:          width,
+			Height:         height,
+			Crop:           true,
+			Gravity:        bimg.GravitySmart,
+			Type:           bimg.WEBP,
+			Quality:        quality,
+			Interpretation: bimg.InterpretationSRGB,
+			StripMetadata:  true,
+		})
+	} else {
+		processed, err = cropImageAround(data, originalSize, scale, width, height, focalPoint, quality)
+	}
+	if err != nil {
+		return nil, models.ImageCrop{}, err
+	}
+	if watermark.AppliesTo(crop.String()) {
+		if processed, err = watermarkImage(watermark, processed, quality); err != nil {
+			return nil, models.ImageCrop{}, err
+		}
+	}
+
+	s, err := bimg.NewImage(processed).Size()
+	if err != nil {
+		return nil, models.ImageCrop{}, err
+	}
+
+	return processed, models.ImageCrop{Crop: crop, Width: s.Width, Height: s.Height}, nil
+}
+
+// cropImageAround scales the image to cover the crop and cuts the crop with
+// the focal point as close to the center as the borders of the image allow.
+func cropImageAround(data []byte, originalSize bimg.ImageSize, scale float64, width, height int, focalPoint *FocalPoint, quality int) ([]byte, error) {
+	scaledWidth := max(int(math.Round(float64(originalSize.Width)*scale)), width)
+	scaledHeight := max(int(math.Round(float64(originalSize.Height)*scale)), height)
+
+	scaled, err := bimg.NewImage(data).Process(bimg.Options{
+		Width:          scaledWidth,
+		Height:         scaledHeight,
+		Force:          true,
+		Type:           bimg.PNG,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	left := int(math.Round(focalPoint.X/100*float64(scaledWidth))) - width/2
+	top := int(math.Round(focalPoint.Y/100*float64(scaledHeight))) - height/2
+
+	return bimg.NewImage(scaled).Process(bimg.Options{
+		Left:          min(max(left, 0), scaledWidth-width),
+		Top:           min(max(top, 0), scaledHeight-height),
+		AreaWidth:     width,
+		AreaHeight:    height,
+		Type:          bimg.WEBP,
+		Quality:       quality,
+		StripMetadata: true,
+	})
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/h2non/bimg"
+)
+
+// imageEditQuality is the quality of the edited rendition, which the derivatives are rendered from.
+const imageEditQuality = 95
+
+// ImageOriginalCacheSuffix is the cache key suffix of the original of an edited image.
+const ImageOriginalCacheSuffix = "original"
+
+// ErrImageEditCrop is returned when the crop box of an edit is outside the image.
+var ErrImageEditCrop = errors.New("crop box is outside the image")
+
+// ImageEditedFilePath method to get the file path of the edited rendition of the image.
+func ImageEditedFilePath(path, filename string) string {
+	return fmt.Sprintf("%s%s-edited.webp", path, filename)
+}
+
+// ImageRenditionFilePath method to get the file path of the image that is served by default:
+// the edited rendition of an edited image, otherwise the original.
+func ImageRenditionFilePath(path string, image *models.Image) string {
+	if image.Edit.Edited {
+		return ImageEditedFilePath(path, image.Name)
+	}
+
+	return ImageFilePath(path, image)
+}
+
+// ReadImageSource method to read the image that the derivatives are rendered from:
+// the edited rendition of an edited image, otherwise the original.
+func ReadImageSource(path string, image *models.Image) ([]byte, error) {
+	return ReadStorageFile(ImageRenditionFilePath(path, image))
+}
+
+// EditImage method to render the edited rendition of an image from its untouched original
+// and to render the web sizes, crops and placeholder again from the rendition.
+// An empty edit reverts the image to its original. The image needs its folder, sizes and crops.
+func EditImage(image *models.Image, edit models.ImageEdit, quality int) error {
+	if edit.IsEmpty() {
+		return RevertImageEdit(image, quality)
+	}
+
+	appStoragePath := &image.Folder.AppStoragePath
+	path, err := GetPath(appStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	data, err := ReadStorageFile(ImageFilePath(path, image))
+	if err != nil {
+		return err
+	}
+
+	rendition, size, err := renderImageEdit(data, &edit)
+	if err != nil {
+		return err
+	}
+	edit.Edited = true
+	edit.Width = size.Width
+	edit.Height = size.Height
+
+	// Write the rendition next to the old one and only put it in place after the database is updated.
+	editedPath := ImageEditedFilePath(path, image.Name)
+	if err := WriteStorageFile(appStoragePath, editedPath+".tmp", rendition, nil); err != nil {
+		return err
+	}
+	if err := saveImageEdit(image, edit); err != nil {
+		_ = RemoveFile(editedPath + ".tmp")
+		return err
+	}
+	if err := os.Rename(editedPath+".tmp", editedPath); err != nil {
+		return err
+	}
+	_ = DeleteImageFromCache(image.ID)
+
+	return renderImageDerivatives(image, quality)
+}
+
+// RevertImageEdit method to remove the edit of an image and to render the web sizes,
+// crops and placeholder again from the original.
+func RevertImageEdit(image *models.Image, quality int) error {
+	if !image.Edit.Edited {
+		return nil
+	}
+
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	if err := saveImageEdit(image, models.ImageEdit{}); err != nil {
+		return err
+	}
+	if err := RemoveFile(ImageEditedFilePath(path, image.Name)); err != nil {
+		return err
+	}
+	_ = DeleteImageFromCache(image.ID)
+
+	return renderImageDerivatives(image, quality)
+}
+
+// saveImageEdit stores the edit recipe on the image.
+func saveImageEdit(image *models.Image, edit models.ImageEdit) error {
+	if result := database.Pg.Model(image).Unscoped().Updates(map[string]interface{}{
+		"edit_edited":          edit.Edited,
+		"edit_crop_left":       edit.CropLeft,
+		"edit_crop_top":        edit.CropTop,
+		"edit_crop_width":      edit.CropWidth,
+		"edit_crop_height":     edit.CropHeight,
+		"edit_rotation":        edit.Rotation,
+		"edit_flip_horizontal": edit.FlipHorizontal,
+		"edit_flip_vertical":   edit.FlipVertical,
+		"edit_brightness":      edit.Brightness,
+		"edit_contrast":        edit.Contrast,
+		"edit_width":           edit.Width,
+		"edit_height":          edit.Height,
+	}); result.Error != nil {
+		return result.Error
+	}
+	image.Edit = edit
+
+	return nil
+}
+
+// renderImageDerivatives renders the web sizes, crops, watermarked rendition and placeholder
+// of an image again from its source. Images that are not resized only get a new watermarked
+// rendition and placeholder.
+func renderImageDerivatives(image *models.Image, quality int) error {
+	if len(image.ImageSizes) > 0 || len(image.ImageCrops) > 0 {
+		if _, err := RegenerateImageSizes(image, quality); err != nil {
+			return err
+		}
+		if _, err := RegenerateImageCrops(image, quality); err != nil {
+			return err
+		}
+	} else if err := RegenerateImageWatermark(image, quality); err != nil {
+		return err
+	}
+
+	return RegenerateImagePlaceholder(image)
+}
+
+// renderImageEdit applies the edit to the upright original: first the crop, which is in the
+// coordinates of the displayed original, then the rotation, the flips and the adjustments.
+func renderImageEdit(data []byte, edit *models.ImageEdit) ([]byte, bimg.ImageSize, error) {
+	// Turn the image upright and keep it lossless until the rendition is saved.
+	upright, err := bimg.NewImage(data).Process(bimg.Options{
+		Type:           bimg.PNG,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return nil, bimg.ImageSize{}, err
+	}
+
+	if edit.HasCrop() {
+		size, err := bimg.NewImage(upright).Size()
+		if err != nil {
+			return nil, bimg.ImageSize{}, err
+		}
+		if edit.CropLeft < 0 || edit.CropTop < 0 || edit.CropLeft+edit.CropWidth > size.Width || edit.CropTop+edit.CropHeight > size.Height {
+			return nil, bimg.ImageSize{}, ErrImageEditCrop
+		}
+
+		if upright, err = bimg.NewImage(upright).Extract(edit.CropTop, edit.CropLeft, edit.CropWidth, edit.CropHeight); err != nil {
+			return nil, bimg.ImageSize{}, err
+		}
+	}
+
+	// Brightness is an offset of the pixel values and contrast a factor, both given in percent.
+	rendition, err := bimg.NewImage(upright).Process(bimg.Options{
+		Rotate:         bimg.Angle(edit.Rotation),
+		Flip:           edit.FlipHorizontal,
+		Flop:           edit.FlipVertical,
+		Brightness:     edit.Brightness * 255 / 100,
+		Contrast:       1 + edit.Contrast/100,
+		NoAutoRotate:   true,
+		Type:           bimg.WEBP,
+		Quality:        imageEditQuality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return nil, bimg.ImageSize{}, err
+	}
+
+	size, err := bimg.NewImage(rendition).Size()
+	if err != nil {
+		return nil, bimg.ImageSize{}, err
+	}
+
+	return rendition, size, nil
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/metrics"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/h2non/bimg"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ImageFilePath method to get the file path of the original image.
+func ImageFilePath(path string, image *models.Image) string {
+	return fmt.Sprintf("%s%s.%s", path, image.Name, image.Extension)
+}
+
+// ImageSizeFilePath method to get the file path of a web size of the image.
+func ImageSizeFilePath(path, filename string, size enums.Size) string {
+	return fmt.Sprintf("%s%s-%s.webp", path, filename, size)
+}
+
+// UploadImageFile method to write the original image to the storage path.
+// The filename includes the extension.
+func UploadImageFile(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, onProgress func(percentage float64)) (width, height int, err error) {
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return 0, 0, err
+	}
+
+	size, err := OrientedImageSize(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := WriteStorageFileContext(ctx, appStoragePath, path+filename, data, onProgress); err != nil {
+		return 0, 0, err
+	}
+	metrics.BytesUploaded.WithLabelValues(appStoragePath.AppName).Add(float64(len(data)))
+
+	return size.Width, size.Height, nil
+}
+
+// ConvertAndUploadImageFiles method to create the web sizes of the image in the storage path.
+// Only sizes smaller than the original are created, except for vector images which
+// are rendered at every size. Animated images get animated sizes,
+// still sizes or no sizes as configured on the storage path. The onProgress callback
+// receives the amount of created sizes and the total amount to create.
+func ConvertAndUploadImageFiles(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, quality int, onProgress func(done, total int)) ([]models.ImageSize, error) {
+	var imageSizes []models.ImageSize
+
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return imageSizes, err
+	}
+
+	animation := ReadImageAnimation(data)
+	if !IsResizedAnimation(appStoragePath, &animation) {
+		return imageSizes, nil
+	}
+	animated := IsAnimatedSize(appStoragePath, &animation)
+	vector := IsVectorImage(data)
+
+	originalSize, err := OrientedImageSize(data)
+	if err != nil {
+		return imageSizes, err
+	}
+
+	var sizes []enums.Size
+	for _, size := range enums.Sizes {
+		if vector || originalSize.Width > size.Width() {
+			sizes = append(sizes, size)
+		}
+	}
+
+	for i, size := range sizes {
+		imageSize, err := ConvertAndUploadImageFile(ctx, appStoragePath, path, filename, data, size, quality, animated)
+		if err != nil {
+			return imageSizes, err
+		}
+		imageSizes = append(imageSizes, imageSize)
+
+		if onProgress != nil {
+			onProgress(i+1, len(sizes))
+		}
+	}
+
+	return imageSizes, nil
+}
+
+// ConvertAndUploadImageFile method to create a single web size of the image in the path
+// of the storage path. The filename excludes the extension.
+func ConvertAndUploadImageFile(ctx context.Context, appStoragePath *models.AppStoragePath, path, filename string, data []byte, size enums.Size, quality int, animated bool) (models.ImageSize, error) {
+	start := time.Now()
+	defer func() {
+		metrics.ImageConvertDuration.WithLabelValues(size.String()).Observe(time.Since(start).Seconds())
+	}()
+
+	_, span := tracing.Start(ctx, "bimg.resize", attribute.String("image.size", size.String()), attribute.Bool("image.animated", animated))
+	processed, imageSize, err := convertImage(data, size, quality, animated, &appStoragePath.Watermark)
+	tracing.End(span, err)
+	if err != nil {
+		return models.ImageSize{}, err
+	}
+
+	if err := WriteStorageFileContext(ctx, appStoragePath, ImageSizeFilePath(path, filename, size), processed, nil); err != nil {
+		return models.ImageSize{}, err
+	}
+
+	return imageSize, nil
+}
+
+// RegenerateImageSizes method to create the web sizes of an image again from the source,
+// which is the edited rendition of an edited image.
+// Only the given sizes are replaced, or every size when none are given. Sizes that
+// are not smaller than the original are removed. The new files are written next to
+// the old ones and only take their place after the database is updated.
+func RegenerateImageSizes(image *models.Image, quality int, sizes ...enums.Size) ([]models.ImageSize, error) {
+	all := len(sizes) == 0
+	if all {
+		sizes = enums.Sizes
+	}
+
+	appStoragePath := &image.Folder.AppStoragePath
+	path, err := GetPath(appStoragePath, image.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ReadImageSource(path, image)
+	if err != nil {
+		return nil, err
+	}
+
+	originalSize, err := OrientedImageSize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	animation := ReadImageAnimation(data)
+	resized := IsResizedAnimation(appStoragePath, &animation)
+	animated := IsAnimatedSize(appStoragePath, &animation)
+	vector := IsVectorImage(data)
+
+	// Write the new web sizes to temporary files.
+	var created []models.ImageSize
+	removeTemporaryFiles := func() {
+		for i := range created {
+			_ = RemoveFile(ImageSizeFilePath(path, image.Name, created[i].Size) + ".tmp")
+		}
+	}
+	for _, size := range sizes {
+		if !resized || !vector && originalSize.Width <= size.Width() {
+			continue
+		}
+
+		processed, imageSize, err := convertImage(data, size, quality, animated, &appStoragePath.Watermark)
+		if err != nil {
+			removeTemporaryFiles()
+			return nil, err
+		}
+		created = append(created, imageSize)
+
+		if err := WriteStorageFile(appStoragePath, ImageSizeFilePath(path, image.Name, size)+".tmp", processed, nil); err != nil {
+			removeTemporaryFiles()
+			return nil, err
+		}
+	}
+
+	// Keep the web sizes that are not regenerated.
+	imageSizes := make([]models.ImageSize, 0, len(enums.Sizes))
+	var removed []enums.Size
+	for i := range image.ImageSizes {
+		if slices.Contains(sizes, image.ImageSizes[i].Size) {
+			removed = append(removed, image.ImageSizes[i].Size)
+			continue
+		}
+		imageSizes = append(imageSizes, models.ImageSize{
+			Size:   image.ImageSizes[i].Size,
+			Width:  image.ImageSizes[i].Width,
+			Height: image.ImageSizes[i].Height,
+		})
+	}
+	imageSizes = append(imageSizes, created...)
+
+	if err := ReplaceImageSizes(image, imageSizes); err != nil {
+		removeTemporaryFiles()
+		return nil, err
+	}
+
+	// Move the new web sizes in place and remove the sizes that are no longer created.
+	for _, size := range removed {
+		_ = DeleteImageFromCache(image.ID, size.String())
+		if !slices.ContainsFunc(created, func(imageSize models.ImageSize) bool { return imageSize.Size == size }) {
+			if err := RemoveFile(ImageSizeFilePath(path, image.Name, size)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := range created {
+		sizePath := ImageSizeFilePath(path, image.Name, created[i].Size)
+		if err := os.Rename(sizePath+".tmp", sizePath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create or remove the poster and the watermarked rendition together with all sizes.
+	if all {
+		if err := regenerateImagePoster(path, image, data, animation, quality); err != nil {
+			return nil, err
+		}
+		if err := regenerateImageWatermark(path, image, data, quality); err != nil {
+			return nil, err
+		}
+	}
+
+	return image.ImageSizes, nil
+}
+
+// regenerateImagePoster writes the poster of an animated image again, or removes it
+// when the storage path does not ask for one, and stores the animation of the image.
+func regenerateImagePoster(path string, image *models.Image, data []byte, animation models.ImageAnimation, quality int) error {
+	if err := CreateImagePosterFile(context.Background(), &image.Folder.AppStoragePath, image.FolderID, image.Name, data, &animation, quality); err != nil {
+		return err
+	}
+	if !animation.HasPoster {
+		if err := RemoveFile(ImagePosterFilePath(path, image.Name)); err != nil {
+			return err
+		}
+	}
+
+	if result := database.Pg.Model(image).Unscoped().Updates(map[string]interface{}{
+		"frame_count": animation.FrameCount,
+		"duration":    animation.Duration,
+		"has_poster":  animation.HasPoster,
+	}); result.Error != nil {
+		return result.Error
+	}
+	image.Animation = animation
+	_ = DeleteImageFromCache(image.ID, ImagePosterCacheSuffix)
+
+	return nil
+}
+
+// RegenerateImages method to regenerate the web sizes of the images as the work of a job.
+// When every size is regenerated, the crops are regenerated as well.
+// A failing image does not stop the job. The onProgress callback is called after each image.
+func RegenerateImages(job *models.Job, images []models.Image, quality int, sizes []enums.Size, onProgress func(image *models.Image)) error {
+	for i := range images {
+		image := &images[i]
+
+		if err := regenerateLockedImage(image, quality, sizes); err != nil {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: %v", image.ID, err)
+		} else {
+			job.Done++
+		}
+
+		if err := SaveJob(job); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(image)
+		}
+	}
+
+	return nil
+}
+
+// regenerateLockedImage regenerates the web sizes of the image, and the crops, while the image is locked.
+// The image is read again after locking it, because it can be changed since the job started.
+func regenerateLockedImage(image *models.Image, quality int, sizes []enums.Size) error {
+	unlock, err := LockImage(context.Background(), image.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := GetImageById(image.ID, true)
+	if err != nil {
+		return err
+	} else if current.ID == 0 {
+		return errors.New("image does not exist")
+	}
+	*image = current
+
+	if image.Broken {
+		return errors.New("original is broken")
+	}
+	if _, err := RegenerateImageSizes(image, quality, sizes...); err != nil {
+		return err
+	}
+	_, err = regenerateAllImageCrops(image, quality, sizes)
+
+	return err
+}
+
+// regenerateAllImageCrops regenerates the crops as well when all web sizes are regenerated.
+func regenerateAllImageCrops(image *models.Image, quality int, sizes []enums.Size) ([]models.ImageCrop, error) {
+	if len(sizes) > 0 {
+		return image.ImageCrops, nil
+	}
+
+	return RegenerateImageCrops(image, quality)
+}
+
+// OrientedImageSize method to get the size of the image as it is displayed,
+// so with the width and height swapped when the EXIF orientation rotates it.
+func OrientedImageSize(data []byte) (bimg.ImageSize, error) {
+	metadata, err := bimg.Metadata(data)
+	if err != nil {
+		return bimg.ImageSize{}, err
+	}
+
+	if metadata.Orientation >= 5 && metadata.Orientation <= 8 {
+		return bimg.ImageSize{Width: metadata.Size.Height, Height: metadata.Size.Width}, nil
+	}
+
+	return metadata.Size, nil
+}
+
+// convertImage resizes the image to the width of the size, converts it to WebP
+// and draws the watermark on it when the watermark applies to the size.
+func convertImage(data []byte, size enums.Size, quality int, animated bool, watermark *models.Watermark) ([]byte, models.ImageSize, error) {
+	processed, imageSize, err := resizeImage(data, size, quality, animated)
+	if err != nil || !watermark.AppliesTo(size.String()) {
+		return processed, imageSize, err
+	}
+
+	if processed, err = watermarkImage(watermark, processed, quality); err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	return processed, imageSize, nil
+}
+
+// resizeImage resizes the image to the width of the size and converts it to WebP.
+// The image is rotated by its EXIF orientation and the metadata is not copied.
+// Animated images keep every frame when animated is set, otherwise only the first frame is used.
+// Vector images are rendered at the size instead of being resized.
+func resizeImage(data []byte, size enums.Size, quality int, animated bool) ([]byte, models.ImageSize, error) {
+	if animated {
+		return convertAnimatedImage(data, size, quality)
+	}
+	if IsVectorImage(data) {
+		return convertVectorImage(data, size, quality)
+	}
+
+	originalSize, err := OrientedImageSize(data)
+	if err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	width := size.Width()
+	newHeight := originalSize.Height * width / originalSize.Width
+	processed, err := bimg.NewImage(data).Process(bimg.Options{
+		Width:          width,
+		Height:         newHeight,
+		Embed:          true,
+		Type:           bimg.WEBP,
+		Quality:        quality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	s, err := bimg.NewImage(processed).Size()
+	if err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	return processed, models.ImageSize{Size: size, Width: s.Width, Height: s.Height}, nil
+}
+
+// DeleteImageFiles method to delete the original, the edited and watermarked renditions, the web sizes and the crops of the image from the storage path.
+// Files that are already missing are skipped.
+func DeleteImageFiles(image *models.Image) error {
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	if err := RemoveFile(ImageFilePath(path, image)); err != nil {
+		return err
+	}
+
+	for i := range image.ImageSizes {
+		if err := RemoveFile(ImageSizeFilePath(path, image.Name, image.ImageSizes[i].Size)); err != nil {
+			return err
+		}
+	}
+
+	for i := range image.ImageCrops {
+		if err := RemoveFile(ImageCropFilePath(path, image.Name, image.ImageCrops[i].Crop)); err != nil {
+			return err
+		}
+	}
+
+	if image.Animation.HasPoster {
+		if err := RemoveFile(ImagePosterFilePath(path, image.Name)); err != nil {
+			return err
+		}
+	}
+
+	if image.Edit.Edited {
+		if err := RemoveFile(ImageEditedFilePath(path, image.Name)); err != nil {
+			return err
+		}
+	}
+
+	return RemoveFile(ImageWatermarkedFilePath(path, image.Name))
+}
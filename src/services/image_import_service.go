@@ -0,0 +1,92 @@
+package services
+
+import (
+	"api-file/main/src/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors of the steps of importing an image, they wrap the error of the step.
+var (
+	ErrImageInvalid = errors.New("invalid image")
+	ErrImageUpload  = errors.New("image can not be uploaded")
+	ErrImageConvert = errors.New("image can not be converted")
+)
+
+// ImportImageOptions are the options of importing an image.
+// The onProgress callback receives the percentage of the written files.
+type ImportImageOptions struct {
+	Quality        int
+	IsNotResizable bool
+	Description    *string
+	OnProgress     func(percentage float64)
+}
+
+// ImportImage method to store a new image in the folder: the SVG is sanitized, the metadata is stripped
+// as configured on the storage path, and the original, the web sizes, the crops, the poster, the watermarked
+// rendition and the placeholder are created before the image is saved.
+// The caller checks the name and the space of the storage path.
+func ImportImage(ctx context.Context, storagePath *models.AppStoragePath, folderID uint, filename, extension, mimeType string, data []byte, options ImportImageOptions) (models.Image, error) {
+	var err error
+	onProgress := func(percentage float64) {
+		if options.OnProgress != nil {
+			options.OnProgress(percentage)
+		}
+	}
+
+	// Remove scripts and external references from an SVG.
+	if IsSvgImage(mimeType, extension, data) {
+		if data, err = SanitizeSvg(data); err != nil {
+			return models.Image{}, fmt.Errorf("%w: %w", ErrImageInvalid, err)
+		}
+	}
+
+	// Read the metadata and strip it from the original.
+	data, metadata, err := ProcessImageMetadata(ctx, data, storagePath.StripMetadata)
+	if err != nil {
+		return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+	}
+
+	// Upload the original, which is a seventh of the work when the web sizes are created.
+	progress := 100.0
+	if !options.IsNotResizable {
+		progress = 100.0 / 7
+	}
+
+	width, height, err := UploadImageFile(ctx, storagePath, folderID, fmt.Sprintf("%s.%s", filename, extension), data, func(percentage float64) {
+		onProgress(progress * percentage / 100.0)
+	})
+	if err != nil {
+		return models.Image{}, fmt.Errorf("%w: %w", ErrImageUpload, err)
+	}
+
+	// Create the web sizes, the crops and the poster of an animated image.
+	var imageSizes []models.ImageSize
+	var imageCrops []models.ImageCrop
+	animation := ReadImageAnimation(data)
+	if !options.IsNotResizable {
+		if imageSizes, err = ConvertAndUploadImageFiles(ctx, storagePath, folderID, filename, data, options.Quality, func(done, total int) {
+			onProgress(progress + (100.0-progress)*float64(done)/float64(total))
+		}); err != nil {
+			return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+		}
+		if imageCrops, err = CropAndUploadImageFiles(ctx, storagePath, folderID, filename, data, nil, options.Quality); err != nil {
+			return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+		}
+		if err := CreateImagePosterFile(ctx, storagePath, folderID, filename, data, &animation, options.Quality); err != nil {
+			return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+		}
+	}
+
+	// Create the watermarked rendition and the placeholder.
+	if err := CreateImageWatermarkedFile(ctx, storagePath, folderID, filename, data, options.Quality); err != nil {
+		return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+	}
+	placeholder, err := GenerateImagePlaceholder(ctx, data)
+	if err != nil {
+		return models.Image{}, fmt.Errorf("%w: %w", ErrImageConvert, err)
+	}
+
+	return CreateImage(folderID, filename, extension, mimeType, len(data), width, height, options.Description, metadata, placeholder, animation, imageSizes, imageCrops)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	imageLockExpiration = 10 * time.Minute
+	imageLockTimeout    = 30 * time.Second
+	imageLockRetry      = 100 * time.Millisecond
+)
+
+// ErrImageLocked is returned when the files of an image are still changed by another request or job.
+var ErrImageLocked = errors.New("image is locked")
+
+// unlockImageScript releases the lock only when it is still held by the token, not when it expired
+// and is taken by another request.
+var unlockImageScript = valkey.NewLuaScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// LockImage method to lock an image while its files are changed, so requests and jobs on several servers
+// do not change them at the same time. It waits for the lock and returns the func that releases it.
+// The lock expires when it is never released, like after a crash.
+func LockImage(ctx context.Context, imageID uint) (func(), error) {
+	key := imageLockCacheKey(imageID)
+	token := uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(ctx, imageLockTimeout)
+	defer cancel()
+
+	for {
+		result := cache.Valkey.Do(ctx, cache.Valkey.B().Set().Key(key).Value(token).Nx().Ex(imageLockExpiration).Build())
+		if err := result.Error(); err == nil {
+			break
+		} else if !valkey.IsValkeyNil(err) {
+			if ctx.Err() != nil {
+				return nil, ErrImageLocked
+			}
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrImageLocked
+		case <-time.After(imageLockRetry):
+		}
+	}
+
+	return func() {
+		if err := unlockImageScript.Exec(context.Background(), cache.Valkey, []string{key}, []string{token}).Error(); err != nil {
+			log.Printf("Error unlocking image %d: %v", imageID, err)
+		}
+	}, nil
+}
+
+// Creates a key for the lock of an image.
+func imageLockCacheKey(imageID uint) string {
+	return fmt.Sprintf("lock:image:%d", imageID)
+}
@@ -0,0 +1,659 @@
+package services
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/h2non/bimg"
+)
+
+// EXIF tags, markers and headers of the metadata blocks.
+const (
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagOrientation       = 0x0112
+	tagDateTime          = 0x0132
+	tagArtist            = 0x013B
+	tagCopyright         = 0x8298
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagDateTimeOriginal  = 0x9003
+	tagGPSLatitudeRef    = 0x0001
+	tagGPSLatitude       = 0x0002
+	tagGPSLongitudeRef   = 0x0003
+	tagGPSLongitude      = 0x0004
+	tagGPSAltitudeRef    = 0x0005
+	tagGPSAltitude       = 0x0006
+	exifDateTimeLayout   = "2006:01:02 15:04:05"
+	iptcDateLayout       = "20060102"
+	xmpNamespace         = "http://ns.adobe.com/xap/1.0/\x00"
+	exifHeader           = "Exif\x00\x00"
+	photoshopHeader      = "Photoshop 3.0\x00"
+	pngSignature         = "\x89PNG\r\n\x1a\n"
+	webpVP8XExifFlag     = 0x08
+	webpVP8XXMPFlag      = 0x04
+	jpegStartOfScan      = 0xDA
+	jpegComment          = 0xFE
+	jpegApp1             = 0xE1
+	jpegApp13            = 0xED
+	iptcRecordCopyright  = 116
+	iptcRecordByline     = 80
+	iptcRecordDateCreate = 55
+)
+
+var (
+	xmpRights  = regexp.MustCompile(`(?s)<dc:rights>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpCreator = regexp.MustCompile(`(?s)<dc:creator>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// tiffEntry is a single entry of an IFD in TIFF data.
+type tiffEntry struct {
+	offset    int
+	tag       uint16
+	valueType uint16
+	count     uint32
+}
+
+// tiffReader reads IFDs from the TIFF data of an EXIF block.
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ExtractImageMetadata method to read the camera metadata from the EXIF, IPTC and XMP
+// blocks of a JPEG, PNG or WebP image. Unknown formats return empty metadata.
+func ExtractImageMetadata(data []byte) models.ImageMetadata {
+	metadata := models.ImageMetadata{}
+
+	for _, block := range metadataBlocks(data) {
+		switch {
+		case strings.HasPrefix(string(block), exifHeader):
+			readExif(block[len(exifHeader):], &metadata)
+		case strings.HasPrefix(string(block), "II*\x00") || strings.HasPrefix(string(block), "MM\x00*"):
+			readExif(block, &metadata)
+		case strings.HasPrefix(string(block), photoshopHeader):
+			readIptc(block[len(photoshopHeader):], &metadata)
+		case bytes.Contains(block, []byte("<x:xmpmeta")):
+			readXmp(block, &metadata)
+		}
+	}
+
+	return metadata
+}
+
+// ProcessImageMetadata method to read the camera metadata of an uploaded image and remove
+// the metadata from the original as configured on the storage path. When all metadata is
+// removed the image is rotated by its EXIF orientation first, which re-encodes it.
+// The location is not kept in the returned metadata when the storage path strips it.
+func ProcessImageMetadata(ctx context.Context, data []byte, strip enums.StripMetadata) ([]byte, models.ImageMetadata, error) {
+	metadata := ExtractImageMetadata(data)
+
+	animation := ReadImageAnimation(data)
+	if strip == enums.StripAll && metadata.Orientation > 1 && !animation.IsAnimated() {
+		_, span := tracing.Start(ctx, "bimg.autorotate")
+		rotated, err := bimg.NewImage(data).AutoRotate()
+		tracing.End(span, err)
+		if err != nil {
+			return nil, metadata, err
+		}
+		data = rotated
+		metadata.Orientation = 1
+	}
+
+	if strip == enums.StripLocation || strip == enums.StripAll {
+		metadata.Latitude = nil
+		metadata.Longitude = nil
+		metadata.Altitude = nil
+	}
+
+	return StripImageMetadata(data, strip), metadata, nil
+}
+
+// StripImageMetadata method to remove metadata from a JPEG, PNG or WebP image without
+// re-encoding it. With StripLocation only the GPS data of the EXIF block and the XMP block
+// are removed, with StripAll every EXIF, IPTC, XMP and comment block is removed.
+// Other formats are returned unchanged.
+func StripImageMetadata(data []byte, strip enums.StripMetadata) []byte {
+	if strip != enums.StripLocation && strip != enums.StripAll {
+		return data
+	}
+
+	// The GPS data is removed in place, so work on a copy.
+	data = bytes.Clone(data)
+
+	switch {
+	case len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8:
+		return stripJpeg(data, strip)
+	case strings.HasPrefix(string(data), pngSignature):
+		return stripPng(data, strip)
+	case isWebp(data):
+		return stripWebp(data, strip)
+	default:
+		return data
+	}
+}
+
+// metadataBlocks returns the raw metadata blocks of a JPEG, PNG or WebP image.
+func metadataBlocks(data []byte) [][]byte {
+	var blocks [][]byte
+
+	switch {
+	case len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8:
+		walkJpeg(data, func(marker byte, segment []byte) bool {
+			if marker == jpegApp1 || marker == jpegApp13 {
+				blocks = append(blocks, segment)
+			}
+			return true
+		})
+	case strings.HasPrefix(string(data), pngSignature):
+		walkPng(data, func(chunkType string, chunk []byte) bool {
+			if chunkType == "eXIf" || chunkType == "iTXt" {
+				blocks = append(blocks, chunk)
+			}
+			return true
+		})
+	case isWebp(data):
+		walkWebp(data, func(chunkType string, chunk []byte) bool {
+			if chunkType == "EXIF" || chunkType == "XMP " {
+				blocks = append(blocks, chunk)
+			}
+			return true
+		})
+	}
+
+	return blocks
+}
+
+// readExif reads the metadata from the TIFF data of an EXIF block.
+func readExif(data []byte, metadata *models.ImageMetadata) {
+	reader, ifd, ok := newTiffReader(data)
+	if !ok {
+		return
+	}
+
+	for _, entry := range reader.entries(ifd) {
+		switch entry.tag {
+		case tagMake:
+			metadata.CameraMake = reader.ascii(entry)
+		case tagModel:
+			metadata.CameraModel = reader.ascii(entry)
+		case tagOrientation:
+			metadata.Orientation = int(reader.short(entry))
+		case tagDateTime:
+			if metadata.TakenAt == nil {
+				metadata.TakenAt = parseTime(exifDateTimeLayout, reader.ascii(entry))
+			}
+		case tagArtist:
+			metadata.Creator = reader.ascii(entry)
+		case tagCopyright:
+			metadata.Copyright = reader.ascii(entry)
+		case tagExifIFD:
+			for _, exifEntry := range reader.entries(int(reader.long(entry))) {
+				if exifEntry.tag == tagDateTimeOriginal {
+					if takenAt := parseTime(exifDateTimeLayout, reader.ascii(exifEntry)); takenAt != nil {
+						metadata.TakenAt = takenAt
+					}
+				}
+			}
+		case tagGPSIFD:
+			readGps(reader, int(reader.long(entry)), metadata)
+		}
+	}
+}
+
+// readGps reads the location from the GPS IFD.
+func readGps(reader *tiffReader, ifd int, metadata *models.ImageMetadata) {
+	var latitudeRef, longitudeRef string
+	var latitude, longitude []float64
+	var altitude *float64
+	var belowSeaLevel bool
+
+	for _, entry := range reader.entries(ifd) {
+		switch entry.tag {
+		case tagGPSLatitudeRef:
+			latitudeRef = reader.ascii(entry)
+		case tagGPSLatitude:
+			latitude = reader.rationals(entry)
+		case tagGPSLongitudeRef:
+			longitudeRef = reader.ascii(entry)
+		case tagGPSLongitude:
+			longitude = reader.rationals(entry)
+		case tagGPSAltitudeRef:
+			belowSeaLevel = reader.byteValue(entry) == 1
+		case tagGPSAltitude:
+			if values := reader.rationals(entry); len(values) == 1 {
+				altitude = &values[0]
+			}
+		}
+	}
+
+	if len(latitude) == 3 && len(longitude) == 3 {
+		lat := latitude[0] + latitude[1]/60 + latitude[2]/3600
+		lon := longitude[0] + longitude[1]/60 + longitude[2]/3600
+		if latitudeRef == "S" {
+			lat = -lat
+		}
+		if longitudeRef == "W" {
+			lon = -lon
+		}
+		metadata.Latitude = &lat
+		metadata.Longitude = &lon
+	}
+
+	if altitude != nil && belowSeaLevel {
+		*altitude = -*altitude
+	}
+	metadata.Altitude = altitude
+}
+
+// readIptc reads the creator, copyright and creation date from the IPTC record in a Photoshop block.
+// Values that are already read from the EXIF block are kept.
+func readIptc(data []byte, metadata *models.ImageMetadata) {
+	for i := 0; i+12 <= len(data) && string(data[i:i+4]) == "8BIM"; {
+		id := binary.BigEndian.Uint16(data[i+4:])
+		nameLength := int(data[i+6]) + 1
+		if nameLength%2 == 1 {
+			nameLength++
+		}
+		sizeOffset := i + 6 + nameLength
+		if sizeOffset+4 > len(data) {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(data[sizeOffset:]))
+		start := sizeOffset + 4
+		if start+size > len(data) {
+			return
+		}
+
+		if id == 0x0404 {
+			readIptcRecords(data[start:start+size], metadata)
+		}
+
+		i = start + size + size%2
+	}
+}
+
+// readIptcRecords reads the datasets of the IPTC application record.
+func readIptcRecords(data []byte, metadata *models.ImageMetadata) {
+	for i := 0; i+5 <= len(data) && data[i] == 0x1C; {
+		record, dataset := data[i+1], data[i+2]
+		size := int(binary.BigEndian.Uint16(data[i+3:]))
+		start := i + 5
+		if start+size > len(data) {
+			return
+		}
+		value := strings.TrimSpace(string(data[start : start+size]))
+
+		if record == 2 {
+			switch dataset {
+			case iptcRecordCopyright:
+				if metadata.Copyright == "" {
+					metadata.Copyright = value
+				}
+			case iptcRecordByline:
+				if metadata.Creator == "" {
+					metadata.Creator = value
+				}
+			case iptcRecordDateCreate:
+				if metadata.TakenAt == nil {
+					metadata.TakenAt = parseTime(iptcDateLayout, value)
+				}
+			}
+		}
+
+		i = start + size
+	}
+}
+
+// readXmp reads the creator and rights from an XMP packet.
+// Values that are already read from the EXIF or IPTC block are kept.
+func readXmp(data []byte, metadata *models.ImageMetadata) {
+	if match := xmpRights.FindSubmatch(data); match != nil && metadata.Copyright == "" {
+		metadata.Copyright = strings.TrimSpace(string(match[1]))
+	}
+	if match := xmpCreator.FindSubmatch(data); match != nil && metadata.Creator == "" {
+		metadata.Creator = strings.TrimSpace(string(match[1]))
+	}
+}
+
+// stripJpeg removes the metadata segments of a JPEG image.
+func stripJpeg(data []byte, strip enums.StripMetadata) []byte {
+	result := make([]byte, 0, len(data))
+	result = append(result, data[:2]...)
+	position := 2
+	scanStart := -1
+
+	walkJpeg(data, func(marker byte, segment []byte) bool {
+		start := position
+		position += 4 + len(segment)
+		if marker == jpegStartOfScan {
+			scanStart = start
+			return false
+		}
+
+		isExif := marker == jpegApp1 && strings.HasPrefix(string(segment), exifHeader)
+		isXmp := marker == jpegApp1 && strings.HasPrefix(string(segment), xmpNamespace)
+		isIptc := marker == jpegApp13 || marker == jpegComment
+
+		switch {
+		case strip == enums.StripAll && (isExif || isXmp || isIptc):
+			return true
+		case strip == enums.StripLocation && isXmp:
+			return true
+		case strip == enums.StripLocation && isExif:
+			removeGps(data[start+4+len(exifHeader) : position])
+		}
+
+		result = append(result, data[start:position]...)
+		return true
+	})
+	if scanStart < 0 {
+		// Keep the data after the last segment that could be read, like the data of a JPEG without start of scan.
+		return append(result, data[position:]...)
+	}
+
+	// Copy the start of scan and the compressed image data.
+	return append(result, data[scanStart:]...)
+}
+
+// stripPng removes the metadata chunks of a PNG image.
+func stripPng(data []byte, strip enums.StripMetadata) []byte {
+	result := make([]byte, 0, len(data))
+	result = append(result, data[:len(pngSignature)]...)
+	position := len(pngSignature)
+
+	walkPng(data, func(chunkType string, chunk []byte) bool {
+		start := position
+		position += 12 + len(chunk)
+
+		isXmp := chunkType == "iTXt" && strings.HasPrefix(string(chunk), "XML:com.adobe.xmp")
+		switch {
+		case strip == enums.StripAll && (chunkType == "eXIf" || chunkType == "iTXt" || chunkType == "tEXt" || chunkType == "zTXt" || chunkType == "tIME"):
+			return true
+		case strip == enums.StripLocation && isXmp:
+			return true
+		case strip == enums.StripLocation && chunkType == "eXIf":
+			removeGps(data[start+8 : position-4])
+			binary.BigEndian.PutUint32(data[position-4:], crc32.ChecksumIEEE(data[start+4:position-4]))
+		}
+
+		result = append(result, data[start:position]...)
+		return true
+	})
+
+	return result
+}
+
+// stripWebp removes the metadata chunks of an extended WebP image.
+func stripWebp(data []byte, strip enums.StripMetadata) []byte {
+	result := make([]byte, 0, len(data))
+	result = append(result, data[:12]...)
+	position := 12
+
+	walkWebp(data, func(chunkType string, chunk []byte) bool {
+		start := position
+		end := start + 8 + len(chunk)
+		position = end + len(chunk)%2
+
+		switch {
+		case strip == enums.StripAll && chunkType == "EXIF":
+			return true
+		case chunkType == "XMP ":
+			return true
+		case strip == enums.StripLocation && chunkType == "EXIF":
+			tiff := data[start+8 : start+8+len(chunk)]
+			if strings.HasPrefix(string(tiff), exifHeader) {
+				tiff = tiff[len(exifHeader):]
+			}
+			removeGps(tiff)
+		case chunkType == "VP8X" && len(chunk) > 0:
+			flags := webpVP8XXMPFlag
+			if strip == enums.StripAll {
+				flags |= webpVP8XExifFlag
+			}
+			data[start+8] &^= byte(flags)
+		}
+
+		// An odd sized chunk is padded, also when the last chunk misses its padding byte.
+		result = append(result, data[start:end]...)
+		if len(chunk)%2 == 1 {
+			result = append(result, 0)
+		}
+		return true
+	})
+
+	binary.LittleEndian.PutUint32(result[4:], uint32(len(result)-8))
+
+	return result
+}
+
+// removeGps empties the GPS IFD of the TIFF data in place, so the location is no longer readable.
+func removeGps(data []byte) {
+	reader, ifd, ok := newTiffReader(data)
+	if !ok {
+		return
+	}
+
+	for _, entry := range reader.entries(ifd) {
+		if entry.tag != tagGPSIFD {
+			continue
+		}
+
+		gpsIFD := int(reader.long(entry))
+		for _, gpsEntry := range reader.entries(gpsIFD) {
+			if start, end, ok := reader.valueRange(gpsEntry); ok {
+				clear(data[start:end])
+			}
+			clear(data[gpsEntry.offset : gpsEntry.offset+12])
+		}
+
+		// Only an IFD that is inside the data is emptied, an offset of 0 is the byte order mark.
+		if gpsIFD > 0 && gpsIFD+2 <= len(data) {
+			reader.order.PutUint16(data[gpsIFD:], 0)
+		}
+	}
+}
+
+// walkJpeg calls the callback for every segment of a JPEG image until the start of scan.
+func walkJpeg(data []byte, callback func(marker byte, segment []byte) bool) {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF || data[i+1] == 0xFF {
+			return
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return
+		}
+		if !callback(marker, data[i+4:i+2+size]) {
+			return
+		}
+		i += 2 + size
+	}
+}
+
+// walkPng calls the callback for every chunk of a PNG image.
+func walkPng(data []byte, callback func(chunkType string, chunk []byte) bool) {
+	for i := len(pngSignature); i+12 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[i:]))
+		if i+12+size > len(data) {
+			return
+		}
+		if !callback(string(data[i+4:i+8]), data[i+8:i+8+size]) {
+			return
+		}
+		i += 12 + size
+	}
+}
+
+// walkWebp calls the callback for every chunk of a WebP image.
+func walkWebp(data []byte, callback func(chunkType string, chunk []byte) bool) {
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			return
+		}
+		if !callback(string(data[i:i+4]), data[i+8:i+8+size]) {
+			return
+		}
+		i += 8 + size + size%2
+	}
+}
+
+// isWebp checks if the data is a WebP image.
+func isWebp(data []byte) bool {
+	return len(data) > 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// parseTime parses the time and returns nil when it is invalid.
+func parseTime(layout, value string) *time.Time {
+	parsed, err := time.Parse(layout, strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+
+	return &parsed
+}
+
+// newTiffReader creates a reader for TIFF data and returns the offset of the first IFD.
+func newTiffReader(data []byte) (*tiffReader, int, bool) {
+	if len(data) < 8 {
+		return nil, 0, false
+	}
+
+	reader := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		reader.order = binary.LittleEndian
+	case "MM":
+		reader.order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+
+	return reader, int(reader.order.Uint32(data[4:])), true
+}
+
+// entries returns the entries of the IFD at the offset.
+func (r *tiffReader) entries(offset int) []tiffEntry {
+	if offset <= 0 || offset+2 > len(r.data) {
+		return nil
+	}
+
+	count := int(r.order.Uint16(r.data[offset:]))
+	entries := make([]tiffEntry, 0, count)
+	for i := 0; i < count; i++ {
+		start := offset + 2 + i*12
+		if start+12 > len(r.data) {
+			break
+		}
+		entries = append(entries, tiffEntry{
+			offset:    start,
+			tag:       r.order.Uint16(r.data[start:]),
+			valueType: r.order.Uint16(r.data[start+2:]),
+			count:     r.order.Uint32(r.data[start+4:]),
+		})
+	}
+
+	return entries
+}
+
+// valueRange returns the range of the value of the entry, which is stored
+// inside the entry when it fits in four bytes.
+func (r *tiffReader) valueRange(entry tiffEntry) (start, end int, ok bool) {
+	var size int
+	switch entry.valueType {
+	case 1, 2, 6, 7:
+		size = 1
+	case 3, 8:
+		size = 2
+	case 4, 9, 11:
+		size = 4
+	case 5, 10, 12:
+		size = 8
+	default:
+		return 0, 0, false
+	}
+
+	length := size * int(entry.count)
+	if length <= 4 {
+		start = entry.offset + 8
+	} else {
+		start = int(r.order.Uint32(r.data[entry.offset+8:]))
+	}
+
+	end = start + length
+	if length < 0 || start < 0 || end > len(r.data) {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// ascii returns the value of an ASCII entry.
+func (r *tiffReader) ascii(entry tiffEntry) string {
+	start, end, ok := r.valueRange(entry)
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(r.data[start:end]), "\x00"))
+}
+
+// byteValue returns the value of a BYTE entry.
+func (r *tiffReader) byteValue(entry tiffEntry) byte {
+	start, _, ok := r.valueRange(entry)
+	if !ok {
+		return 0
+	}
+
+	return r.data[start]
+}
+
+// short returns the value of a SHORT entry.
+func (r *tiffReader) short(entry tiffEntry) uint16 {
+	start, end, ok := r.valueRange(entry)
+	if !ok || end-start < 2 {
+		return 0
+	}
+
+	return r.order.Uint16(r.data[start:])
+}
+
+// long returns the value of a LONG entry.
+func (r *tiffReader) long(entry tiffEntry) uint32 {
+	start, end, ok := r.valueRange(entry)
+	if !ok || end-start < 4 {
+		return 0
+	}
+
+	return r.order.Uint32(r.data[start:])
+}
+
+// rationals returns the values of a RATIONAL entry.
+func (r *tiffReader) rationals(entry tiffEntry) []float64 {
+	start, end, ok := r.valueRange(entry)
+	if !ok || entry.valueType != 5 {
+		return nil
+	}
+
+	values := make([]float64, 0, entry.count)
+	for i := start; i+8 <= end; i += 8 {
+		numerator := r.order.Uint32(r.data[i:])
+		denominator := r.order.Uint32(r.data[i+4:])
+		if denominator == 0 {
+			return nil
+		}
+		values = append(values, float64(numerator)/float64(denominator))
+	}
+
+	return values
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"api-file/main/src/enums"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// testTiff creates little endian TIFF data with a GPS IFD at the offset, 0 puts it after the first IFD.
+func testTiff(gpsIFD uint32) []byte {
+	order := binary.LittleEndian
+	data := []byte("II*\x00")
+	data = order.AppendUint32(data, 8)
+
+	// The first IFD points to the GPS IFD.
+	if gpsIFD == 0 {
+		gpsIFD = 26
+	}
+	data = order.AppendUint16(data, 1)
+	data = appendTiffEntry(data, tagGPSIFD, 4, 1, gpsIFD)
+	data = order.AppendUint32(data, 0)
+
+	// The GPS IFD with the location, the rationals follow the IFD.
+	data = order.AppendUint16(data, 4)
+	data = appendTiffEntry(data, tagGPSLatitudeRef, 2, 2, uint32('N'))
+	data = appendTiffEntry(data, tagGPSLatitude, 5, 3, 80)
+	data = appendTiffEntry(data, tagGPSLongitudeRef, 2, 2, uint32('E'))
+	data = appendTiffEntry(data, tagGPSLongitude, 5, 3, 104)
+	data = order.AppendUint32(data, 0)
+	for _, value := range []uint32{52, 1, 22, 1, 0, 1, 4, 1, 53, 1, 0, 1} {
+		data = order.AppendUint32(data, value)
+	}
+
+	return data
+}
+
+// appendTiffEntry appends a little endian IFD entry.
+func appendTiffEntry(data []byte, tag, valueType uint16, count, value uint32) []byte {
+	data = binary.LittleEndian.AppendUint16(data, tag)
+	data = binary.LittleEndian.AppendUint16(data, valueType)
+	data = binary.LittleEndian.AppendUint32(data, count)
+
+	return binary.LittleEndian.AppendUint32(data, value)
+}
+
+// testJpeg creates a JPEG with an EXIF segment, with or without start of scan.
+func testJpeg(tiff []byte, scan bool) []byte {
+	exif := append([]byte(exifHeader), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, jpegApp1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(exif)+2))
+	data = append(data, exif...)
+	if scan {
+		data = append(data, 0xFF, jpegStartOfScan, 0x00, 0x02, 0x01, 0x02, 0xFF, 0xD9)
+	}
+
+	return data
+}
+
+// testPng creates a PNG with an eXIf chunk.
+func testPng(tiff []byte) []byte {
+	data := []byte(pngSignature)
+	for _, chunk := range []struct {
+		chunkType string
+		data      []byte
+	}{{"eXIf", tiff}, {"IEND", nil}} {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(chunk.data)))
+		start := len(data)
+		data = append(data, chunk.chunkType...)
+		data = append(data, chunk.data...)
+		data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data[start:]))
+	}
+
+	return data
+}
+
+// testWebp creates an extended WebP with an EXIF chunk, without the padding byte of an odd sized last chunk.
+func testWebp(tiff []byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = append(data, "VP8X"...)
+	data = binary.LittleEndian.AppendUint32(data, 10)
+	data = append(data, webpVP8XExifFlag, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	data = append(data, "EXIF"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(tiff)))
+	data = append(data, tiff...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	return data
+}
+
+func TestStripImageMetadataLocation(t *testing.T) {
+	tiff := testTiff(0)
+	images := map[string][]byte{
+		"jpeg": testJpeg(tiff, true),
+		"png":  testPng(tiff),
+		"webp": testWebp(tiff),
+	}
+
+	for name, data := range images {
+		t.Run(name, func(t *testing.T) {
+			if metadata := ExtractImageMetadata(data); metadata.Latitude == nil || metadata.Longitude == nil {
+				t.Fatal("the location is not read")
+			}
+
+			stripped := StripImageMetadata(data, enums.StripLocation)
+			if metadata := ExtractImageMetadata(stripped); metadata.Latitude != nil || metadata.Longitude != nil {
+				t.Error("the location is not removed")
+			}
+			if !bytes.Contains(stripped, []byte("II*\x00")) {
+				t.Error("the byte order mark of the EXIF data is removed")
+			}
+			if !bytes.Equal(data, StripImageMetadata(data, enums.StripNone)) {
+				t.Error("the image is changed without stripping")
+			}
+		})
+	}
+}
+
+func TestStripImageMetadataMalformed(t *testing.T) {
+	truncated := testTiff(0)[:40]
+	pastIFD := testTiff(0)
+	binary.LittleEndian.PutUint32(pastIFD[4:], 0xFFFF)
+	manyEntries := testTiff(0)
+	binary.LittleEndian.PutUint16(manyEntries[26:], 0xFFFF)
+	valuePastData := testTiff(0)
+	binary.LittleEndian.PutUint32(valuePastData[26+2+12+8:], 0xFFFFFFF0)
+
+	tests := []struct {
+		name string
+		tiff []byte
+	}{
+		{name: "GPS IFD past the data", tiff: testTiff(0xFFFF)},
+		{name: "GPS IFD at the byte order mark", tiff: testTiff(0)[:26]},
+		{name: "truncated GPS IFD", tiff: truncated},
+		{name: "first IFD past the data", tiff: pastIFD},
+		{name: "too many entries", tiff: manyEntries},
+		{name: "value past the data", tiff: valuePastData},
+		{name: "header only", tiff: []byte("II*\x00")},
+		{name: "empty", tiff: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, data := range [][]byte{testJpeg(test.tiff, true), testJpeg(test.tiff, false), testPng(test.tiff), testWebp(test.tiff)} {
+				ExtractImageMetadata(data)
+				for _, strip := range []enums.StripMetadata{enums.StripLocation, enums.StripAll} {
+					stripped := StripImageMetadata(data, strip)
+					if strip == enums.StripLocation && len(test.tiff) >= 4 && !bytes.Contains(stripped, test.tiff[:4]) {
+						t.Errorf("the byte order mark of the EXIF data is changed")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStripImageMetadataGpsPointerAtZero(t *testing.T) {
+	tiff := testTiff(0)
+	binary.LittleEndian.PutUint32(tiff[8+2+8:], 0)
+
+	stripped := StripImageMetadata(testJpeg(tiff, true), enums.StripLocation)
+	if !bytes.Contains(stripped, []byte(exifHeader+"II*\x00")) {
+		t.Error("the byte order mark of the EXIF data is overwritten")
+	}
+}
+
+func TestStripWebpOddChunk(t *testing.T) {
+	tiff := append(testTiff(0), 0x00)
+	data := testWebp(tiff)
+
+	for _, strip := range []enums.StripMetadata{enums.StripLocation, enums.StripAll} {
+		stripped := StripImageMetadata(data, strip)
+		if len(stripped)%2 != 0 {
+			t.Errorf("%s: got %d bytes, want an even size", strip, len(stripped))
+		}
+		if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+			t.Errorf("%s: got RIFF size %d, want %d", strip, size, len(stripped)-8)
+		}
+	}
+}
+
+func TestStripJpegWithoutScan(t *testing.T) {
+	data := testJpeg(testTiff(0), false)
+
+	stripped := StripImageMetadata(data, enums.StripAll)
+	if bytes.Contains(stripped, []byte(exifHeader)) {
+		t.Error("the EXIF segment is not removed")
+	}
+	if !bytes.HasPrefix(stripped, []byte{0xFF, 0xD8}) {
+		t.Error("the start of image is removed")
+	}
+}
+
+func FuzzImageMetadata(f *testing.F) {
+	for _, tiff := range [][]byte{testTiff(0), testTiff(0xFFFF), testTiff(0)[:40]} {
+		f.Add(testJpeg(tiff, true))
+		f.Add(testJpeg(tiff, false))
+		f.Add(testPng(tiff))
+		f.Add(testWebp(tiff))
+		f.Add(testWebp(append(tiff, 0x00)))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ExtractImageMetadata(data)
+		original := bytes.Clone(data)
+		StripImageMetadata(data, enums.StripLocation)
+		StripImageMetadata(data, enums.StripAll)
+		if !bytes.Equal(data, original) {
+			t.Error("the data of the caller is changed")
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/h2non/bimg"
+)
+
+const (
+	placeholderWidth    = 16
+	placeholderQuality  = 40
+	blurHashWidth       = 32
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+	blurHashCharacters  = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// GenerateImagePlaceholder method to create the BlurHash, the low quality image placeholder
+// and the dominant color of an image. The image is rotated by its EXIF orientation first.
+func GenerateImagePlaceholder(ctx context.Context, data []byte) (placeholder models.ImagePlaceholder, err error) {
+	_, span := tracing.Start(ctx, "bimg.placeholder")
+	defer func() { tracing.End(span, err) }()
+
+	lqip, err := bimg.NewImage(data).Process(bimg.Options{
+		Width:          placeholderWidth,
+		Type:           bimg.WEBP,
+		Quality:        placeholderQuality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return models.ImagePlaceholder{}, err
+	}
+
+	thumbnail, err := bimg.NewImage(data).Process(bimg.Options{
+		Width:          blurHashWidth,
+		Type:           bimg.PNG,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return models.ImagePlaceholder{}, err
+	}
+
+	pixels, err := png.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		return models.ImagePlaceholder{}, err
+	}
+
+	return models.ImagePlaceholder{
+		BlurHash:      encodeBlurHash(pixels, blurHashComponentsX, blurHashComponentsY),
+		LQIP:          "data:image/webp;base64," + base64.StdEncoding.EncodeToString(lqip),
+		DominantColor: dominantColor(pixels),
+	}, nil
+}
+
+// GenerateImagePlaceholders method to create the placeholders of the images as the work of a job.
+// Images that already have a placeholder are skipped unless overwrite is set.
+// A failing image does not stop the job. The onProgress callback is called after each image.
+func GenerateImagePlaceholders(job *models.Job, images []models.Image, overwrite bool, onProgress func(image *models.Image)) error {
+	for i := range images {
+		image := &images[i]
+
+		if !overwrite && !image.Placeholder.IsEmpty() {
+			job.Done++
+		} else if image.Broken {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: original is broken", image.ID)
+		} else if err := RegenerateImagePlaceholder(image); err != nil {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: %v", image.ID, err)
+		} else {
+			job.Done++
+		}
+
+		if err := SaveJob(job); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(image)
+		}
+	}
+
+	return nil
+}
+
+// RegenerateImagePlaceholder method to create the placeholder of an image again from its source.
+func RegenerateImagePlaceholder(image *models.Image) error {
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	data, err := ReadImageSource(path, image)
+	if err != nil {
+		return err
+	}
+
+	placeholder, err := GenerateImagePlaceholder(context.Background(), data)
+	if err != nil {
+		return err
+	}
+
+	if result := database.Pg.Model(image).Unscoped().Updates(map[string]interface{}{
+		"blur_hash":      placeholder.BlurHash,
+		"lqip":           placeholder.LQIP,
+		"dominant_color": placeholder.DominantColor,
+	}); result.Error != nil {
+		return result.Error
+	}
+	image.Placeholder = placeholder
+
+	return nil
+}
+
+// encodeBlurHash encodes the pixels to a BlurHash with the given amount of components.
+func encodeBlurHash(pixels image.Image, componentsX, componentsY int) string {
+	bounds := pixels.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Convert the pixels to linear RGB once.
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := pixels.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			linear[y*width+x] = [3]float64{srgbToLinear(r >> 8), srgbToLinear(g >> 8), srgbToLinear(b >> 8)}
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					pixel := linear[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+
+			scale := 1.0 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((componentsX-1)+(componentsY-1)*9, 1))
+
+	maximum := 1.0
+	if len(factors) > 1 {
+		actualMaximum := 0.0
+		for _, factor := range factors[1:] {
+			actualMaximum = math.Max(actualMaximum, math.Max(math.Abs(factor[0]), math.Max(math.Abs(factor[1]), math.Abs(factor[2]))))
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximum = float64(quantisedMaximum+1) / 166
+		hash.WriteString(encodeBase83(quantisedMaximum, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(encodeBase83(linearToSrgb(dc[0])<<16+linearToSrgb(dc[1])<<8+linearToSrgb(dc[2]), 4))
+
+	for _, factor := range factors[1:] {
+		quantise := func(value float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(value/maximum, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quantise(factor[0])*19*19+quantise(factor[1])*19+quantise(factor[2]), 2))
+	}
+
+	return hash.String()
+}
+
+// dominantColor gets the most common color of the opaque pixels as a hex color.
+// Colors are grouped in buckets so small differences count as the same color.
+func dominantColor(pixels image.Image) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+
+	buckets := make(map[int]*bucket)
+	var dominant *bucket
+	bounds := pixels.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := pixels.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			r, g, b = r>>8, g>>8, b>>8
+
+			key := int(r>>4)<<8 | int(g>>4)<<4 | int(b>>4)
+			current, ok := buckets[key]
+			if !ok {
+				current = &bucket{}
+				buckets[key] = current
+			}
+			current.count++
+			current.r += int(r)
+			current.g += int(g)
+			current.b += int(b)
+
+			if dominant == nil || current.count > dominant.count {
+				dominant = current
+			}
+		}
+	}
+
+	if dominant == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", dominant.r/dominant.count, dominant.g/dominant.count, dominant.b/dominant.count)
+}
+
+// encodeBase83 encodes the value with the BlurHash characters to the given length.
+func encodeBase83(value, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		result[i-1] = blurHashCharacters[digit]
+	}
+
+	return string(result)
+}
+
+// srgbToLinear converts an sRGB channel value to linear RGB.
+func srgbToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSrgb converts a linear RGB channel value to sRGB.
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(math.Round(v * 12.92 * 255))
+	}
+
+	return int(math.Round((1.055*math.Pow(v, 1/2.4) - 0.055) * 255))
+}
+
+// signPow raises the absolute value to the exponent and keeps the sign.
+func signPow(value, exponent float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exponent), value)
+}
@@ -4,17 +4,26 @@ import (
 	"api-file/main/src/cache"
 	"api-file/main/src/database"
 	"api-file/main/src/enums"
+	"api-file/main/src/metrics"
 	"api-file/main/src/models"
 	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/valkey-io/valkey-go"
+	"gorm.io/gorm"
 )
 
 // IsImageAvailable method to check if an image is available within the app.
 func IsImageAvailable(folderId uint, name, extension string) (bool, error) {
-	if result := database.Pg.
+	return isImageAvailable(database.Pg, folderId, name, extension)
+}
+
+// isImageAvailable checks with the query if the folder has a image with the name, also when it is deleted.
+func isImageAvailable(db *gorm.DB, folderId uint, name, extension string) (bool, error) {
+	if result := db.
 		Unscoped().
 		Limit(1).
 		Find(&models.Image{}, "folder_id = ? AND name = ? AND extension = ?", folderId, name, extension); result.Error != nil {
@@ -43,7 +52,7 @@ func GetImageById(id uint, withSizes bool) (models.Image, error) {
 	query := database.Pg.Preload("Folder").Preload("Folder.AppStoragePath")
 
 	if withSizes {
-		query = query.Preload("ImageSizes")
+		query = query.Preload("ImageSizes").Preload("ImageCrops")
 	}
 
 	if result := query.Find(&image, "id = ?", id); result.Error != nil {
@@ -83,14 +92,75 @@ func GetImage(id uint) (models.Image, error) {
 	return image, nil
 }
 
+// GetImagesByStoragePath method to get all images of a storage path with their sizes.
+func GetImagesByStoragePath(appStoragePathID uint) ([]models.Image, error) {
+	images := make([]models.Image, 0)
+
+	if result := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Joins("JOIN folders ON images.folder_id = folders.id").
+		Where("folders.app_storage_path_id = ?", appStoragePathID).
+		Order("images.id").
+		Find(&images); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return images, nil
+}
+
+// GetImagesByFolderIDs method to get all images of the folders with their sizes.
+func GetImagesByFolderIDs(folderIDs []uint) ([]models.Image, error) {
+	images := make([]models.Image, 0)
+
+	if result := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Where("folder_id IN ?", folderIDs).
+		Order("id").
+		Find(&images); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return images, nil
+}
+
+// GetImagesByIDs method to get the images with their folder, sizes and crops.
+func GetImagesByIDs(ids []uint) ([]models.Image, error) {
+	images := make([]models.Image, 0)
+
+	if result := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&images); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return images, nil
+}
+
 // GetImageFromCache method to get the image from the cache.
 func GetImageFromCache(id uint, size ...string) (string, error) {
 	key := ImageCacheKey(id, size...)
 
 	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(key).Build())
-	if result.Error() != nil {
-		return "", result.Error()
+	if err := result.Error(); err != nil {
+		if valkey.IsValkeyNil(err) {
+			metrics.PathCacheLookups.WithLabelValues(metrics.CacheMiss).Inc()
+		} else {
+			metrics.PathCacheLookups.WithLabelValues(metrics.CacheError).Inc()
+		}
+		return "", err
 	}
+	metrics.PathCacheLookups.WithLabelValues(metrics.CacheHit).Inc()
 
 	value, err := result.ToString()
 	if err != nil {
@@ -101,7 +171,7 @@ func GetImageFromCache(id uint, size ...string) (string, error) {
 }
 
 // CreateImage method to create the image that is uploaded.
-func CreateImage(folderID uint, name, extension, mimeType string, size, width, height int, description *string, sizes []models.ImageSize) (models.Image, error) {
+func CreateImage(folderID uint, name, extension, mimeType string, size, width, height int, description *string, metadata models.ImageMetadata, placeholder models.ImagePlaceholder, animation models.ImageAnimation, sizes []models.ImageSize, crops []models.ImageCrop) (models.Image, error) {
 	image := models.Image{
 		FolderID:    folderID,
 		Name:        name,
@@ -111,7 +181,11 @@ func CreateImage(folderID uint, name, extension, mimeType string, size, width, h
 		Width:       width,
 		Height:      height,
 		Description: sql.NullString{Valid: false, String: ""},
+		Metadata:    metadata,
+		Placeholder: placeholder,
+		Animation:   animation,
 		ImageSizes:  sizes,
+		ImageCrops:  crops,
 	}
 
 	if description != nil {
@@ -145,7 +219,7 @@ func SaveImageToCache(imageId uint, path string, size ...string) error {
 }
 
 // UpdateImage method to update the image description.
-func UpdateImage(image *models.Image, name, extension, mimeType *string, size, width, height *int, description *string, sizes *[]models.ImageSize) (models.Image, error) {
+func UpdateImage(image *models.Image, name, extension, mimeType *string, size, width, height *int, description *string, metadata *models.ImageMetadata, placeholder *models.ImagePlaceholder, animation *models.ImageAnimation, sizes *[]models.ImageSize, crops *[]models.ImageCrop) (models.Image, error) {
 	if name != nil {
 		image.Name = *name
 	}
@@ -164,6 +238,15 @@ func UpdateImage(image *models.Image, name, extension, mimeType *string, size, w
 	if height != nil {
 		image.Height = *height
 	}
+	if metadata != nil {
+		image.Metadata = *metadata
+	}
+	if placeholder != nil {
+		image.Placeholder = *placeholder
+	}
+	if animation != nil {
+		image.Animation = *animation
+	}
 
 	image.Description.Valid = description != nil && *description != ""
 	if image.Description.Valid {
@@ -177,6 +260,13 @@ func UpdateImage(image *models.Image, name, extension, mimeType *string, size, w
 		image.ImageSizes = *sizes
 	}
 
+	if crops != nil {
+		if result := database.Pg.Model(&models.ImageCrop{}).Unscoped().Delete(&models.ImageCrop{}, "image_id = ?", image.ID); result.Error != nil {
+			return *image, result.Error
+		}
+		image.ImageCrops = *crops
+	}
+
 	if result := database.Pg.Save(&image); result.Error != nil {
 		return models.Image{}, result.Error
 	}
@@ -184,13 +274,55 @@ func UpdateImage(image *models.Image, name, extension, mimeType *string, size, w
 	return *image, nil
 }
 
+// ReplaceImageSizes method to replace the sizes of an image in a single transaction.
+func ReplaceImageSizes(image *models.Image, sizes []models.ImageSize) error {
+	return database.Pg.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Unscoped().Delete(&models.ImageSize{}, "image_id = ?", image.ID); result.Error != nil {
+			return result.Error
+		}
+
+		for i := range sizes {
+			sizes[i].ImageID = image.ID
+		}
+		if len(sizes) > 0 {
+			if result := tx.Create(&sizes); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		image.ImageSizes = sizes
+
+		return nil
+	})
+}
+
 // DeleteImage method to delete a image.
 func DeleteImage(image *models.Image, hard ...bool) error {
-	query1 := database.Pg
-	query2 := database.Pg.Model(&models.ImageSize{})
-	if len(hard) > 0 && hard[0] == true {
+	if err := deleteImage(database.Pg, image, len(hard) > 0 && hard[0]); err != nil {
+		return err
+	}
+
+	_ = DeleteImageFromCache(image.ID)
+	for i := range image.ImageSizes {
+		_ = DeleteImageFromCache(image.ID, image.ImageSizes[i].Size.String())
+	}
+	for i := range image.ImageCrops {
+		_ = DeleteImageFromCache(image.ID, ImageCropCacheSuffix(image.ImageCrops[i].Crop))
+	}
+	_ = DeleteImageFromCache(image.ID, ImagePosterCacheSuffix)
+
+	return nil
+}
+
+// deleteImage deletes the image with its sizes and crops with the query.
+func deleteImage(tx *gorm.DB, image *models.Image, hard bool) error {
+	query1 := tx
+	query2 := tx.Model(&models.ImageSize{})
+	query3 := tx.Model(&models.ImageCrop{})
+	if hard {
 		query1 = query1.Unscoped()
 		query2 = query2.Unscoped()
+		query3 = query3.Unscoped()
 	}
 
 	if result := query1.Delete(image); result.Error != nil {
@@ -201,9 +333,8 @@ func DeleteImage(image *models.Image, hard ...bool) error {
 		return result.Error
 	}
 
-	_ = DeleteImageFromCache(image.ID)
-	for i := range image.ImageSizes {
-		_ = DeleteImageFromCache(image.ID, image.ImageSizes[i].Size.String())
+	if result := query3.Delete(&models.ImageCrop{}, "image_id = ?", image.ID); result.Error != nil {
+		return result.Error
 	}
 
 	return nil
@@ -221,6 +352,21 @@ func DeleteImageFromCache(id uint, size ...string) error {
 	return nil
 }
 
+// DeleteImageFilesFromCache method to delete the cached paths of the image with its original,
+// web sizes, crops and poster. The sizes and crops of the image need to be loaded.
+func DeleteImageFilesFromCache(image *models.Image) {
+	_ = DeleteImageFromCache(image.ID)
+	for i := range image.ImageSizes {
+		_ = DeleteImageFromCache(image.ID, image.ImageSizes[i].Size.String())
+	}
+	for i := range image.ImageCrops {
+		_ = DeleteImageFromCache(image.ID, ImageCropCacheSuffix(image.ImageCrops[i].Crop))
+	}
+	_ = DeleteImageFromCache(image.ID, ImagePosterCacheSuffix)
+	_ = DeleteImageFromCache(image.ID, ImageOriginalCacheSuffix)
+	_ = DeleteImageFromCache(image.ID, AppCacheSuffix)
+}
+
 // RestoreImage method to restore an image.
 func RestoreImage(id uint) error {
 	if result := database.Pg.Model(&models.Image{}).
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// ImageContentSecurityPolicy is sent with the original image files, so scripts in SVG
+// files that are stored before the sanitization existed can not run either.
+const ImageContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; img-src data:; sandbox"
+
+// svgForbiddenElements are removed with their content.
+var svgForbiddenElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"audio":         true,
+	"video":         true,
+	"handler":       true,
+	"listener":      true,
+}
+
+// svgAnimationElements can change the attributes of other elements.
+var svgAnimationElements = map[string]bool{
+	"set":              true,
+	"animate":          true,
+	"animatecolor":     true,
+	"animatemotion":    true,
+	"animatetransform": true,
+}
+
+var (
+	svgLocalReference  = regexp.MustCompile(`^#[^\s]*$`)
+	svgInlineImage     = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);base64,[a-z0-9+/=\s]*$`)
+	svgUrlReference    = regexp.MustCompile(`url\(\s*['"]?([^'")\s]*)`)
+	svgUnsafeStyle     = regexp.MustCompile(`@import|expression\s*\(|javascript:|behavior\s*:|-moz-binding`)
+	svgTextEscaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	svgAttributeEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// IsSvgImage method to check if the upload is an SVG image, by its MIME type,
+// its extension or its content, so an SVG can not pass as another image type.
+func IsSvgImage(mimeType, extension string, data []byte) bool {
+	return strings.EqualFold(mimeType, "image/svg+xml") ||
+		strings.EqualFold(extension, "svg") ||
+		IsVectorImage(data)
+}
+
+// IsVectorImage method to check if the image data is an SVG.
+func IsVectorImage(data []byte) bool {
+	return mimetype.Detect(data).Is("image/svg+xml")
+}
+
+// SanitizeSvg method to remove everything from an SVG that can run code or load external
+// resources: scripts, foreign objects, event attributes, external references, doctypes
+// and processing instructions. Comments are removed as well.
+func SanitizeSvg(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var output bytes.Buffer
+	hasRoot := false
+	depth := 0
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if depth == 0 {
+				if hasRoot || name != "svg" {
+					return nil, errors.New("the image is not an svg")
+				}
+				hasRoot = true
+			}
+
+			if svgForbiddenElements[name] || svgAnimationElements[name] && !isSafeSvgAnimation(t) {
+				if err := skipSvgElement(decoder); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if name == "style" {
+				if err := writeSvgStyle(&output, decoder, t); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			writeSvgStartElement(&output, t)
+			depth++
+		case xml.EndElement:
+			output.WriteString("</" + svgQualifiedName(t.Name) + ">")
+			depth--
+		case xml.CharData:
+			if depth > 0 {
+				output.WriteString(svgTextEscaper.Replace(string(t)))
+			}
+		}
+	}
+
+	if !hasRoot {
+		return nil, errors.New("the image is not an svg")
+	}
+
+	return output.Bytes(), nil
+}
+
+// writeSvgStartElement writes the element with only its safe attributes.
+func writeSvgStartElement(output *bytes.Buffer, element xml.StartElement) {
+	output.WriteString("<" + svgQualifiedName(element.Name))
+	for _, attribute := range element.Attr {
+		if !isSafeSvgAttribute(attribute) {
+			continue
+		}
+		output.WriteString(" " + svgQualifiedName(attribute.Name) + `="` + svgAttributeEscape.Replace(attribute.Value) + `"`)
+	}
+	output.WriteString(">")
+}
+
+// writeSvgStyle writes a style element, or nothing when its CSS is unsafe.
+func writeSvgStyle(output *bytes.Buffer, decoder *xml.Decoder, element xml.StartElement) error {
+	var css strings.Builder
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			css.Write(t)
+		}
+	}
+
+	if !isSafeSvgStyle(css.String()) {
+		return nil
+	}
+
+	writeSvgStartElement(output, element)
+	output.WriteString(svgTextEscaper.Replace(css.String()))
+	output.WriteString("</" + svgQualifiedName(element.Name) + ">")
+
+	return nil
+}
+
+// skipSvgElement reads the tokens until the end of the current element.
+func skipSvgElement(decoder *xml.Decoder) error {
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return err
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// isSafeSvgAttribute checks if the attribute can not run code or load an external resource.
+func isSafeSvgAttribute(attribute xml.Attr) bool {
+	name := strings.ToLower(attribute.Name.Local)
+	value := strings.ToLower(strings.Join(strings.Fields(attribute.Value), ""))
+
+	switch {
+	case strings.HasPrefix(name, "on"):
+		return false
+	case name == "href" || name == "src":
+		return svgLocalReference.MatchString(value) || svgInlineImage.MatchString(strings.ToLower(attribute.Value))
+	case name == "style":
+		return isSafeSvgStyle(attribute.Value)
+	case strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:"):
+		return false
+	}
+
+	return isSafeSvgUrls(attribute.Value)
+}
+
+// isSafeSvgAnimation checks if the animation does not change a reference or an event attribute.
+func isSafeSvgAnimation(element xml.StartElement) bool {
+	for _, attribute := range element.Attr {
+		if strings.ToLower(attribute.Name.Local) != "attributename" {
+			continue
+		}
+
+		target := strings.ToLower(attribute.Value)
+		if i := strings.LastIndex(target, ":"); i >= 0 {
+			target = target[i+1:]
+		}
+		if target == "href" || target == "src" || strings.HasPrefix(target, "on") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSafeSvgStyle checks if the CSS only refers to elements within the SVG.
+func isSafeSvgStyle(css string) bool {
+	if svgUnsafeStyle.MatchString(strings.ToLower(css)) {
+		return false
+	}
+
+	return isSafeSvgUrls(css)
+}
+
+// isSafeSvgUrls checks if every url() in the value refers to an element within the SVG.
+func isSafeSvgUrls(value string) bool {
+	for _, match := range svgUrlReference.FindAllStringSubmatch(value, -1) {
+		if !svgLocalReference.MatchString(match[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// svgQualifiedName joins the prefix and the local name of a raw XML name.
+func svgQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
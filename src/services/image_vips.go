@@ -0,0 +1,124 @@
+package services
+
+/*
+#cgo pkg-config: vips
+#include <stdlib.h>
+#include <vips/vips.h>
+
+// thumbnail_webp loads the image with the load options, resizes it to the width and saves it
+// as WebP. Animated images keep their frames, delays and loops. Vector images are rendered
+// at the target size, so they stay sharp when they are enlarged.
+static int thumbnail_webp(void *buf, size_t len, int width, int quality, const char *option_string, VipsSize size, void **out, size_t *out_len, int *out_width, int *out_height) {
+	VipsImage *image;
+	if (vips_thumbnail_buffer(buf, len, &image, width, "option_string", option_string, "size", size, NULL)) {
+		return -1;
+	}
+
+	*out_width = vips_image_get_width(image);
+	*out_height = vips_image_get_page_height(image);
+
+	int result = vips_webpsave_buffer(image, out, out_len, "Q", quality, "strip", TRUE, NULL);
+	g_object_unref(image);
+
+	return result;
+}
+
+// count_pages loads the image or document with the load options and counts its pages or frames.
+static int count_pages(void *buf, size_t len, const char *option_string) {
+	VipsImage *image = vips_image_new_from_buffer(buf, len, option_string, NULL);
+	if (!image) {
+		return -1;
+	}
+
+	int pages = vips_image_get_n_pages(image);
+	g_object_unref(image);
+
+	return pages;
+}
+*/
+import "C"
+
+import (
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"errors"
+	"unsafe"
+
+	"github.com/h2non/bimg"
+)
+
+// convertAnimatedImage resizes every frame of an animated image to the width
+// of the size and converts it to an animated WebP.
+func convertAnimatedImage(data []byte, size enums.Size, quality int) ([]byte, models.ImageSize, error) {
+	processed, width, height, err := thumbnailWebp(data, size.Width(), quality, "n=-1", false)
+	if err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	return processed, models.ImageSize{Size: size, Width: width, Height: height}, nil
+}
+
+// convertVectorImage renders a vector image at the width of the size and converts it to WebP.
+func convertVectorImage(data []byte, size enums.Size, quality int) ([]byte, models.ImageSize, error) {
+	processed, width, height, err := thumbnailWebp(data, size.Width(), quality, "", true)
+	if err != nil {
+		return nil, models.ImageSize{}, err
+	}
+
+	return processed, models.ImageSize{Size: size, Width: width, Height: height}, nil
+}
+
+// countPages counts the pages of a document or the frames of an image with libvips.
+func countPages(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("file is empty")
+	}
+	bimg.Initialize()
+
+	input := C.CBytes(data)
+	defer C.free(input)
+	options := C.CString("")
+	defer C.free(unsafe.Pointer(options))
+
+	pages := C.count_pages(input, C.size_t(len(data)), options)
+	if pages < 0 {
+		err := errors.New(C.GoString(C.vips_error_buffer()))
+		C.vips_error_clear()
+		return 0, err
+	}
+
+	return int(pages), nil
+}
+
+// thumbnailWebp resizes the image with libvips to the width and converts it to WebP.
+// The image is only enlarged when enlarge is set.
+func thumbnailWebp(data []byte, width, quality int, optionString string, enlarge bool) ([]byte, int, int, error) {
+	if len(data) == 0 {
+		return nil, 0, 0, errors.New("image is empty")
+	}
+	if quality == 0 {
+		quality = bimg.Quality
+	}
+	size := C.VipsSize(C.VIPS_SIZE_DOWN)
+	if enlarge {
+		size = C.VipsSize(C.VIPS_SIZE_BOTH)
+	}
+	bimg.Initialize()
+
+	input := C.CBytes(data)
+	defer C.free(input)
+	options := C.CString(optionString)
+	defer C.free(unsafe.Pointer(options))
+
+	var output unsafe.Pointer
+	var outputLen C.size_t
+	var outputWidth, outputHeight C.int
+	if C.thumbnail_webp(input, C.size_t(len(data)), C.int(width), C.int(quality), options, size, &output, &outputLen, &outputWidth, &outputHeight) != 0 {
+		err := errors.New(C.GoString(C.vips_error_buffer()))
+		C.vips_error_clear()
+		return nil, 0, 0, err
+	}
+	defer C.g_free(C.gpointer(output))
+
+	return C.GoBytes(output, C.int(outputLen)), int(outputWidth), int(outputHeight), nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/enums"
+	"api-file/main/src/metrics"
+	"api-file/main/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valkey-io/valkey-go"
+)
+
+// CreateJob method to create a pending job in the cache for the storage path that it writes to.
+func CreateJob(jobType enums.JobType, appStoragePathID uint, total int) (*models.Job, error) {
+	code, err := uuid.NewUUID()
+	if err != nil {
+		return nil, errors.New("failed to generate job id")
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		ID:               code.String(),
+		Type:             jobType,
+		Status:           enums.Pending,
+		AppStoragePathID: appStoragePathID,
+		Total:            total,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if err := SaveJob(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetJob method to get a job from the cache.
+// It returns nil when the job does not exist or is expired.
+func GetJob(id string) (*models.Job, error) {
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(jobCacheKey(id)).Build())
+	if valkey.IsValkeyNil(result.Error()) {
+		return nil, nil
+	} else if result.Error() != nil {
+		return nil, result.Error()
+	}
+
+	value, err := result.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{}
+	if err := json.Unmarshal(value, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// SaveJob method to save the state of a job in the cache.
+func SaveJob(job *models.Job) error {
+	duration, err := time.ParseDuration(os.Getenv("VALKEY_EXPIRATION_JOB"))
+	if err != nil {
+		return err
+	}
+
+	job.UpdatedAt = time.Now()
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(jobCacheKey(job.ID)).Value(valkey.BinaryString(value)).Ex(duration).Build())
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	return nil
+}
+
+// RunJob method to run the work of a job in the background.
+// The state of the job is saved when it starts and when it ends. A panic of the work fails the job.
+func RunJob(job *models.Job, work func(job *models.Job) error) {
+	queueDepth := metrics.JobQueueDepth.WithLabelValues(job.Type.String())
+	queueDepth.Inc()
+
+	go func() {
+		defer queueDepth.Dec()
+
+		if err := holdStoragePath(job); err != nil {
+			log.Printf("Error holding storage path %d for job %s: %v", job.AppStoragePathID, job.ID, err)
+		}
+		defer releaseStoragePath(job)
+
+		job.Status = enums.Running
+		if err := SaveJob(job); err != nil {
+			log.Printf("Error saving job %s: %v", job.ID, err)
+		}
+
+		if err := runJobWork(job, work); err != nil {
+			job.Status = enums.Failed
+			job.Error = err.Error()
+		} else {
+			job.Status = enums.Completed
+		}
+
+		if err := SaveJob(job); err != nil {
+			log.Printf("Error saving job %s: %v", job.ID, err)
+		}
+	}()
+}
+
+// runJobWork runs the work of the job and turns a panic into an error, so it does not stop the service.
+func runJobWork(job *models.Job, work func(job *models.Job) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Panic in job %s: %v\n%s", job.ID, recovered, debug.Stack())
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return work(job)
+}
+
+// HasRunningJobs method to check if a job is running that writes to the storage path.
+// Jobs that are finished or expired without releasing the storage path, like after a crash, are removed.
+func HasRunningJobs(appStoragePathID uint) (bool, error) {
+	key := storagePathJobsCacheKey(appStoragePathID)
+	ids, err := cache.Valkey.Do(context.Background(), cache.Valkey.B().Smembers().Key(key).Build()).AsStrSlice()
+	if err != nil {
+		return false, err
+	}
+
+	running := false
+	for _, id := range ids {
+		job, err := GetJob(id)
+		if err != nil {
+			return false, err
+		} else if job != nil && (job.Status == enums.Pending || job.Status == enums.Running) {
+			running = true
+			continue
+		}
+
+		if err := cache.Valkey.Do(context.Background(), cache.Valkey.B().Srem().Key(key).Member(id).Build()).Error(); err != nil {
+			return false, err
+		}
+	}
+
+	return running, nil
+}
+
+// holdStoragePath adds the job to the running jobs of its storage path.
+func holdStoragePath(job *models.Job) error {
+	if job.AppStoragePathID == 0 {
+		return nil
+	}
+
+	return cache.Valkey.Do(context.Background(), cache.Valkey.B().Sadd().Key(storagePathJobsCacheKey(job.AppStoragePathID)).Member(job.ID).Build()).Error()
+}
+
+// releaseStoragePath removes the job from the running jobs of its storage path.
+func releaseStoragePath(job *models.Job) {
+	if job.AppStoragePathID == 0 {
+		return
+	}
+
+	key := storagePathJobsCacheKey(job.AppStoragePathID)
+	if err := cache.Valkey.Do(context.Background(), cache.Valkey.B().Srem().Key(key).Member(job.ID).Build()).Error(); err != nil {
+		log.Printf("Error releasing storage path %d for job %s: %v", job.AppStoragePathID, job.ID, err)
+	}
+}
+
+// Creates a key for the running jobs of a storage path.
+func storagePathJobsCacheKey(appStoragePathID uint) string {
+	return fmt.Sprintf("jobs:storage-path:%d", appStoragePathID)
+}
+
+// Creates a key for the job cache.
+func jobCacheKey(id string) string {
+	return fmt.Sprintf("job:%s", id)
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"errors"
+	"io/fs"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// MoveImages method to move the images with their web sizes, crops and poster into the target folder.
+// The images need their folder, sizes and crops and the target folder its storage path.
+// Either every image is moved or none: when a file or the database fails, the moved files are put back.
+// Images that move to a storage path with another watermark get their public renditions rendered again.
+func MoveImages(images []models.Image, target *models.Folder) error {
+	files, err := moveImages(database.Pg, images, target)
+	if err != nil {
+		return err
+	}
+	files.commit()
+
+	return imagesMoved(images, target)
+}
+
+// moveImages moves the files of the images and updates their folder with the query.
+// The files are put back when it fails, otherwise they need to be committed or rolled back.
+func moveImages(tx *gorm.DB, images []models.Image, target *models.Folder) (*storageFileMove, error) {
+	targetPath, err := GetPath(&target.AppStoragePath, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(targetPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	files := storageFileMove{target: &target.AppStoragePath}
+	ids := make([]uint, len(images))
+	for i := range images {
+		image := &images[i]
+		ids[i] = image.ID
+
+		sourcePath, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+		if err != nil {
+			files.rollback()
+			return nil, err
+		}
+		if err := files.moveImageFiles(image, image, sourcePath, targetPath); err != nil {
+			files.rollback()
+			return nil, err
+		}
+	}
+
+	if result := tx.Model(&models.Image{}).Where("id IN ?", ids).Update("folder_id", target.ID); result.Error != nil {
+		files.rollback()
+		return nil, result.Error
+	}
+
+	return &files, nil
+}
+
+// imagesMoved clears the cache of the moved images and renders their public renditions again
+// when they moved to a storage path with another watermark.
+func imagesMoved(images []models.Image, target *models.Folder) error {
+	for i := range images {
+		DeleteImageFilesFromCache(&images[i])
+		source := images[i].Folder.AppStoragePath
+		images[i].FolderID = target.ID
+		images[i].Folder = *target
+
+		if err := rewatermarkImage(&images[i], &source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveDocuments method to move the documents with their previews into the target folder.
+// The documents need their folder and the target folder its storage path.
+// Either every document is moved or none: when a file or the database fails, the moved files are put back.
+func MoveDocuments(documents []models.Document, target *models.Folder) error {
+	files, err := moveDocuments(database.Pg, documents, target)
+	if err != nil {
+		return err
+	}
+	files.commit()
+	documentsMoved(documents, target)
+
+	return nil
+}
+
+// moveDocuments moves the files of the documents and updates their folder with the query.
+// The files are put back when it fails, otherwise they need to be committed or rolled back.
+func moveDocuments(tx *gorm.DB, documents []models.Document, target *models.Folder) (*storageFileMove, error) {
+	targetPath, err := GetPath(&target.AppStoragePath, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(targetPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	files := storageFileMove{target: &target.AppStoragePath}
+	ids := make([]uint, len(documents))
+	for i := range documents {
+		document := &documents[i]
+		ids[i] = document.ID
+
+		sourcePath, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+		if err != nil {
+			files.rollback()
+			return nil, err
+		}
+		if err := files.moveDocumentFiles(document, document, sourcePath, targetPath); err != nil {
+			files.rollback()
+			return nil, err
+		}
+	}
+
+	if result := tx.Model(&models.Document{}).Where("id IN ?", ids).Update("folder_id", target.ID); result.Error != nil {
+		files.rollback()
+		return nil, result.Error
+	}
+
+	return &files, nil
+}
+
+// documentsMoved clears the cache of the moved documents.
+func documentsMoved(documents []models.Document, target *models.Folder) {
+	for i := range documents {
+		DeleteDocumentFilesFromCache(&documents[i])
+		documents[i].FolderID = target.ID
+		documents[i].Folder = *target
+	}
+}
+
+// fileMove is a file that is moved. A copied file still has its source,
+// which is removed when the move is committed.
+type fileMove struct {
+	source string
+	target string
+	copied bool
+}
+
+// storageFileMove moves files into a storage path and keeps them,
+// so they can be moved back when the move fails.
+type storageFileMove struct {
+	target *models.AppStoragePath
+	files  []fileMove
+}
+
+// moveImageFiles moves the original, edited and watermarked renditions, web sizes, crops and poster of the image
+// to the files of the moved image, which can have another name.
+func (f *storageFileMove) moveImageFiles(image, moved *models.Image, sourcePath, targetPath string) error {
+	source := &image.Folder.AppStoragePath
+
+	if err := f.move(source, ImageFilePath(sourcePath, image), ImageFilePath(targetPath, moved)); err != nil {
+		return err
+	}
+	for _, size := range image.ImageSizes {
+		if err := f.move(source, ImageSizeFilePath(sourcePath, image.Name, size.Size), ImageSizeFilePath(targetPath, moved.Name, size.Size)); err != nil {
+			return err
+		}
+	}
+	for _, crop := range image.ImageCrops {
+		if err := f.move(source, ImageCropFilePath(sourcePath, image.Name, crop.Crop), ImageCropFilePath(targetPath, moved.Name, crop.Crop)); err != nil {
+			return err
+		}
+	}
+	if image.Animation.HasPoster {
+		if err := f.move(source, ImagePosterFilePath(sourcePath, image.Name), ImagePosterFilePath(targetPath, moved.Name)); err != nil {
+			return err
+		}
+	}
+	if image.Edit.Edited {
+		if err := f.move(source, ImageEditedFilePath(sourcePath, image.Name), ImageEditedFilePath(targetPath, moved.Name)); err != nil {
+			return err
+		}
+	}
+
+	return f.moveIfExists(source, ImageWatermarkedFilePath(sourcePath, image.Name), ImageWatermarkedFilePath(targetPath, moved.Name))
+}
+
+// moveDocumentFiles moves the document with its thumbnail, converted PDF and the pages that are rendered
+// to the files of the moved document, which can have another name.
+func (f *storageFileMove) moveDocumentFiles(document, moved *models.Document, sourcePath, targetPath string) error {
+	source := &document.Folder.AppStoragePath
+
+	if err := f.move(source, DocumentFilePath(sourcePath, document), DocumentFilePath(targetPath, moved)); err != nil {
+		return err
+	}
+	if err := f.moveIfExists(source, DocumentThumbnailFilePath(sourcePath, document), DocumentThumbnailFilePath(targetPath, moved)); err != nil {
+		return err
+	}
+	if err := f.moveIfExists(source, DocumentPdfFilePath(sourcePath, document), DocumentPdfFilePath(targetPath, moved)); err != nil {
+		return err
+	}
+	for page := 1; page <= document.PageCount; page++ {
+		if err := f.moveIfExists(source, DocumentPreviewFilePath(sourcePath, document, page), DocumentPreviewFilePath(targetPath, moved, page)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// move renames a file within its storage path. A file that moves to another storage path,
+// or to another file system, is copied instead and its source is removed on commit.
+// An existing target file is never replaced, it can be the file of a deleted item.
+func (f *storageFileMove) move(source *models.AppStoragePath, sourceFilePath, targetFilePath string) error {
+	if _, err := os.Lstat(targetFilePath); err == nil {
+		return &fs.PathError{Op: "move", Path: targetFilePath, Err: fs.ErrExist}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if source.ID == f.target.ID {
+		if err := os.Rename(sourceFilePath, targetFilePath); err == nil {
+			f.files = append(f.files, fileMove{source: sourceFilePath, target: targetFilePath})
+			return nil
+		}
+	}
+
+	files := storageFileCopy{source: source, target: f.target}
+	if err := files.copy(sourceFilePath, targetFilePath); err != nil {
+		return err
+	}
+	f.files = append(f.files, fileMove{source: sourceFilePath, target: targetFilePath, copied: true})
+
+	return nil
+}
+
+// moveIfExists moves a file that is generated on demand and can be missing.
+func (f *storageFileMove) moveIfExists(source *models.AppStoragePath, sourceFilePath, targetFilePath string) error {
+	if _, err := os.Stat(sourceFilePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return f.move(source, sourceFilePath, targetFilePath)
+}
+
+// rollback puts the moved files back in reverse order.
+func (f *storageFileMove) rollback() {
+	for i := len(f.files) - 1; i >= 0; i-- {
+		if f.files[i].copied {
+			_ = RemoveFile(f.files[i].target)
+		} else {
+			_ = os.Rename(f.files[i].target, f.files[i].source)
+		}
+	}
+	f.files = nil
+}
+
+// commit removes the sources of the copied files.
+func (f *storageFileMove) commit() {
+	for _, file := range f.files {
+		if file.copied {
+			_ = RemoveFile(file.source)
+		}
+	}
+	f.files = nil
+}
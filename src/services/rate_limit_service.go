@@ -0,0 +1,290 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+	"gorm.io/gorm"
+)
+
+// RateLimitAllGroups is the group of a rate limit that applies to every group of routes of the app.
+const RateLimitAllGroups = "*"
+
+// AppCacheSuffix is the cache suffix of the name of the app of an image or document.
+const AppCacheSuffix = "app"
+
+// rateLimitsTTL is how long the rate limits are kept before they are read again.
+const rateLimitsTTL = time.Minute
+
+// defaultRateLimitWindow is the window of the rate limits when RATE_LIMIT_WINDOW is not set.
+const defaultRateLimitWindow = time.Minute
+
+// rateLimits keeps the rate limits of the apps to check a request without a query.
+var rateLimits struct {
+	sync.Mutex
+	rateLimits map[string]models.RateLimit
+	loadedAt   time.Time
+}
+
+// rateLimitScript takes an amount from a fixed window counter that starts with its first hit.
+// The amount is taken when the counter stays within the limit, or always when it is forced.
+// It returns if the amount is taken, the used amount and the milliseconds until the window resets.
+var rateLimitScript = valkey.NewLuaScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local amount = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+if ARGV[4] ~= '1' and (used >= limit or used + amount > limit) then
+	return {0, used, redis.call('PTTL', KEYS[1])}
+end
+if amount > 0 then
+	used = redis.call('INCRBY', KEYS[1], amount)
+	if redis.call('PTTL', KEYS[1]) < 0 then
+		redis.call('PEXPIRE', KEYS[1], window)
+	end
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	ttl = window
+end
+return {1, used, ttl}
+`)
+
+// RateLimitResult is the state of a rate limit counter after a request.
+type RateLimitResult struct {
+	Allowed bool
+	Used    int64
+	Reset   time.Duration
+}
+
+// GetRateLimits method to get the rate limits of the app.
+func GetRateLimits(app string) ([]models.RateLimit, error) {
+	limits := make([]models.RateLimit, 0)
+
+	if result := database.Pg.Order("route_group").Find(&limits, "app_name = ?", app); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return limits, nil
+}
+
+// ReplaceRateLimits method to replace the rate limits of the app.
+func ReplaceRateLimits(app string, limits []models.RateLimit) ([]models.RateLimit, error) {
+	for i := range limits {
+		limits[i].ID = 0
+		limits[i].AppName = app
+	}
+
+	err := database.Pg.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("app_name = ?", app).Delete(&models.RateLimit{}); result.Error != nil {
+			return result.Error
+		}
+		if len(limits) == 0 {
+			return nil
+		}
+
+		return tx.Create(&limits).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimits.Lock()
+	rateLimits.loadedAt = time.Time{}
+	rateLimits.Unlock()
+
+	return limits, nil
+}
+
+// GetRateLimit method to get the rate limit of the app for the group of routes.
+// The limit of the group comes first, then the limit of all groups of the app and then the defaults of
+// RATE_LIMIT_*. The rate limits are kept for a minute, the defaults are returned when they can not be read.
+func GetRateLimit(app, group string) (models.RateLimit, error) {
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+
+	if time.Since(rateLimits.loadedAt) > rateLimitsTTL {
+		limits := make([]models.RateLimit, 0)
+		if result := database.Pg.Find(&limits); result.Error != nil {
+			return DefaultRateLimit(), result.Error
+		}
+
+		rateLimits.rateLimits = make(map[string]models.RateLimit, len(limits))
+		for i := range limits {
+			rateLimits.rateLimits[rateLimitKey(limits[i].AppName, limits[i].RouteGroup)] = limits[i]
+		}
+		rateLimits.loadedAt = time.Now()
+	}
+
+	if limit, ok := rateLimits.rateLimits[rateLimitKey(app, group)]; ok {
+		return limit, nil
+	}
+	if limit, ok := rateLimits.rateLimits[rateLimitKey(app, RateLimitAllGroups)]; ok {
+		return limit, nil
+	}
+
+	return DefaultRateLimit(), nil
+}
+
+// DefaultRateLimit method to get the rate limit of apps without their own limits.
+// It is set with RATE_LIMIT_REQUESTS, RATE_LIMIT_UPLOAD_BYTES, RATE_LIMIT_DOWNLOAD_BYTES and RATE_LIMIT_WINDOW.
+func DefaultRateLimit() models.RateLimit {
+	limit := models.RateLimit{
+		RouteGroup:    RateLimitAllGroups,
+		Requests:      rateLimitEnv("RATE_LIMIT_REQUESTS"),
+		UploadBytes:   rateLimitEnv("RATE_LIMIT_UPLOAD_BYTES"),
+		DownloadBytes: rateLimitEnv("RATE_LIMIT_DOWNLOAD_BYTES"),
+		Window:        int(defaultRateLimitWindow / time.Second),
+	}
+
+	if window, err := time.ParseDuration(os.Getenv("RATE_LIMIT_WINDOW")); err == nil && window >= time.Second {
+		limit.Window = int(window / time.Second)
+	}
+
+	return limit
+}
+
+// TakeRateLimit method to take an amount from the counter of the client within the window of the limit.
+// A forced amount is always taken, it is used for the bytes that are known after they are sent.
+func TakeRateLimit(ctx context.Context, kind enums.RateLimitKind, app, group, client string, amount, limit int64, window time.Duration, force bool) (RateLimitResult, error) {
+	key := fmt.Sprintf("ratelimit:%s:%s:%s:%s", kind, app, group, client)
+	forced := "0"
+	if force {
+		forced = "1"
+	}
+
+	values, err := rateLimitScript.Exec(ctx, cache.Valkey, []string{key}, []string{
+		strconv.FormatInt(amount, 10),
+		strconv.FormatInt(limit, 10),
+		strconv.FormatInt(window.Milliseconds(), 10),
+		forced,
+	}).AsIntSlice()
+	if err != nil {
+		return RateLimitResult{}, err
+	} else if len(values) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit result %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed: values[0] == 1,
+		Used:    values[1],
+		Reset:   time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// GetImageAppName method to get the name of the app of the image, also when the image is deleted.
+// The name is kept in the cache, it is empty when the image does not exist.
+func GetImageAppName(id uint) (string, error) {
+	return getAppName(ImageCacheKey(id, AppCacheSuffix), "VALKEY_EXPIRATION_IMAGE", func() *gorm.DB {
+		return database.Pg.Unscoped().Model(&models.Image{}).
+			Joins("JOIN folders ON folders.id = images.folder_id").
+			Joins("JOIN app_storage_paths ON app_storage_paths.id = folders.app_storage_path_id").
+			Where("images.id = ?", id)
+	})
+}
+
+// GetDocumentAppName method to get the name of the app of the document, also when the document is deleted.
+// The name is kept in the cache, it is empty when the document does not exist.
+func GetDocumentAppName(id uint) (string, error) {
+	return getAppName(DocumentCacheKey(id, AppCacheSuffix), "VALKEY_EXPIRATION_DOCUMENT", func() *gorm.DB {
+		return database.Pg.Unscoped().Model(&models.Document{}).
+			Joins("JOIN folders ON folders.id = documents.folder_id").
+			Joins("JOIN app_storage_paths ON app_storage_paths.id = folders.app_storage_path_id").
+			Where("documents.id = ?", id)
+	})
+}
+
+// GetShareLinkAppName method to get the name of the app of the item that the share link shares.
+// The name is kept in the cache, it is empty when the share link does not exist.
+func GetShareLinkAppName(token string) (string, error) {
+	return getAppName(fmt.Sprintf("share:%s:%s", token, AppCacheSuffix), "VALKEY_EXPIRATION_DOCUMENT", func() *gorm.DB {
+		return database.Pg.Model(&models.ShareLink{}).
+			Joins("LEFT JOIN images ON images.id = share_links.image_id").
+			Joins("LEFT JOIN documents ON documents.id = share_links.document_id").
+			Joins("JOIN folders ON folders.id = COALESCE(images.folder_id, documents.folder_id, share_links.folder_id)").
+			Joins("JOIN app_storage_paths ON app_storage_paths.id = folders.app_storage_path_id").
+			Where("share_links.token = ?", token)
+	})
+}
+
+// GetAppStoragePathAppName method to get the name of the app of the storage path.
+// The name is kept in the cache, it is empty when the storage path does not exist.
+func GetAppStoragePathAppName(id uint) (string, error) {
+	return getAppName(fmt.Sprintf("storage-path:%d:%s", id, AppCacheSuffix), "VALKEY_EXPIRATION_DOCUMENT", func() *gorm.DB {
+		return database.Pg.Model(&models.AppStoragePath{}).
+			Where("app_storage_paths.id = ?", id)
+	})
+}
+
+// GetFolderAppName method to get the name of the app of the folder, also when the folder is deleted.
+// The name is kept in the cache, it is empty when the folder does not exist.
+func GetFolderAppName(id uint) (string, error) {
+	return getAppName(fmt.Sprintf("folder:%d:%s", id, AppCacheSuffix), "VALKEY_EXPIRATION_DOCUMENT", func() *gorm.DB {
+		return database.Pg.Unscoped().Model(&models.Folder{}).
+			Joins("JOIN app_storage_paths ON app_storage_paths.id = folders.app_storage_path_id").
+			Where("folders.id = ?", id)
+	})
+}
+
+// GetShareLinkAppNameByID method to get the name of the app of the item that the share link of the id shares.
+// The name is kept in the cache, it is empty when the share link does not exist.
+func GetShareLinkAppNameByID(id uint) (string, error) {
+	return getAppName(fmt.Sprintf("share-link:%d:%s", id, AppCacheSuffix), "VALKEY_EXPIRATION_DOCUMENT", func() *gorm.DB {
+		return database.Pg.Model(&models.ShareLink{}).
+			Joins("LEFT JOIN images ON images.id = share_links.image_id").
+			Joins("LEFT JOIN documents ON documents.id = share_links.document_id").
+			Joins("JOIN folders ON folders.id = COALESCE(images.folder_id, documents.folder_id, share_links.folder_id)").
+			Joins("JOIN app_storage_paths ON app_storage_paths.id = folders.app_storage_path_id").
+			Where("share_links.id = ?", id)
+	})
+}
+
+// getAppName gets the name of the app from the cache, or with the query when it is not cached yet.
+func getAppName(key, expiration string, query func() *gorm.DB) (string, error) {
+	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(key).Build())
+	if app, err := result.ToString(); err == nil {
+		return app, nil
+	} else if !valkey.IsValkeyNil(err) {
+		return "", err
+	}
+
+	apps := make([]string, 0, 1)
+	if result := query().Limit(1).Pluck("app_storage_paths.app_name", &apps); result.Error != nil {
+		return "", result.Error
+	} else if len(apps) == 0 {
+		return "", nil
+	}
+
+	duration, err := time.ParseDuration(os.Getenv(expiration))
+	if err != nil {
+		return apps[0], err
+	}
+	if err := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(key).Value(apps[0]).Ex(duration).Build()).Error(); err != nil {
+		return apps[0], err
+	}
+
+	return apps[0], nil
+}
+
+// rateLimitKey gets the key of the rate limit of the app for the group.
+func rateLimitKey(app, group string) string {
+	return app + "\x00" + group
+}
+
+// rateLimitEnv gets a limit from the environment, which is unlimited when it is not set.
+func rateLimitEnv(name string) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(name), 10, 64); err == nil && value > 0 {
+		return value
+	}
+
+	return 0
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+)
+
+// RenameImage method to rename an image with its web sizes, crops and poster without uploading it again.
+// The image needs its folder, sizes and crops. When a file or the database fails,
+// the renamed files get their old name back.
+func RenameImage(image *models.Image, name string) error {
+	if name == image.Name {
+		return nil
+	}
+
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	renamed := *image
+	renamed.Name = name
+
+	files := storageFileMove{target: &image.Folder.AppStoragePath}
+	if err := files.moveImageFiles(image, &renamed, path, path); err != nil {
+		files.rollback()
+		return err
+	}
+
+	if result := database.Pg.Model(image).Update("name", name); result.Error != nil {
+		files.rollback()
+		return result.Error
+	}
+	files.commit()
+
+	DeleteImageFilesFromCache(image)
+	image.Name = name
+
+	return nil
+}
+
+// RenameDocument method to rename a document with its previews without uploading it again.
+// The document needs its folder. When a file or the database fails,
+// the renamed files get their old name back.
+func RenameDocument(document *models.Document, name string) error {
+	if name == document.Name {
+		return nil
+	}
+
+	path, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return err
+	}
+
+	renamed := *document
+	renamed.Name = name
+
+	files := storageFileMove{target: &document.Folder.AppStoragePath}
+	if err := files.moveDocumentFiles(document, &renamed, path, path); err != nil {
+		files.rollback()
+		return err
+	}
+
+	if result := database.Pg.Model(document).Update("name", name); result.Error != nil {
+		files.rollback()
+		return result.Error
+	}
+	files.commit()
+
+	DeleteDocumentFilesFromCache(document)
+	document.Name = name
+
+	return nil
+}
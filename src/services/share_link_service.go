@@ -0,0 +1,284 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"archive/zip"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// shareLinkTokenBytes is the amount of random bytes of a share link token.
+const shareLinkTokenBytes = 24
+
+var (
+	ErrShareLinkRevoked   = errors.New("share link is revoked")
+	ErrShareLinkExpired   = errors.New("share link is expired")
+	ErrShareLinkPassword  = errors.New("share link password is wrong")
+	ErrShareLinkDownloads = errors.New("share link has no downloads left")
+)
+
+// CreateShareLink method to create a share link with a new random token.
+// The password is hashed when it is given.
+func CreateShareLink(shareLink *models.ShareLink, password *string, expiresAt *time.Time, maxDownloads *int64) error {
+	token := make([]byte, shareLinkTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	shareLink.Token = base64.RawURLEncoding.EncodeToString(token)
+
+	if err := setShareLinkRules(shareLink, password, expiresAt, maxDownloads); err != nil {
+		return err
+	}
+
+	if result := database.Pg.Create(shareLink); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetShareLink method to get a share link by its ID.
+func GetShareLink(id uint) (models.ShareLink, error) {
+	shareLink := models.ShareLink{}
+
+	if result := database.Pg.Find(&shareLink, "id = ?", id); result.Error != nil {
+		return shareLink, result.Error
+	}
+
+	return shareLink, nil
+}
+
+// GetShareLinkByToken method to get a share link by its token.
+func GetShareLinkByToken(token string) (models.ShareLink, error) {
+	shareLink := models.ShareLink{}
+
+	if result := database.Pg.Find(&shareLink, "token = ?", token); result.Error != nil {
+		return shareLink, result.Error
+	}
+
+	return shareLink, nil
+}
+
+// UpdateShareLink method to replace the rules of a share link.
+// A rule that is nil is removed, so the password has to be sent again to keep it.
+func UpdateShareLink(shareLink *models.ShareLink, password *string, expiresAt *time.Time, maxDownloads *int64) error {
+	if err := setShareLinkRules(shareLink, password, expiresAt, maxDownloads); err != nil {
+		return err
+	}
+
+	if result := database.Pg.Save(shareLink); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// RevokeShareLink method to revoke a share link, so it can not be used anymore.
+func RevokeShareLink(shareLink *models.ShareLink) error {
+	if shareLink.RevokedAt.Valid {
+		return nil
+	}
+
+	revokedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	if result := database.Pg.Model(shareLink).Update("revoked_at", revokedAt); result.Error != nil {
+		return result.Error
+	}
+	shareLink.RevokedAt = revokedAt
+
+	return nil
+}
+
+// CheckShareLink method to check if a share link can be used with the password.
+// A link without downloads left can still be used to list a shared folder.
+func CheckShareLink(shareLink *models.ShareLink, password string) error {
+	if shareLink.RevokedAt.Valid {
+		return ErrShareLinkRevoked
+	}
+	if shareLink.ExpiresAt.Valid && !time.Now().Before(shareLink.ExpiresAt.Time) {
+		return ErrShareLinkExpired
+	}
+	if shareLink.HasPassword() && bcrypt.CompareHashAndPassword([]byte(shareLink.PasswordHash.String), []byte(password)) != nil {
+		return ErrShareLinkPassword
+	}
+
+	return nil
+}
+
+// CountShareLinkDownload method to count a download of a share link.
+// The count is checked against the limit in the same statement, so parallel downloads can not pass it.
+func CountShareLinkDownload(shareLink *models.ShareLink) error {
+	result := database.Pg.Model(&models.ShareLink{}).
+		Where("id = ? AND (max_downloads IS NULL OR downloads < max_downloads)", shareLink.ID).
+		UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrShareLinkDownloads
+	}
+	shareLink.Downloads++
+
+	return nil
+}
+
+// GetFolderTree method to get a folder with everything inside it that is not deleted.
+// Infected documents are left out, they can not be downloaded.
+func GetFolderTree(folder *models.Folder) (*models.FolderTree, error) {
+	descendantIDs, err := getFolderDescendantIDs(folder.AppStoragePathID, folder.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	folderIDs := append([]uint{folder.ID}, descendantIDs...)
+
+	var folders []models.Folder
+	if result := database.Pg.Preload("AppStoragePath").Find(&folders, "id IN ?", folderIDs); result.Error != nil {
+		return nil, result.Error
+	}
+	var relations []models.FolderFolder
+	if result := database.Pg.Find(&relations, "folder_id IN ?", descendantIDs); result.Error != nil {
+		return nil, result.Error
+	}
+	var images []models.Image
+	if result := database.Pg.Order("name").Find(&images, "folder_id IN ?", folderIDs); result.Error != nil {
+		return nil, result.Error
+	}
+	var documents []models.Document
+	if result := database.Pg.Order("name").Find(&documents, "folder_id IN ? AND scan_status != ?", folderIDs, enums.Infected); result.Error != nil {
+		return nil, result.Error
+	}
+
+	trees := make(map[uint]*models.FolderTree, len(folders))
+	for i := range folders {
+		trees[folders[i].ID] = &models.FolderTree{Folder: folders[i]}
+	}
+	for _, relation := range relations {
+		if parent, child := trees[relation.ParentFolderID], trees[relation.FolderID]; parent != nil && child != nil {
+			parent.Folders = append(parent.Folders, child)
+		}
+	}
+	for i := range images {
+		if tree := trees[images[i].FolderID]; tree != nil {
+			images[i].Folder = tree.Folder
+			tree.Images = append(tree.Images, images[i])
+		}
+	}
+	for i := range documents {
+		if tree := trees[documents[i].FolderID]; tree != nil {
+			documents[i].Folder = tree.Folder
+			tree.Documents = append(tree.Documents, documents[i])
+		}
+	}
+
+	tree := trees[folder.ID]
+	if tree == nil {
+		return nil, fmt.Errorf("folder %d does not exist", folder.ID)
+	}
+
+	return tree, nil
+}
+
+// CheckFolderZip method to check that every file of the folder tree exists before the archive is written,
+// because an error while the archive is streamed can only break the download.
+func CheckFolderZip(tree *models.FolderTree) error {
+	return walkFolderZip(tree, tree.Folder.Name+"/", func(_, filePath string, _ uint16) error {
+		_, err := os.Stat(filePath)
+		return err
+	})
+}
+
+// WriteFolderZip method to write the files of the folder tree as a ZIP archive.
+// Images are written as they are served publicly, so with the watermark of the storage path.
+func WriteFolderZip(w io.Writer, tree *models.FolderTree) error {
+	archive := zip.NewWriter(w)
+	if err := walkFolderZip(tree, tree.Folder.Name+"/", func(name, filePath string, method uint16) error {
+		return writeZipFile(archive, name, filePath, method)
+	}); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// walkFolderZip calls the function with the name in the archive below the prefix, the path and the compression
+// of each file of the folder tree.
+func walkFolderZip(tree *models.FolderTree, prefix string, file func(name, filePath string, method uint16) error) error {
+	path, err := GetPath(&tree.Folder.AppStoragePath, tree.Folder.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range tree.Images {
+		image := &tree.Images[i]
+		filePath := ImagePublicFilePath(path, image)
+		name := fmt.Sprintf("%s%s%s", prefix, image.Name, filepath.Ext(filePath))
+		if err := file(name, filePath, zip.Store); err != nil {
+			return err
+		}
+	}
+	for i := range tree.Documents {
+		document := &tree.Documents[i]
+		name := fmt.Sprintf("%s%s.%s", prefix, document.Name, document.Extension)
+		if err := file(name, DocumentFilePath(path, document), zip.Deflate); err != nil {
+			return err
+		}
+	}
+	for _, folder := range tree.Folders {
+		if err := walkFolderZip(folder, prefix+folder.Folder.Name+"/", file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeZipFile adds a file of a storage path to the archive. Encrypted files are decrypted first.
+func writeZipFile(archive *zip.Writer, name, filePath string, method uint16) error {
+	data, err := ReadStorageFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+
+	return err
+}
+
+// setShareLinkRules sets the password, the expiry date and the download limit of the share link.
+// A rule that is nil or an empty password is removed, the password is stored as a bcrypt hash.
+func setShareLinkRules(shareLink *models.ShareLink, password *string, expiresAt *time.Time, maxDownloads *int64) error {
+	shareLink.PasswordHash = sql.NullString{}
+	if password != nil && *password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		shareLink.PasswordHash = sql.NullString{String: string(hash), Valid: true}
+	}
+
+	shareLink.ExpiresAt = sql.NullTime{}
+	if expiresAt != nil {
+		shareLink.ExpiresAt = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+
+	shareLink.MaxDownloads = sql.NullInt64{}
+	if maxDownloads != nil {
+		shareLink.MaxDownloads = sql.NullInt64{Int64: *maxDownloads, Valid: true}
+	}
+
+	return nil
+}
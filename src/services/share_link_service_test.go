@@ -0,0 +1,47 @@
+package services
+
+import (
+	"api-file/main/src/models"
+	"database/sql"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSetShareLinkRules(t *testing.T) {
+	password := "secret"
+	expiresAt := time.Now().Add(time.Hour)
+	maxDownloads := int64(5)
+
+	shareLink := models.ShareLink{}
+	if err := setShareLinkRules(&shareLink, &password, &expiresAt, &maxDownloads); err != nil {
+		t.Fatal(err)
+	}
+	if !shareLink.HasPassword() || bcrypt.CompareHashAndPassword([]byte(shareLink.PasswordHash.String), []byte(password)) != nil {
+		t.Errorf("got password hash %+v, want the hash of the password", shareLink.PasswordHash)
+	}
+	if !shareLink.ExpiresAt.Valid || !shareLink.ExpiresAt.Time.Equal(expiresAt) {
+		t.Errorf("got expiry date %+v, want %s", shareLink.ExpiresAt, expiresAt)
+	}
+	if shareLink.MaxDownloads != (sql.NullInt64{Int64: maxDownloads, Valid: true}) {
+		t.Errorf("got download limit %+v, want %d", shareLink.MaxDownloads, maxDownloads)
+	}
+
+	// The rules are replaced, so every rule that is left out is removed, also the password.
+	if err := setShareLinkRules(&shareLink, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if shareLink.HasPassword() || shareLink.ExpiresAt.Valid || shareLink.MaxDownloads.Valid {
+		t.Errorf("got %+v, want every rule removed", shareLink)
+	}
+
+	empty := ""
+	shareLink.PasswordHash = sql.NullString{String: "hash", Valid: true}
+	if err := setShareLinkRules(&shareLink, &empty, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if shareLink.HasPassword() {
+		t.Errorf("got password hash %+v, want the empty password removed", shareLink.PasswordHash)
+	}
+}
@@ -2,9 +2,40 @@ package services
 
 import (
 	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/metrics"
 	"api-file/main/src/models"
+	"context"
 	"database/sql"
+	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// storagePathAppsTTL is how long the storage paths are kept to find the app of a file.
+// They are loaded again in the background, also right away when a storage path is created or updated.
+const storagePathAppsTTL = time.Minute
+
+// storagePathApp is the root of a storage path with the name of its app.
+type storagePathApp struct {
+	root string
+	app  string
+}
+
+// storagePathAppsSnapshot is the roots of the storage paths, with the longest root first.
+type storagePathAppsSnapshot struct {
+	apps     []storagePathApp
+	loadedAt time.Time
+}
+
+// storagePathApps keeps the storage paths to find the app of a file without a query per file.
+var (
+	storagePathApps        atomic.Pointer[storagePathAppsSnapshot]
+	storagePathAppsLoading atomic.Bool
 )
 
 // IsStorageAvailable method to check if a storage path is available within the app.
@@ -34,6 +65,24 @@ func IsStorageSpaceAvailable(appStoragePathID uint) (bool, error) {
 	return !limit.Valid || usedSpace < limit.Int64, nil
 }
 
+// IsStorageSpaceAvailableFor method to check if the storage path has space for the amount of bytes.
+func IsStorageSpaceAvailableFor(appStoragePathID uint, size int64) (bool, error) {
+	var limit sql.NullInt64
+	usedSpace, err := GetUsedSpace(appStoragePathID)
+	if err != nil {
+		return false, err
+	}
+
+	if result := database.Pg.Model(&models.AppStoragePath{}).
+		Select("limit").
+		Find(&limit, "id = ?", appStoragePathID).
+		Scan(&limit); result.Error != nil {
+		return false, result.Error
+	}
+
+	return !limit.Valid || usedSpace+size <= limit.Int64, nil
+}
+
 // GetStoragePathIDByApp method to get the storage path ID by app name.
 func GetStoragePathIDByApp(app string) (*uint, error) {
 	var storagePathID *uint
@@ -51,8 +100,13 @@ func GetStoragePathIDByApp(app string) (*uint, error) {
 
 // GetPath method to get the full path.
 func GetPath(appStoragePath *models.AppStoragePath, folderID uint) (string, error) {
+	return GetPathContext(context.Background(), appStoragePath, folderID)
+}
+
+// GetPathContext method to get the full path, the queries are traced as children of the context.
+func GetPathContext(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint) (string, error) {
 	path := os.Getenv("PATH_FILES") + appStoragePath.Path
-	folderPath, err := GetFolderPath(appStoragePath.ID, folderID)
+	folderPath, err := GetFolderPath(ctx, appStoragePath.ID, folderID)
 
 	if err != nil {
 		return "", err
@@ -84,6 +138,115 @@ func GetUsedSpace(appStoragePathID uint) (int64, error) {
 	return imagesSize + documentsSize, nil
 }
 
+// GetStorageUsages method to get the used space and the limit of every storage path.
+func GetStorageUsages() ([]metrics.StorageUsage, error) {
+	if !database.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	storagePaths, err := GetStoragePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]metrics.StorageUsage, 0, len(storagePaths))
+	for i := range storagePaths {
+		usedSpace, err := GetUsedSpace(storagePaths[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		usage := metrics.StorageUsage{App: storagePaths[i].AppName, Path: storagePaths[i].Path, Used: usedSpace}
+		if storagePaths[i].Limit.Valid {
+			usage.Limit = &storagePaths[i].Limit.Int64
+		}
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
+
+// GetStoragePathAppName method to get the name of the app of which the storage path holds the file.
+// It returns an empty name when no storage path holds the file or the storage paths are not loaded yet.
+func GetStoragePathAppName(filePath string) string {
+	snapshot := storagePathApps.Load()
+	if snapshot == nil || time.Since(snapshot.loadedAt) > storagePathAppsTTL {
+		refreshStoragePathAppsInBackground()
+	}
+	if snapshot == nil {
+		return ""
+	}
+
+	filePath = filepath.Clean(filePath)
+	for _, storagePathApp := range snapshot.apps {
+		if strings.HasPrefix(filePath, storagePathApp.root) {
+			return storagePathApp.app
+		}
+	}
+
+	return ""
+}
+
+// RefreshStoragePathApps method to load the storage paths that are used to find the app of a file.
+func RefreshStoragePathApps() error {
+	if !database.IsConnected() {
+		return ErrNotConnected
+	}
+
+	storagePaths, err := GetStoragePaths()
+	if err != nil {
+		return err
+	}
+	storagePathApps.Store(newStoragePathAppsSnapshot(storagePaths))
+
+	return nil
+}
+
+// newStoragePathAppsSnapshot creates the snapshot of the roots of the storage paths.
+// The roots end with a separator, so a root does not hold the files of a root that starts with the same name.
+func newStoragePathAppsSnapshot(storagePaths []models.AppStoragePath) *storagePathAppsSnapshot {
+	apps := make([]storagePathApp, len(storagePaths))
+	for i := range storagePaths {
+		root := filepath.Clean(os.Getenv("PATH_FILES") + storagePaths[i].Path)
+		if !strings.HasSuffix(root, string(os.PathSeparator)) {
+			root += string(os.PathSeparator)
+		}
+		apps[i] = storagePathApp{root: root, app: storagePaths[i].AppName}
+	}
+	slices.SortFunc(apps, func(a, b storagePathApp) int {
+		return len(b.root) - len(a.root)
+	})
+
+	return &storagePathAppsSnapshot{apps: apps, loadedAt: time.Now()}
+}
+
+// refreshStoragePathAppsInBackground loads the storage paths again without waiting for it.
+// Only one load runs at a time.
+func refreshStoragePathAppsInBackground() {
+	if !storagePathAppsLoading.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer storagePathAppsLoading.Store(false)
+
+		if err := RefreshStoragePathApps(); err != nil {
+			log.Printf("Error loading the storage paths: %v", err)
+		}
+	}()
+}
+
+// GetStoragePaths method to get all storage paths.
+func GetStoragePaths() ([]models.AppStoragePath, error) {
+	storagePaths := make([]models.AppStoragePath, 0)
+
+	if result := database.Pg.Order("id").Find(&storagePaths); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return storagePaths, nil
+}
+
 // GetStoragePath method to get a storage path for the app.
 func GetStoragePath(id uint) (*models.AppStoragePath, error) {
 	storagePath := &models.AppStoragePath{}
@@ -104,7 +267,8 @@ func GetStoragePath(id uint) (*models.AppStoragePath, error) {
 }
 
 // CreateStoragePath method to create a storage path for the app.
-func CreateStoragePath(app, path string, limit *int64) (*models.AppStoragePath, error) {
+// An encrypted storage path gets a data key that is wrapped by the master key.
+func CreateStoragePath(app, path string, limit *int64, stripMetadata enums.StripMetadata, animationMode enums.AnimationMode, animationPoster, encrypted bool, watermark models.Watermark) (*models.AppStoragePath, error) {
 	nullableLimit := sql.NullInt64{}
 	if limit != nil {
 		nullableLimit.Int64 = *limit
@@ -113,19 +277,44 @@ func CreateStoragePath(app, path string, limit *int64) (*models.AppStoragePath,
 		nullableLimit.Valid = false
 	}
 
-	storagePath := &models.AppStoragePath{AppName: app, Path: path, Limit: nullableLimit}
+	storagePath := &models.AppStoragePath{
+		AppName:         app,
+		Path:            path,
+		Limit:           nullableLimit,
+		StripMetadata:   stripMetadata,
+		AnimationMode:   animationMode,
+		AnimationPoster: animationPoster,
+		Encrypted:       encrypted,
+		Watermark:       watermark,
+	}
+
+	if err := EnableStorageEncryption(storagePath); err != nil {
+		return nil, err
+	}
 
 	if result := database.Pg.Create(storagePath); result.Error != nil {
 		return nil, result.Error
 	}
+	refreshStoragePathAppsInBackground()
 
 	return storagePath, nil
 }
 
 // UpdateStoragePath method to update a storage path for the app.
-func UpdateStoragePath(oldStoragePath *models.AppStoragePath, app, path string, limit *int64) (*models.AppStoragePath, error) {
+// Existing files keep their encryption, only new files follow the encrypted option.
+// The public renditions of existing images keep their watermark until they are rendered again.
+func UpdateStoragePath(oldStoragePath *models.AppStoragePath, app, path string, limit *int64, stripMetadata enums.StripMetadata, animationMode enums.AnimationMode, animationPoster, encrypted bool, watermark models.Watermark) (*models.AppStoragePath, error) {
 	oldStoragePath.AppName = app
 	oldStoragePath.Path = path
+	oldStoragePath.StripMetadata = stripMetadata
+	oldStoragePath.AnimationMode = animationMode
+	oldStoragePath.AnimationPoster = animationPoster
+	oldStoragePath.Encrypted = encrypted
+	oldStoragePath.Watermark = watermark
+
+	if err := EnableStorageEncryption(oldStoragePath); err != nil {
+		return nil, err
+	}
 
 	if limit != nil {
 		oldStoragePath.Limit.Int64 = *limit
@@ -137,6 +326,7 @@ func UpdateStoragePath(oldStoragePath *models.AppStoragePath, app, path string,
 	if result := database.Pg.Save(oldStoragePath); result.Error != nil {
 		return nil, result.Error
 	}
+	refreshStoragePathAppsInBackground()
 
 	return oldStoragePath, nil
 }
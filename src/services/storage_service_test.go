@@ -0,0 +1,34 @@
+package services
+
+import (
+	"api-file/main/src/models"
+	"testing"
+)
+
+func TestGetStoragePathAppName(t *testing.T) {
+	t.Setenv("PATH_FILES", "/data")
+	storagePathApps.Store(newStoragePathAppsSnapshot([]models.AppStoragePath{
+		{AppName: "app", Path: "/app"},
+		{AppName: "app2", Path: "/app2"},
+		{AppName: "nested", Path: "/app/nested/"},
+	}))
+	t.Cleanup(func() { storagePathApps.Store(nil) })
+
+	tests := []struct {
+		filePath string
+		app      string
+	}{
+		{filePath: "/data/app/1/image.png", app: "app"},
+		{filePath: "/data/app2/1/image.png", app: "app2"},
+		{filePath: "/data/app/nested/1/image.png", app: "nested"},
+		{filePath: "/data//app2//1/image.png", app: "app2"},
+		{filePath: "/data/application/image.png", app: ""},
+		{filePath: "/data/app", app: ""},
+	}
+
+	for _, test := range tests {
+		if app := GetStoragePathAppName(test.filePath); app != test.app {
+			t.Errorf("%s: got app %q, want %q", test.filePath, app, test.app)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"api-file/main/src/database"
+	"api-file/main/src/models"
+	"os"
+	"time"
+)
+
+// GetDeletedImages method to get the images of a storage path that are deleted before the given time.
+func GetDeletedImages(appStoragePathID uint, before time.Time) ([]models.Image, error) {
+	images := make([]models.Image, 0)
+
+	if result := database.Pg.Unscoped().
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Preload("ImageSizes").
+		Preload("ImageCrops").
+		Joins("JOIN folders ON images.folder_id = folders.id").
+		Where("folders.app_storage_path_id = ? AND images.deleted_at IS NOT NULL AND images.deleted_at < ?", appStoragePathID, before).
+		Find(&images); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return images, nil
+}
+
+// GetDeletedDocuments method to get the documents of a storage path that are deleted before the given time.
+func GetDeletedDocuments(appStoragePathID uint, before time.Time) ([]models.Document, error) {
+	documents := make([]models.Document, 0)
+
+	if result := database.Pg.Unscoped().
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Joins("JOIN folders ON documents.folder_id = folders.id").
+		Where("folders.app_storage_path_id = ? AND documents.deleted_at IS NOT NULL AND documents.deleted_at < ?", appStoragePathID, before).
+		Find(&documents); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return documents, nil
+}
+
+// GetDeletedFolders method to get the folders of a storage path that are deleted before the given time.
+func GetDeletedFolders(appStoragePathID uint, before time.Time) ([]models.Folder, error) {
+	folders := make([]models.Folder, 0)
+
+	if result := database.Pg.Unscoped().
+		Preload("AppStoragePath").
+		Where("app_storage_path_id = ? AND deleted_at IS NOT NULL AND deleted_at < ?", appStoragePathID, before).
+		Find(&folders); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return folders, nil
+}
+
+// DeleteFolderHard method to delete a folder with all its sub folders, images and documents for ever.
+// The directory of the folder is removed from the storage path.
+func DeleteFolderHard(folder *models.Folder) error {
+	// The folder can already be removed together with a deleted parent folder.
+	var count int64
+	if result := database.Pg.Model(&models.Folder{}).Unscoped().Where("id = ?", folder.ID).Count(&count); result.Error != nil {
+		return result.Error
+	} else if count == 0 {
+		return nil
+	}
+
+	path, err := GetPath(&folder.AppStoragePath, folder.ID)
+	if err != nil {
+		return err
+	}
+
+	ids, err := GetFolderDescendantIDs(folder.AppStoragePathID, folder.ID)
+	if err != nil {
+		return err
+	}
+	ids = append(ids, folder.ID)
+
+	var images []models.Image
+	if result := database.Pg.Unscoped().Preload("ImageSizes").Preload("ImageCrops").Find(&images, "folder_id IN ?", ids); result.Error != nil {
+		return result.Error
+	}
+
+	// The files are removed first, so a failure leaves the records to delete them again.
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	// Images, documents and folder relations are removed by the cascade.
+	if result := database.Pg.Unscoped().Delete(&models.Folder{}, "id IN ?", ids); result.Error != nil {
+		return result.Error
+	}
+
+	for i := range images {
+		DeleteImageFilesFromCache(&images[i])
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"api-file/main/src/cache"
+	"api-file/main/src/database"
+	"api-file/main/src/enums"
+	"api-file/main/src/models"
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	virusScanTimeout   = time.Minute
+	virusScanChunkSize = 64 * 1024
+	virusRescanLockKey = "virus-rescan"
+)
+
+// ScanResult is the verdict of the virus scanner on a file.
+type ScanResult struct {
+	Status    enums.ScanStatus
+	Signature string
+}
+
+// IsVirusScanEnabled method to check if a virus scanner is configured.
+func IsVirusScanEnabled() bool {
+	return os.Getenv("CLAMD_ADDRESS") != ""
+}
+
+// IsQuarantineEnabled method to check if infected documents are kept in quarantine
+// instead of being rejected. Quarantined documents are never served.
+func IsQuarantineEnabled() bool {
+	return strings.EqualFold(os.Getenv("CLAMD_INFECTED_ACTION"), "quarantine")
+}
+
+// ScanData method to scan the data with the virus scanner.
+// The result is unscanned when no virus scanner is configured.
+func ScanData(data []byte) (ScanResult, error) {
+	return ScanReader(bytes.NewReader(data))
+}
+
+// ScanReader method to stream a file to a clamd compatible virus scanner with the INSTREAM command.
+// The scanner is configured with CLAMD_ADDRESS as tcp://host:port or unix:///path/to/clamd.sock.
+func ScanReader(reader io.Reader) (ScanResult, error) {
+	address := os.Getenv("CLAMD_ADDRESS")
+	if address == "" {
+		return ScanResult{Status: enums.Unscanned}, nil
+	}
+
+	timeout := virusScanTimeout
+	if value := os.Getenv("CLAMD_TIMEOUT"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return ScanResult{}, err
+		}
+		timeout = duration
+	}
+
+	network, address := clamdNetwork(address)
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("virus scanner is not available: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return ScanResult{}, err
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ScanResult{}, err
+	}
+
+	// Send the file in chunks that are prefixed with their length, a zero length ends the stream.
+	chunk := make([]byte, virusScanChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := reader.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, chunk[:n]...)); err != nil {
+				return ScanResult{}, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return ScanResult{}, err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return ScanResult{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return ScanResult{}, err
+	}
+
+	return parseClamdReply(reply)
+}
+
+// ScanDocument method to scan a stored document again and to save the result.
+func ScanDocument(document *models.Document) (ScanResult, error) {
+	path, err := GetPath(&document.Folder.AppStoragePath, document.FolderID)
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	data, err := ReadStorageFile(DocumentFilePath(path, document))
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	result, err := ScanData(data)
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	if err := SaveDocumentScan(document, result); err != nil {
+		return ScanResult{}, err
+	}
+
+	return result, nil
+}
+
+// SaveDocumentScan method to save the scan result on the document.
+// An infected document also loses its previews, so nothing of it is served.
+func SaveDocumentScan(document *models.Document, result ScanResult) error {
+	scannedAt := sql.NullTime{Valid: result.Status != enums.Unscanned, Time: time.Now()}
+	signature := sql.NullString{Valid: result.Signature != "", String: result.Signature}
+
+	updates := map[string]interface{}{
+		"scan_status":    result.Status,
+		"scan_signature": signature,
+		"scanned_at":     scannedAt,
+	}
+	if result.Status == enums.Infected {
+		updates["has_preview"] = false
+	}
+
+	if dbResult := database.Pg.Model(document).Unscoped().Updates(updates); dbResult.Error != nil {
+		return dbResult.Error
+	}
+	document.ScanStatus = result.Status
+	document.ScanSignature = signature
+	document.ScannedAt = scannedAt
+
+	if result.Status == enums.Infected {
+		document.HasPreview = false
+		if path, err := GetPath(&document.Folder.AppStoragePath, document.FolderID); err == nil {
+			_ = DeleteDocumentPreviewFiles(path, document)
+		}
+	}
+
+	return nil
+}
+
+// GetDocumentsToRescan method to get the documents that are not scanned since the time.
+// Documents that are never scanned are included.
+func GetDocumentsToRescan(appStoragePathID uint, before time.Time) ([]models.Document, error) {
+	documents := make([]models.Document, 0)
+
+	query := database.Pg.
+		Preload("Folder").
+		Preload("Folder.AppStoragePath").
+		Joins("JOIN folders ON documents.folder_id = folders.id").
+		Where("documents.scanned_at IS NULL OR documents.scanned_at < ?", before)
+	if appStoragePathID != 0 {
+		query = query.Where("folders.app_storage_path_id = ?", appStoragePathID)
+	}
+
+	if result := query.Order("documents.id").Find(&documents); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return documents, nil
+}
+
+// StartVirusRescans method to rescan the stored documents in the background at the interval
+// of CLAMD_RESCAN_INTERVAL, so files are checked against new signatures.
+// When several servers run, only one of them rescans per interval.
+func StartVirusRescans() {
+	value := os.Getenv("CLAMD_RESCAN_INTERVAL")
+	if !IsVirusScanEnabled() || value == "" {
+		return
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("Invalid CLAMD_RESCAN_INTERVAL %q: %v", value, err)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(virusRescanLockKey).Value("1").Nx().Ex(interval).Build())
+			if valkey.IsValkeyNil(result.Error()) {
+				continue
+			} else if result.Error() != nil {
+				log.Printf("Error locking the virus rescan: %v", result.Error())
+				continue
+			}
+
+			documents, err := GetDocumentsToRescan(0, time.Now().Add(-interval))
+			if err != nil {
+				log.Printf("Error getting the documents to rescan: %v", err)
+				continue
+			}
+
+			for i := range documents {
+				result, err := ScanDocument(&documents[i])
+				if err != nil {
+					log.Printf("Error rescanning document %d: %v", documents[i].ID, err)
+				} else if result.Status == enums.Infected {
+					log.Printf("Document %d is infected with %s and is quarantined.", documents[i].ID, result.Signature)
+				}
+			}
+		}
+	}()
+}
+
+// clamdNetwork splits the address of the scanner in its network and address.
+func clamdNetwork(address string) (string, string) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "/"):
+		return "unix", address
+	}
+
+	return "tcp", address
+}
+
+// parseClamdReply reads the verdict from a reply like "stream: OK" or "stream: Eicar-Signature FOUND".
+func parseClamdReply(reply string) (ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+
+	switch {
+	case verdict == "OK":
+		return ScanResult{Status: enums.Clean}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return ScanResult{Status: enums.Infected, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+
+	return ScanResult{}, fmt.Errorf("virus scanner failed: %s", reply)
+}
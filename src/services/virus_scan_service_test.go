@@ -0,0 +1,175 @@
+package services
+
+import (
+	"api-file/main/src/enums"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubClamd listens like clamd and answers an INSTREAM command with the reply.
+// The stream it received is sent on the channel, an empty reply is never sent.
+func stubClamd(t *testing.T, reply string) (string, <-chan []byte) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		stream, err := readInstream(bufio.NewReader(conn))
+		if err != nil {
+			t.Errorf("invalid INSTREAM: %v", err)
+			return
+		}
+		received <- stream
+
+		if reply == "" {
+			_, _ = io.Copy(io.Discard, conn)
+			return
+		}
+		_, _ = conn.Write([]byte(reply + "\x00"))
+	}()
+
+	return "tcp://" + listener.Addr().String(), received
+}
+
+// readInstream reads the command and the chunks that are prefixed with their length, until the zero length.
+func readInstream(reader *bufio.Reader) ([]byte, error) {
+	command, err := reader.ReadString(0)
+	if err != nil {
+		return nil, err
+	} else if command != "zINSTREAM\x00" {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var stream []byte
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, size); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(size)
+		if length == 0 {
+			return stream, nil
+		} else if length > virusScanChunkSize {
+			return nil, io.ErrShortBuffer
+		}
+
+		chunk := make([]byte, length)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		stream = append(stream, chunk...)
+	}
+}
+
+func TestScanReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		status    enums.ScanStatus
+		signature string
+		err       bool
+	}{
+		{name: "clean", reply: "stream: OK", status: enums.Clean},
+		{name: "infected", reply: "stream: Eicar-Signature FOUND", status: enums.Infected, signature: "Eicar-Signature"},
+		{name: "error reply", reply: "INSTREAM size limit exceeded. ERROR", err: true},
+	}
+
+	// The data is larger than a chunk, so it is sent in several chunks.
+	data := bytes.Repeat([]byte("0123456789"), virusScanChunkSize/4)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			address, received := stubClamd(t, test.reply)
+			t.Setenv("CLAMD_ADDRESS", address)
+			t.Setenv("CLAMD_TIMEOUT", "5s")
+
+			result, err := ScanReader(bytes.NewReader(data))
+			if test.err {
+				if err == nil || !strings.Contains(err.Error(), test.reply) {
+					t.Errorf("got error %v, want the reply %q", err, test.reply)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if result.Status != test.status || result.Signature != test.signature {
+				t.Errorf("got %+v, want %s %q", result, test.status, test.signature)
+			}
+
+			if stream := <-received; !bytes.Equal(stream, data) {
+				t.Errorf("scanner received %d bytes, want %d", len(stream), len(data))
+			}
+		})
+	}
+}
+
+func TestScanReaderTimeout(t *testing.T) {
+	address, _ := stubClamd(t, "")
+	t.Setenv("CLAMD_ADDRESS", address)
+	t.Setenv("CLAMD_TIMEOUT", "100ms")
+
+	start := time.Now()
+	if _, err := ScanReader(strings.NewReader("data")); err == nil {
+		t.Fatal("got no error, want a timeout")
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Errorf("got error %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("scan took %s, want it to stop at the timeout", elapsed)
+	}
+}
+
+func TestScanReaderDisabled(t *testing.T) {
+	t.Setenv("CLAMD_ADDRESS", "")
+
+	result, err := ScanReader(strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != enums.Unscanned {
+		t.Errorf("got %s, want unscanned", result.Status)
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		status    enums.ScanStatus
+		signature string
+		err       bool
+	}{
+		{reply: "stream: OK\x00", status: enums.Clean},
+		{reply: "stream: OK\n", status: enums.Clean},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND\x00", status: enums.Infected, signature: "Win.Test.EICAR_HDB-1"},
+		{reply: "stream: Can't allocate memory ERROR\x00", err: true},
+		{reply: "", err: true},
+	}
+
+	for _, test := range tests {
+		result, err := parseClamdReply(test.reply)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: got %+v, want an error", test.reply, result)
+			}
+		} else if err != nil {
+			t.Errorf("%q: %v", test.reply, err)
+		} else if result.Status != test.status || result.Signature != test.signature {
+			t.Errorf("%q: got %+v, want %s %q", test.reply, result, test.status, test.signature)
+		}
+	}
+}
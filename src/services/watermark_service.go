@@ -0,0 +1,211 @@
+package services
+
+import (
+	"api-file/main/src/models"
+	"api-file/main/src/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/h2non/bimg"
+)
+
+// watermarkMargin is the distance of the watermark to the border as a fraction of the smallest side.
+const watermarkMargin = 0.02
+
+// ErrWatermarkImage is returned when the image of a watermark can not be read.
+var ErrWatermarkImage = errors.New("watermark image is not a valid image")
+
+// ImageWatermarkedFilePath method to get the file path of the watermarked rendition of the image,
+// which is served instead of the original when the storage path has a watermark.
+func ImageWatermarkedFilePath(path, filename string) string {
+	return fmt.Sprintf("%s%s-watermarked.webp", path, filename)
+}
+
+// ImagePublicFilePath method to get the file path of the image that is served publicly:
+// the watermarked rendition when the storage path has a watermark, otherwise the rendition.
+// The image needs its folder with the storage path.
+func ImagePublicFilePath(path string, image *models.Image) string {
+	if image.Folder.AppStoragePath.Watermark.IsEnabled() {
+		return ImageWatermarkedFilePath(path, image.Name)
+	}
+
+	return ImageRenditionFilePath(path, image)
+}
+
+// ValidateWatermarkImage method to check if the image of a watermark can be drawn.
+// Vector images are refused, so an SVG can not load anything while it is rendered.
+func ValidateWatermarkImage(data []byte) error {
+	if IsVectorImage(data) {
+		return ErrWatermarkImage
+	}
+	if _, err := bimg.NewImage(data).Size(); err != nil {
+		return ErrWatermarkImage
+	}
+
+	return nil
+}
+
+// CreateImageWatermarkedFile method to write the watermarked rendition of the image at its full size
+// when the storage path has a watermark. Animated images get a still rendition of the first frame.
+func CreateImageWatermarkedFile(ctx context.Context, appStoragePath *models.AppStoragePath, folderID uint, filename string, data []byte, quality int) error {
+	if !appStoragePath.Watermark.IsEnabled() {
+		return nil
+	}
+
+	path, err := GetPathContext(ctx, appStoragePath, folderID)
+	if err != nil {
+		return err
+	}
+
+	_, span := tracing.Start(ctx, "bimg.watermark")
+	watermarked, err := createImageWatermarked(&appStoragePath.Watermark, data, quality)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	return WriteStorageFileContext(ctx, appStoragePath, ImageWatermarkedFilePath(path, filename), watermarked, nil)
+}
+
+// createImageWatermarked converts the image to WebP at its full size and draws the watermark on it.
+func createImageWatermarked(watermark *models.Watermark, data []byte, quality int) ([]byte, error) {
+	rendition, err := bimg.NewImage(data).Process(bimg.Options{
+		Type:           bimg.WEBP,
+		Quality:        quality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return watermarkImage(watermark, rendition, quality)
+}
+
+// regenerateImageWatermark writes the watermarked rendition of an image again from its source,
+// or removes it when the storage path has no watermark.
+func regenerateImageWatermark(path string, image *models.Image, data []byte, quality int) error {
+	defer func() { _ = DeleteImageFromCache(image.ID) }()
+
+	if !image.Folder.AppStoragePath.Watermark.IsEnabled() {
+		return RemoveFile(ImageWatermarkedFilePath(path, image.Name))
+	}
+
+	return CreateImageWatermarkedFile(context.Background(), &image.Folder.AppStoragePath, image.FolderID, image.Name, data, quality)
+}
+
+// RegenerateImageWatermark method to write the watermarked rendition of an image again from its source.
+func RegenerateImageWatermark(image *models.Image, quality int) error {
+	path, err := GetPath(&image.Folder.AppStoragePath, image.FolderID)
+	if err != nil {
+		return err
+	}
+
+	data, err := ReadImageSource(path, image)
+	if err != nil {
+		return err
+	}
+
+	return regenerateImageWatermark(path, image, data, quality)
+}
+
+// rewatermarkImage renders the public renditions of a copied or moved image again
+// when the watermark of its new storage path differs from the one it came from.
+func rewatermarkImage(image *models.Image, source *models.AppStoragePath) error {
+	if image.Broken || image.Folder.AppStoragePath.Watermark.Equal(&source.Watermark) {
+		return nil
+	}
+
+	return renderImageDerivatives(image, 0)
+}
+
+// RewatermarkImages method to render the public renditions of the images again as the work of a job,
+// after the watermark of their storage path is changed. A failing image does not stop the job.
+// The onProgress callback is called after each image.
+func RewatermarkImages(job *models.Job, images []models.Image, onProgress func(image *models.Image)) error {
+	for i := range images {
+		image := &images[i]
+		DeleteImageFilesFromCache(image)
+
+		if image.Broken {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: original is broken", image.ID)
+		} else if err := renderImageDerivatives(image, 0); err != nil {
+			job.Failed++
+			job.Error = fmt.Sprintf("image %d: %v", image.ID, err)
+		} else {
+			job.Done++
+		}
+
+		if err := SaveJob(job); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(image)
+		}
+	}
+
+	return nil
+}
+
+// watermarkImage draws the watermark on a rendition and converts it to WebP.
+// The watermark is scaled to a fraction of the width of the rendition.
+func watermarkImage(watermark *models.Watermark, data []byte, quality int) ([]byte, error) {
+	size, err := bimg.NewImage(data).Size()
+	if err != nil {
+		return nil, err
+	}
+
+	mark, err := renderWatermark(watermark, max(int(math.Round(float64(size.Width)*watermark.Scale)), 1))
+	if err != nil {
+		return nil, err
+	}
+	markSize, err := bimg.NewImage(mark).Size()
+	if err != nil {
+		return nil, err
+	}
+
+	margin := int(math.Round(float64(min(size.Width, size.Height)) * watermarkMargin))
+	x, y := watermark.Position.Offset()
+	left := margin + int(math.Round(float64(max(size.Width-markSize.Width-2*margin, 0))*x))
+	top := margin + int(math.Round(float64(max(size.Height-markSize.Height-2*margin, 0))*y))
+
+	return bimg.NewImage(data).Process(bimg.Options{
+		WatermarkImage: bimg.WatermarkImage{
+			Left:    left,
+			Top:     top,
+			Buf:     mark,
+			Opacity: float32(watermark.Opacity),
+		},
+		Type:           bimg.WEBP,
+		Quality:        quality,
+		Interpretation: bimg.InterpretationSRGB,
+		StripMetadata:  true,
+	})
+}
+
+// renderWatermark renders the image or text of the watermark as a PNG of the width.
+// Text is drawn in white with a dark outline, so it stays readable on light and dark images.
+func renderWatermark(watermark *models.Watermark, width int) ([]byte, error) {
+	if len(watermark.Image) > 0 {
+		return bimg.NewImage(watermark.Image).Process(bimg.Options{
+			Width:          width,
+			Type:           bimg.PNG,
+			Interpretation: bimg.InterpretationSRGB,
+			StripMetadata:  true,
+		})
+	}
+
+	fontSize := float64(width) / (0.6 * float64(max(utf8.RuneCountInString(watermark.Text), 1)))
+	height := max(int(math.Ceil(fontSize*1.3)), 1)
+	svg := fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+
+			`<text x="0" y="%.2f" font-family="sans-serif" font-size="%.2f" textLength="%d" lengthAdjust="spacingAndGlyphs" `+
+			`fill="#ffffff" stroke="#000000" stroke-opacity="0.6" stroke-width="%.2f">%s</text></svg>`,
+		width, height, fontSize, fontSize, width, fontSize/24, svgTextEscaper.Replace(watermark.Text))
+
+	return bimg.NewImage([]byte(svg)).Process(bimg.Options{Type: bimg.PNG})
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormSpanKey is the key of the span in the instance of a GORM statement.
+const gormSpanKey = "tracing:span"
+
+// rowsAffected is the attribute of the amount of rows that a query affected.
+var rowsAffected = attribute.Key("db.rows_affected")
+
+// GormPlugin is a GORM plugin that creates a span for each query.
+// The span is a child of the context of the query, see gorm.DB.WithContext.
+type GormPlugin struct{}
+
+// Name method to get the name of the plugin.
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize method to register the callbacks around each kind of query.
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"select", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before("tracing:before_"+processor.operation, startGormSpan(processor.operation)); err != nil {
+			return err
+		}
+		if err := processor.after("tracing:after_"+processor.operation, endGormSpan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startGormSpan starts the span of a query before it is executed.
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			return
+		}
+
+		name := "gorm." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := Start(ctx, name, semconv.DBSystemPostgreSQL, semconv.DBOperationName(strings.ToUpper(operation)))
+		if db.Statement.Table != "" {
+			span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+		}
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// endGormSpan ends the span of a query with its statement and the affected rows.
+func endGormSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()), rowsAffected.Int64(db.Statement.RowsAffected))
+
+	err := db.Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// dbSystemValkey is the database system attribute of the Valkey spans.
+var dbSystemValkey = attribute.String("db.system", "valkey")
+
+// valkeyClient is a Valkey client that creates a span for each command.
+type valkeyClient struct {
+	valkey.Client
+}
+
+// WrapValkey func to create a span for each command of the client.
+// The span is a child of the context of the command.
+func WrapValkey(client valkey.Client) valkey.Client {
+	return &valkeyClient{Client: client}
+}
+
+// Do method to run a command in a span.
+func (c *valkeyClient) Do(ctx context.Context, cmd valkey.Completed) valkey.ValkeyResult {
+	ctx, span := startValkeySpan(ctx, cmd.Commands())
+	result := c.Client.Do(ctx, cmd)
+	endValkeySpan(span, result.Error())
+
+	return result
+}
+
+// DoMulti method to run pipelined commands in a span.
+func (c *valkeyClient) DoMulti(ctx context.Context, multi ...valkey.Completed) []valkey.ValkeyResult {
+	commands := make([]string, 0, len(multi))
+	for i := range multi {
+		commands = append(commands, valkeyOperation(multi[i].Commands()))
+	}
+
+	ctx, span := Start(ctx, "valkey.pipeline", dbSystemValkey, attribute.StringSlice("db.operation.name", commands))
+	results := c.Client.DoMulti(ctx, multi...)
+	for _, result := range results {
+		if err := result.Error(); err != nil && !valkey.IsValkeyNil(err) {
+			End(span, err)
+			return results
+		}
+	}
+	span.End()
+
+	return results
+}
+
+// DoCache method to run a cacheable command in a span.
+func (c *valkeyClient) DoCache(ctx context.Context, cmd valkey.Cacheable, ttl time.Duration) valkey.ValkeyResult {
+	ctx, span := startValkeySpan(ctx, cmd.Commands())
+	result := c.Client.DoCache(ctx, cmd, ttl)
+	span.SetAttributes(attribute.Bool("db.valkey.cache_hit", result.IsCacheHit()))
+	endValkeySpan(span, result.Error())
+
+	return result
+}
+
+// startValkeySpan starts the span of a command, which is named after the operation without the keys and values.
+func startValkeySpan(ctx context.Context, commands []string) (context.Context, trace.Span) {
+	operation := valkeyOperation(commands)
+
+	return Start(ctx, "valkey."+strings.ToLower(operation), dbSystemValkey, attribute.String("db.operation.name", operation))
+}
+
+// endValkeySpan ends the span of a command. A missing key is not an error.
+func endValkeySpan(span trace.Span, err error) {
+	if valkey.IsValkeyNil(err) {
+		err = nil
+	}
+	End(span, err)
+}
+
+// valkeyOperation gets the operation of a command, which is its first word.
+func valkeyOperation(commands []string) string {
+	if len(commands) == 0 {
+		return ""
+	}
+
+	return commands[0]
+}
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"api-file/main/src/tracing"
+	"context"
 	"encoding/base64"
 	"errors"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Base64ToBytes func for convert base64 string to bytes.
@@ -11,6 +15,14 @@ func Base64ToBytes(value string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(value)
 }
 
+// Base64ToBytesContext func for convert base64 string to bytes in a span that is a child of the context.
+func Base64ToBytesContext(ctx context.Context, value string) (data []byte, err error) {
+	_, span := tracing.Start(ctx, "base64.decode", attribute.Int("base64.length", len(value)))
+	defer func() { tracing.End(span, err) }()
+
+	return Base64ToBytes(value)
+}
+
 // GetMimeTypeAndBase64 extracts the mimetype and data from a base64 string.
 func GetMimeTypeAndBase64(value string) (mimeType, data string, err error) {
 	if idx := strings.Index(value, ";base64,"); idx != -1 {